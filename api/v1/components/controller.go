@@ -34,4 +34,5 @@ type Controller struct {
 	// +kubebuilder:default:=30
 	MaxFastAttempts uint             `json:"maxFastAttempts,omitempty"`
 	SecurityContext *SecurityContext `json:"securityContext,omitempty"`
+	PodOverrides    *PodOverrides    `json:"podOverrides,omitempty"`
 }
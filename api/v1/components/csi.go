@@ -21,6 +21,7 @@ type DeploymentSpec struct {
 	Driver         *Driver         `json:"driver,omitempty"`
 	NodeController *NodeController `json:"nodeController,omitempty"`
 	Scheduler      *Scheduler      `json:"scheduler,omitempty"`
+	StorageClass   *StorageClass   `json:"storageClass,omitempty"`
 
 	// +nullable
 	// +optional
@@ -28,6 +29,12 @@ type DeploymentSpec struct {
 	// +nullable
 	// +optional
 	RegistrySecret string `json:"registrySecret"`
+	// RegistryMirrors maps source registry to its mirror, it's applied to GlobalRegistry and registries of images
+	// +optional
+	RegistryMirrors map[string]string `json:"registryMirrors,omitempty"`
+	// DigestResolution turns on resolution of image tags to digests
+	// +optional
+	DigestResolution *DigestResolution `json:"digestResolution,omitempty"`
 	// +kubebuilder:validation:Enum=IfNotPresent;Always;Never
 	// +kubebuilder:default:=IfNotPresent
 	PullPolicy string `json:"pullPolicy"`
@@ -23,4 +23,81 @@ type DriveMgr struct {
 	// +nullable
 	// +optional
 	Resources *ResourceRequirements `json:"resources,omitempty"`
+	// Type is a kind of drive manager
+	// +kubebuilder:default:=basemgr
+	// +optional
+	Type DriveMgrType `json:"type,omitempty"`
+	// Loopback is used to generate loopback-config ConfigMap for loopbackmgr,
+	// ConfigMap isn't managed by operator if not set
+	// +optional
+	Loopback *Loopback `json:"loopback,omitempty"`
+	// NodeGroups select another drive manager for groups of nodes,
+	// nodes which don't match any group use the drive manager above
+	// +optional
+	NodeGroups []DriveMgrNodeGroup `json:"nodeGroups,omitempty"`
+}
+
+// DriveMgrNodeGroup selects drive manager for nodes matched by NodeSelector,
+// node is assigned to the first matched group
+type DriveMgrNodeGroup struct {
+	// Name is used as a suffix of node DaemonSet name
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=20
+	Name string `json:"name"`
+	// NodeSelector is a set of node labels of the group
+	// +kubebuilder:validation:MinProperties=1
+	NodeSelector map[string]string `json:"nodeSelector"`
+	// Type is a kind of drive manager of the group, type of the drive manager above is used if not set
+	// +optional
+	Type DriveMgrType `json:"type,omitempty"`
+	// Image overrides drive manager image for the group
+	// +nullable
+	// +optional
+	Image *Image `json:"image,omitempty"`
+}
+
+// DriveMgrType is a kind of drive manager
+// +kubebuilder:validation:Enum=basemgr;loopbackmgr;idracmgr;halmgr
+type DriveMgrType string
+
+const (
+	// DriveMgrTypeBase discovers drives of the node with system utilities
+	DriveMgrTypeBase DriveMgrType = "basemgr"
+	// DriveMgrTypeLoopback emulates drives with loop devices for test clusters
+	DriveMgrTypeLoopback DriveMgrType = "loopbackmgr"
+	// DriveMgrTypeIDRAC discovers drives with iDRAC API
+	DriveMgrTypeIDRAC DriveMgrType = "idracmgr"
+	// DriveMgrTypeHAL discovers drives with hardware abstraction layer
+	DriveMgrTypeHAL DriveMgrType = "halmgr"
+)
+
+// Loopback encapsulates settings of loopbackmgr, which are passed via loopback-config ConfigMap
+type Loopback struct {
+	// DriveCount is a number of drives created on each node
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	DriveCount int `json:"driveCount,omitempty"`
+	// DriveSize is a size of drives, e.g. 302Mi
+	// +optional
+	DriveSize string `json:"driveSize,omitempty"`
+	// Nodes override settings for particular nodes
+	// +optional
+	Nodes []LoopbackNode `json:"nodes,omitempty"`
+}
+
+// LoopbackNode overrides loopbackmgr settings for the node
+type LoopbackNode struct {
+	// Name is a name of Kubernetes node
+	Name string `json:"name"`
+	// DriveCount is a number of drives created on the node
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	DriveCount int `json:"driveCount,omitempty"`
+	// DriveSize is a size of drives on the node
+	// +optional
+	DriveSize string `json:"driveSize,omitempty"`
+	// DriveType is a type of drives on the node, HDD is used if not set
+	// +kubebuilder:validation:Enum=HDD;SSD;NVME
+	// +optional
+	DriveType string `json:"driveType,omitempty"`
 }
@@ -22,7 +22,8 @@ type Driver struct {
 	Node        *Node        `json:"node,omitempty"`
 	Metrics     *Metrics     `json:"metrics,omitempty"`
 	LogReceiver *LogReceiver `json:"logReceiver,omitempty"`
-	// TODO need to implement this
+	// MountRootHost turns on mount of the host root filesystem to csi-baremetal-node at /hostroot, it's mounted if not set.
+	// Only paths required by the node driver are mounted if it's false
 	// +optional
-	MountRootHost bool `json:"mountRootHost"`
+	MountRootHost *bool `json:"mountRootHost,omitempty"`
 }
@@ -19,5 +19,22 @@ package components
 // Image contain information for components docker images
 type Image struct {
 	Name string `json:"name"`
-	Tag  string `json:"tag"`
+	// +optional
+	Tag string `json:"tag,omitempty"`
+	// Digest pins image content, e.g. sha256:<hex>. It's appended to the tagged name if both are set
+	// +optional
+	Digest string `json:"digest,omitempty"`
+	// Registry overrides GlobalRegistry for the image
+	// +optional
+	Registry string `json:"registry,omitempty"`
+}
+
+// DigestResolution contains settings of image tags resolution to digests
+type DigestResolution struct {
+	// Enable turns on resolution of image tags, which are not pinned by digest. Resolved digests are recorded
+	// in status of csi Deployment and used for all components until image reference is changed
+	Enable bool `json:"enable,omitempty"`
+	// Insecure allows to access registry over plain HTTP
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
 }
@@ -16,8 +16,46 @@ limitations under the License.
 
 package components
 
+// LogOutputType is a destination of the collected logs
+type LogOutputType string
+
+const (
+	// ForwardOutput sends logs to fluentd/fluent-bit instance using forward protocol
+	ForwardOutput LogOutputType = "forward"
+	// SyslogOutput sends logs to syslog (rsyslog) server
+	SyslogOutput LogOutputType = "syslog"
+	// StdoutOutput prints logs in stdout of log receiver container
+	StdoutOutput LogOutputType = "stdout"
+	// ElasticsearchOutput sends logs to Elasticsearch
+	ElasticsearchOutput LogOutputType = "es"
+)
+
 // LogReceiver encapsulates information needed to establish log receiver for components
 type LogReceiver struct {
-	Name  string `json:"name"`
-	Image *Image `json:"image,omitempty"`
+	// Enabled turns on fluent-bit sidecars in controller and node pods
+	// +optional
+	Enabled bool   `json:"enabled,omitempty"`
+	Name    string `json:"name"`
+	Image   *Image `json:"image,omitempty"`
+	// +optional
+	Output *LogOutput `json:"output,omitempty"`
+	// +nullable
+	// +optional
+	Resources *ResourceRequirements `json:"resources,omitempty"`
+}
+
+// LogOutput describes where log receiver sends collected logs
+type LogOutput struct {
+	// +kubebuilder:validation:Enum=forward;syslog;stdout;es
+	Type LogOutputType `json:"type"`
+	// +optional
+	Host string `json:"host,omitempty"`
+	// +optional
+	Port int32 `json:"port,omitempty"`
+	// Protocol is udp or tcp for syslog output, http or https for es output
+	// +optional
+	Protocol string `json:"protocol,omitempty"`
+	// CredentialsSecret is a name of Secret with username and password keys, used by es output over https
+	// +optional
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
 }
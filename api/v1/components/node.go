@@ -27,4 +27,11 @@ type Node struct {
 	// +optional
 	Resources         *ResourceRequirements `json:"resources,omitempty"`
 	PodSecurityPolicy *PodSecurityPolicy    `json:"podSecurityPolicy,omitempty"`
+	PodOverrides      *PodOverrides         `json:"podOverrides,omitempty"`
+	// WBT is used to generate node-config ConfigMap, default settings are applied if not set
+	// +optional
+	WBT *WBT `json:"wbt,omitempty"`
+	// Upgrade turns on canary-style upgrade of node pods, all node pods are updated at once if not set
+	// +optional
+	Upgrade *NodeUpgrade `json:"upgrade,omitempty"`
 }
@@ -23,5 +23,10 @@ type NodeController struct {
 	Log    *Log   `json:"log,omitempty"`
 	// +nullable
 	// +optional
-	Resources *ResourceRequirements `json:"resources,omitempty"`
+	Resources    *ResourceRequirements `json:"resources,omitempty"`
+	PodOverrides *PodOverrides         `json:"podOverrides,omitempty"`
+	// Metrics sets port of node-controller metrics endpoint, it's passed with --metrics-addr arg.
+	// node-controller listens on default port 8080 if not set, path is always /metrics
+	// +optional
+	Metrics *Metrics `json:"metrics,omitempty"`
 }
@@ -0,0 +1,36 @@
+/*
+Copyright © 2021 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+// NodeUpgrade encapsulates settings of orchestrated upgrade of csi-baremetal-node pods
+type NodeUpgrade struct {
+	// Enable switches node DaemonSets to OnDelete strategy, node pods are restarted by operator in waves
+	Enable bool `json:"enable"`
+	// CanaryNodes are names of nodes, which are upgraded in the first wave
+	// +optional
+	CanaryNodes []string `json:"canaryNodes,omitempty"`
+	// MaxUnavailable is a maximum number of not ready node pods during upgrade, 1 if not set
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxUnavailable int32 `json:"maxUnavailable,omitempty"`
+	// FailureDomainLabel is a node label, each wave contains nodes with the same label value only
+	// +optional
+	FailureDomainLabel string `json:"failureDomainLabel,omitempty"`
+	// WaveTimeout is a time for wave nodes to pass health gates, upgrade is paused if it is expired. 10m if not set
+	// +optional
+	WaveTimeout string `json:"waveTimeout,omitempty"`
+}
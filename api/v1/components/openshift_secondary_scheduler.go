@@ -19,4 +19,7 @@ package components
 // OpenshiftSecondaryScheduler represents information to deploy Openshift Secondary Scheduler if applicable
 type OpenshiftSecondaryScheduler struct {
 	Image *Image `json:"image,omitempty"`
+	// CoexistWithExisting allows to merge csi-baremetal extender into configuration
+	// of existing 3rd-party secondary scheduler instead of failing installation
+	CoexistWithExisting bool `json:"coexistWithExisting,omitempty"`
 }
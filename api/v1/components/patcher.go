@@ -24,7 +24,11 @@ type Patcher struct {
 	RestoreOnShutdown bool   `json:"restoreOnShutdown,omitempty"`
 	ConfigMapName     string `json:"configMapName,omitempty"`
 	ReadinessTimeout  int    `json:"readinessTimeout,omitempty"`
+	// MaxRetries is the number of patching retries per master before the original scheduler configuration is restored
+	// +kubebuilder:default:=3
+	MaxRetries int `json:"maxRetries,omitempty"`
 	// +nullable
 	// +optional
-	Resources *ResourceRequirements `json:"resources,omitempty"`
+	Resources    *ResourceRequirements `json:"resources,omitempty"`
+	PodOverrides *PodOverrides         `json:"podOverrides,omitempty"`
 }
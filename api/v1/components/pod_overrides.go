@@ -0,0 +1,53 @@
+/*
+Copyright © 2021 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PodOverrides contains pod template settings, which are merged into generated component pods
+type PodOverrides struct {
+	// Tolerations are appended to the default component tolerations
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// Affinity replaces the default component affinity
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// NodeSelector is merged into the default component node selector
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// PodAnnotations are merged into pod template annotations
+	// +optional
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+	// PodLabels are added to pod template labels, labels set by operator can't be overridden
+	// +optional
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+	// Env is added to each container of the pod
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// Volumes are appended to the pod volumes
+	// +optional
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+	// VolumeMounts are appended to each container of the pod, if their mount paths are not used
+	// +optional
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+}
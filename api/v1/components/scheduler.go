@@ -28,9 +28,13 @@ type Scheduler struct {
 	StorageProvisioner string   `json:"storageProvisioner"`
 
 	OpenshiftSecondaryScheduler *OpenshiftSecondaryScheduler `json:"openshiftSecondaryScheduler,omitempty"`
+	// ControlPlaneSelector is an additional selector of control-plane nodes,
+	// nodes with master or control-plane role labels are always treated as control-plane
+	ControlPlaneSelector *NodeSelector `json:"controlPlaneSelector,omitempty"`
 	// +nullable
 	// +optional
 	Resources         *ResourceRequirements `json:"resources,omitempty"`
 	SecurityContext   *SecurityContext      `json:"securityContext,omitempty"`
 	PodSecurityPolicy *PodSecurityPolicy    `json:"podSecurityPolicy,omitempty"`
+	PodOverrides      *PodOverrides         `json:"podOverrides,omitempty"`
 }
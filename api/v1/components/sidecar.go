@@ -33,4 +33,10 @@ type Args struct {
 	RetryIntervalStart string `json:"retryIntervalStart,omitempty"`
 	RetryIntervalMax   string `json:"retryIntervalMax,omitempty"`
 	WorkerThreads      int    `json:"workerThreads,omitempty"`
+	// MonitorInterval is an interval of volume health checks, used by health monitor sidecars
+	MonitorInterval string `json:"monitorInterval,omitempty"`
+	// ListVolumesInterval is an interval of ListVolumes calls, used by health monitor controller sidecar
+	ListVolumesInterval string `json:"listVolumesInterval,omitempty"`
+	// EnableNodeWatcher enables node failure events, used by health monitor controller sidecar
+	EnableNodeWatcher bool `json:"enableNodeWatcher,omitempty"`
 }
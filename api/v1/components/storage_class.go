@@ -0,0 +1,41 @@
+/*
+Copyright © 2021 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// StorageClass encapsulates configuration of StorageClasses created by operator for discovered drive types
+type StorageClass struct {
+	// Enable turns on management of StorageClasses by operator
+	Enable bool `json:"enable"`
+	// Name is a name of ANY StorageClass and a prefix of StorageClasses for other storage types
+	// +optional
+	Name string `json:"name,omitempty"`
+	// +optional
+	FSType string `json:"fsType,omitempty"`
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// +optional
+	ReclaimPolicy string `json:"reclaimPolicy,omitempty"`
+	// +optional
+	AllowedTopologies []corev1.TopologySelectorTerm `json:"allowedTopologies,omitempty"`
+	// Default is a storage type of StorageClass marked as default one, e.g. ANY or HDDLVG.
+	// Default StorageClass isn't set if empty
+	// +optional
+	Default string `json:"default,omitempty"`
+}
@@ -0,0 +1,52 @@
+/*
+Copyright © 2021 Dell Inc. or its subsidiaries. All Rights Reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+// WBT encapsulates writeback throttling settings of csi-baremetal-node, which are passed via node-config ConfigMap
+type WBT struct {
+	// Enable turns on changing of WBT value for acceptable volumes
+	Enable bool `json:"enable"`
+	// LatUsecValue is a value of wbt_lat_usec set for acceptable volumes
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	LatUsecValue uint32 `json:"latUsecValue,omitempty"`
+	// Modes is a list of acceptable volume modes
+	// +optional
+	Modes []WBTMode `json:"modes,omitempty"`
+	// StorageClasses is a list of acceptable StorageClass names
+	// +optional
+	StorageClasses []string `json:"storageClasses,omitempty"`
+	// EnableForAllKernels allows to change WBT value on nodes with any kernel version
+	// +optional
+	EnableForAllKernels bool `json:"enableForAllKernels,omitempty"`
+	// AcceptableKernels is a list of node kernel versions, used only if EnableForAllKernels is false
+	// +optional
+	AcceptableKernels []string `json:"acceptableKernels,omitempty"`
+}
+
+// WBTMode is a mode of volume, which WBT settings are applied for
+// +kubebuilder:validation:Enum=FS;RAW;RAWPART
+type WBTMode string
+
+const (
+	// WBTModeFS is used for filesystem volumes
+	WBTModeFS WBTMode = "FS"
+	// WBTModeRaw is used for block volumes
+	WBTModeRaw WBTMode = "RAW"
+	// WBTModeRawPart is used for partitioned block volumes
+	WBTModeRawPart WBTMode = "RAWPART"
+)
@@ -22,13 +22,153 @@ import (
 	"github.com/dell/csi-baremetal-operator/api/v1/components"
 )
 
+const (
+	// ForceUninstallAnnotation allows to remove csi Deployment without waiting
+	// for kube-scheduler configuration restore, if it's set to "true"
+	ForceUninstallAnnotation = "csi-baremetal.dell.com/force-uninstall"
+
+	// ResumeNodeUpgradeAnnotation resumes paused upgrade of node pods, if it's set to "true".
+	// The annotation is removed by operator after resuming
+	ResumeNodeUpgradeAnnotation = "csi-baremetal.dell.com/resume-node-upgrade"
+
+	// SkipCompatibilityCheckAnnotation allows to apply csi Deployment with versions,
+	// which are incompatible according to compatibility matrix, if it's set to "true"
+	SkipCompatibilityCheckAnnotation = "csi-baremetal.dell.com/skip-compatibility-check"
+
+	// PlanOnlyAnnotation makes operator compute changes of csi Deployment without applying them, if it's set to "true".
+	// Planned changes are reported in status.plan
+	PlanOnlyAnnotation = "csi-baremetal.dell.com/plan-only"
+
+	// AuditAutoRepairAnnotation allows CR auditor to fix safe inconsistencies of csi-baremetal CRs, if it's set to "true".
+	// Other inconsistencies are only reported
+	AuditAutoRepairAnnotation = "csi-baremetal.dell.com/audit-auto-repair"
+
+	// DegradedCondition is True when one or more csi-baremetal components don't work properly
+	DegradedCondition = "Degraded"
+	// SecondarySchedulerReadyCondition is True when Openshift Secondary Scheduler is restarted with csi-baremetal extender
+	SecondarySchedulerReadyCondition = "SecondarySchedulerReady"
+	// SnapshotReadyCondition is True when VolumeSnapshotClasses are created and snapshot controller is running
+	SnapshotReadyCondition = "SnapshotReady"
+	// NodeConfigValidCondition is False when node-config settings of csi Deployment are rejected by validation
+	NodeConfigValidCondition = "NodeConfigValid"
+	// LoopbackConfigValidCondition is False when loopback drive manager settings of csi Deployment are rejected by validation
+	LoopbackConfigValidCondition = "LoopbackConfigValid"
+	// DriveMgrTypeMatchedCondition is False when image name of drive manager contains another type than configured one
+	DriveMgrTypeMatchedCondition = "DriveMgrTypeMatched"
+	// CompatibleCondition is True when versions of csi-baremetal components, CRDs and Kubernetes
+	// are compatible with operator version
+	CompatibleCondition = "Compatible"
+	// ImageDigestsResolvedCondition is False when some image tags can't be resolved to digests
+	ImageDigestsResolvedCondition = "ImageDigestsResolved"
+	// ControllerHealthMonitorVerifiedCondition is False when controller ServiceAccount has insufficient cluster roles
+	// for volume health monitor sidecar, controller is deployed without the sidecar
+	ControllerHealthMonitorVerifiedCondition = "ControllerHealthMonitorVerified"
+	// NodeHealthMonitorVerifiedCondition is False when node ServiceAccount has insufficient cluster roles
+	// for volume health monitor sidecar, node is deployed without the sidecar
+	NodeHealthMonitorVerifiedCondition = "NodeHealthMonitorVerified"
+)
+
 // DeploymentStatus defines the observed state of Deployment
 type DeploymentStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// Conditions represent the latest available observations of the Deployment state
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// NodeUpgrade represents progress of orchestrated upgrade of node pods
+	// +optional
+	NodeUpgrade *NodeUpgradeStatus `json:"nodeUpgrade,omitempty"`
+
+	// ImageDigests maps image reference <registry>/<image_name>:<image_tag> to its resolved digest
+	// +optional
+	ImageDigests map[string]string `json:"imageDigests,omitempty"`
+
+	// Plan describes changes, which would be applied to the cluster, while csi Deployment is in plan-only mode
+	// +optional
+	Plan *DeploymentPlan `json:"plan,omitempty"`
+
+	// AppliedVersions maps operator and csi-baremetal components to versions, which changes were applied with.
+	// Compatibility matrix is enforced only if versions are changed
+	// +optional
+	AppliedVersions map[string]string `json:"appliedVersions,omitempty"`
+}
+
+// PlannedAction is an action, which operator would perform with the object
+type PlannedAction string
+
+const (
+	// PlannedCreate means that the object doesn't exist and would be created
+	PlannedCreate PlannedAction = "Create"
+	// PlannedUpdate means that the object would be updated
+	PlannedUpdate PlannedAction = "Update"
+	// PlannedRecreate means that immutable fields of the object are changed, so it would be removed and created again
+	PlannedRecreate PlannedAction = "Recreate"
+	// PlannedDelete means that the object is not needed anymore and would be removed
+	PlannedDelete PlannedAction = "Delete"
+)
+
+// DeploymentPlan defines changes computed in plan-only mode
+type DeploymentPlan struct {
+	// ObservedGeneration is a generation of csi Deployment, which the plan is computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Summary is a number of planned changes by action
+	// +optional
+	Summary string `json:"summary,omitempty"`
+	// +optional
+	Changes []PlannedChange `json:"changes,omitempty"`
+}
+
+// PlannedChange describes change of a single object
+type PlannedChange struct {
+	Action    PlannedAction `json:"action"`
+	Kind      string        `json:"kind"`
+	Namespace string        `json:"namespace,omitempty"`
+	Name      string        `json:"name"`
+	// Details describe changed fields of the object
+	// +optional
+	Details []string `json:"details,omitempty"`
+}
+
+// NodeUpgradePhase is a phase of orchestrated upgrade of node pods
+type NodeUpgradePhase string
+
+const (
+	// NodeUpgradeInProgress means that node pods of the current wave are restarted
+	NodeUpgradeInProgress NodeUpgradePhase = "InProgress"
+	// NodeUpgradePaused means that wave nodes didn't pass health gates in time
+	NodeUpgradePaused NodeUpgradePhase = "Paused"
+	// NodeUpgradeCompleted means that all node pods run the current DaemonSet revision
+	NodeUpgradeCompleted NodeUpgradePhase = "Completed"
+)
+
+// NodeUpgradeStatus defines the observed state of orchestrated upgrade of node pods
+type NodeUpgradeStatus struct {
+	// +optional
+	Phase NodeUpgradePhase `json:"phase,omitempty"`
+	// Wave is a number of the last started wave
+	// +optional
+	Wave int32 `json:"wave,omitempty"`
+	// WaveNodes are nodes, which pods were restarted in the current wave
+	// +optional
+	WaveNodes []string `json:"waveNodes,omitempty"`
+	// +optional
+	WaveStartTime *metav1.Time `json:"waveStartTime,omitempty"`
+	// UpdatedNodes is a number of node pods of the current DaemonSet revision
+	// +optional
+	UpdatedNodes int32 `json:"updatedNodes,omitempty"`
+	// TotalNodes is a number of node pods
+	// +optional
+	TotalNodes int32 `json:"totalNodes,omitempty"`
+	// Message describes failed health gates or the reason of waiting
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 // +kubebuilder:resource:shortName={bmcsi,bmcsis}
 // Deployment is the Schema for the deployments API
 type Deployment struct {
@@ -53,6 +193,7 @@ func (in *Deployment) DeepCopyInto(out *Deployment) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 func init() {
@@ -28,3 +28,12 @@ requests:
 {{- end }}
 {{- end }}
 {{- end }}
+
+{{- define "setImageLocation" }}
+{{- with .digest }}
+digest: {{ . }}
+{{- end }}
+{{- with .registry }}
+registry: {{ . }}
+{{- end }}
+{{- end }}
@@ -20,12 +20,15 @@ import (
 	"context"
 	"reflect"
 	"strings"
+	"time"
 
+	"github.com/dell/csi-baremetal/api/v1/drivecrd"
 	"github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -39,6 +42,7 @@ import (
 
 	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
 	"github.com/dell/csi-baremetal-operator/pkg"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
 	"github.com/dell/csi-baremetal-operator/pkg/constant"
 	"github.com/dell/csi-baremetal-operator/pkg/patcher"
 	"github.com/dell/csi-baremetal-operator/pkg/validator/rbac"
@@ -57,6 +61,11 @@ type DeploymentReconciler struct {
 
 const (
 	csiFinalizer = "dell.emc.csi/csi-deployment-cleanup"
+
+	// uninstallTimeout limits waiting for kube-scheduler configuration restore before finalizer removal
+	uninstallTimeout = 10 * time.Minute
+	// uninstallRequeueInterval is the interval to check kube-scheduler configuration restore
+	uninstallRequeueInterval = 15 * time.Second
 )
 
 // +kubebuilder:rbac:groups=csi-baremetal.dell.com,resources=deployments,verbs=get;list;watch;create;update;patch;delete
@@ -92,10 +101,19 @@ func (r *DeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	} else {
 		if containsFinalizer(deployment) {
-			if err = r.Uninstall(ctx, deployment); err != nil {
+			restored, err := r.Uninstall(ctx, deployment)
+			if err != nil {
 				log.Error(err, "Error uninstalling patcher")
 			}
 
+			if !restored {
+				if !isUninstallTimedOut(deployment) && !isForceUninstall(deployment) {
+					log.Info("Waiting for kube-scheduler configuration to be restored")
+					return ctrl.Result{RequeueAfter: uninstallRequeueInterval}, nil
+				}
+				log.Warn("Kube-scheduler configuration is not restored, finalizer is removed forcibly")
+			}
+
 			deployment.ObjectMeta.Finalizers = deleteFinalizer(deployment)
 			if err = r.Client.Update(ctx, deployment); err != nil {
 				log.Error(err, "Error removing finalizer")
@@ -107,9 +125,16 @@ func (r *DeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
+	result := ctrl.Result{}
 	if err = r.CSIDeployment.Update(ctx, deployment, r.Scheme); err != nil {
-		log.Error(err, "Unable to update deployment")
-		return ctrl.Result{Requeue: true}, err
+		// wait for external changes without blocking node reconciliation
+		requeueErr, ok := common.IsRequeueError(err)
+		if !ok {
+			log.Error(err, "Unable to update deployment")
+			return ctrl.Result{Requeue: true}, err
+		}
+		log.Infof("Deployment update is postponed: %s", requeueErr.Reason)
+		result.RequeueAfter = requeueErr.After
 	}
 
 	if err = r.CSIDeployment.ReconcileNodes(ctx, deployment); err != nil {
@@ -117,7 +142,7 @@ func (r *DeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{Requeue: true}, err
 	}
 
-	return ctrl.Result{}, nil
+	return result, nil
 }
 
 // SetupWithManager creates controller manager for CSI Deployment
@@ -156,6 +181,15 @@ func (r *DeploymentReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Ma
 		return err
 	}
 
+	// reconcile CSI Deployment if ConfigMap or Secret referenced by its components was changed,
+	// so config hash annotation of pod templates is updated
+	if err = watchReferencedConfig(c, r.Client, &corev1.ConfigMap{}, common.IsConfigMapReferenced, mgr); err != nil {
+		return err
+	}
+	if err = watchReferencedConfig(c, r.Client, &corev1.Secret{}, common.IsSecretReferenced, mgr); err != nil {
+		return err
+	}
+
 	// reconcile CSI Deployment if kube-scheduler or openshift secondary-scheduler pods were changed
 	err = c.Watch(source.Kind(mgr.GetCache(), &corev1.Pod{}), handler.EnqueueRequestsFromMapFunc(handler.MapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
 		var (
@@ -247,6 +281,36 @@ func (r *DeploymentReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Ma
 		return err
 	}
 
+	// reconcile CSI Deployment with managed StorageClasses if drive was created, removed or its type was changed
+	err = c.Watch(source.Kind(mgr.GetCache(), &drivecrd.Drive{}), handler.EnqueueRequestsFromMapFunc(handler.MapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+		deployments := &csibaremetalv1.DeploymentList{}
+		if err := r.Client.List(ctx, deployments); err != nil {
+			return []reconcile.Request{}
+		}
+
+		var requests []reconcile.Request
+		for _, dep := range deployments.Items {
+			if dep.Spec.StorageClass == nil || !dep.Spec.StorageClass.Enable {
+				continue
+			}
+
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      dep.Name,
+					Namespace: dep.Namespace,
+				}})
+		}
+
+		return requests
+	})), predicate.Funcs{
+		UpdateFunc: func(updateEvent event.UpdateEvent) bool {
+			return isDriveChanged(updateEvent.ObjectOld, updateEvent.ObjectNew)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
 	if err = watchRole(c, r.Client, r.Matcher, r.MatchPodSecurityPolicyTemplate, r.MatchSecurityContextConstraintsPolicies, r.Log, mgr); err != nil {
 		return err
 	}
@@ -265,6 +329,57 @@ func (r *DeploymentReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Ma
 	return nil
 }
 
+// watchReferencedConfig enqueues csi Deployments, which Deployments or DaemonSets reference the object in pod template
+func watchReferencedConfig(c controller.Controller, cl client.Client, obj client.Object,
+	isReferenced func(spec *corev1.PodSpec, name string) bool, mgr ctrl.Manager) error {
+	return c.Watch(source.Kind(mgr.GetCache(), obj), handler.EnqueueRequestsFromMapFunc(handler.MapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+		var (
+			deployments     = &csibaremetalv1.DeploymentList{}
+			appsDeployments = &appsv1.DeploymentList{}
+			daemonSets      = &appsv1.DaemonSetList{}
+			owners          = map[types.UID]bool{}
+		)
+
+		if err := cl.List(ctx, deployments, client.InNamespace(obj.GetNamespace())); err != nil || len(deployments.Items) == 0 {
+			return []reconcile.Request{}
+		}
+		if err := cl.List(ctx, appsDeployments, client.InNamespace(obj.GetNamespace())); err != nil {
+			return []reconcile.Request{}
+		}
+		if err := cl.List(ctx, daemonSets, client.InNamespace(obj.GetNamespace())); err != nil {
+			return []reconcile.Request{}
+		}
+
+		// UIDs of controllers, which components use the object
+		addOwner := func(component metav1.Object, spec *corev1.PodSpec) {
+			if owner := metav1.GetControllerOf(component); owner != nil && isReferenced(spec, obj.GetName()) {
+				owners[owner.UID] = true
+			}
+		}
+		for i := range appsDeployments.Items {
+			addOwner(&appsDeployments.Items[i], &appsDeployments.Items[i].Spec.Template.Spec)
+		}
+		for i := range daemonSets.Items {
+			addOwner(&daemonSets.Items[i], &daemonSets.Items[i].Spec.Template.Spec)
+		}
+
+		var requests []reconcile.Request
+		for _, dep := range deployments.Items {
+			if !owners[dep.UID] {
+				continue
+			}
+
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      dep.Name,
+					Namespace: dep.Namespace,
+				}})
+		}
+
+		return requests
+	})))
+}
+
 func watchRole(c controller.Controller, cl client.Client, m rbac.Matcher,
 	matchPodSecurityPolicyTemplate rbacv1.PolicyRule, matchSecurityContextConstraintsPolicies []rbacv1.PolicyRule,
 	log *logrus.Entry, mgr ctrl.Manager) error {
@@ -386,6 +501,25 @@ func watchRoleBinding(c controller.Controller, cl client.Client, m rbac.Matcher,
 	})))
 }
 
+// isDriveChanged checks drive fields, which define StorageClasses to create
+func isDriveChanged(old runtime.Object, new runtime.Object) bool {
+	var (
+		oldDrive *drivecrd.Drive
+		newDrive *drivecrd.Drive
+		ok       bool
+	)
+	if oldDrive, ok = old.(*drivecrd.Drive); !ok {
+		return false
+	}
+	if newDrive, ok = new.(*drivecrd.Drive); !ok {
+		return false
+	}
+
+	return oldDrive.Spec.Type != newDrive.Spec.Type ||
+		oldDrive.Spec.IsSystem != newDrive.Spec.IsSystem ||
+		oldDrive.Spec.Usage != newDrive.Spec.Usage
+}
+
 func isNodeChanged(old runtime.Object, new runtime.Object) bool {
 	var (
 		oldNode *corev1.Node
@@ -426,6 +560,14 @@ func containsFinalizer(csiDep *csibaremetalv1.Deployment) bool {
 	return false
 }
 
+func isUninstallTimedOut(csiDep *csibaremetalv1.Deployment) bool {
+	return csiDep.GetDeletionTimestamp() != nil && time.Since(csiDep.GetDeletionTimestamp().Time) > uninstallTimeout
+}
+
+func isForceUninstall(csiDep *csibaremetalv1.Deployment) bool {
+	return csiDep.GetAnnotations()[csibaremetalv1.ForceUninstallAnnotation] == "true"
+}
+
 func deleteFinalizer(csiDep *csibaremetalv1.Deployment) []string {
 	result := make([]string, 0)
 	for _, finalizer := range csiDep.ObjectMeta.Finalizers {
@@ -20,7 +20,11 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/dell/csi-baremetal/pkg/events/recorder"
 	"github.com/sirupsen/logrus"
@@ -29,7 +33,10 @@ import (
 	"k8s.io/client-go/kubernetes"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	"github.com/dell/csi-baremetal-operator/controllers"
@@ -37,6 +44,10 @@ import (
 	"github.com/dell/csi-baremetal-operator/pkg/acrvalidator"
 	"github.com/dell/csi-baremetal-operator/pkg/common"
 	"github.com/dell/csi-baremetal-operator/pkg/constant"
+	"github.com/dell/csi-baremetal-operator/pkg/crauditor"
+	"github.com/dell/csi-baremetal-operator/pkg/extenderprobe"
+	"github.com/dell/csi-baremetal-operator/pkg/render"
+	"github.com/dell/csi-baremetal-operator/pkg/supportbundle"
 	"github.com/dell/csi-baremetal-operator/pkg/validator/rbac"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -59,7 +70,144 @@ func InitLogger(logLevel string) *logrus.Logger {
 	return logger
 }
 
+// runRender prints objects, which operator creates for csi Deployment, without access to cluster
+func runRender(args []string) error {
+	var deploymentPath, nodesPath, platform, kubernetesVersion, logLevel string
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	flags.StringVar(&deploymentPath, "deployment", "", "Path to csi Deployment YAML, \"-\" reads it from stdin")
+	flags.StringVar(&nodesPath, "nodes", "", "Path to Node or NodeList YAML, e.g. output of \"kubectl get nodes -o yaml\"")
+	flags.StringVar(&platform, "platform", "", "Platform overriding the one of csi Deployment: vanilla, rke or openshift")
+	flags.StringVar(&kubernetesVersion, "kubernetes-version", render.DefaultKubernetesVersion, "Version of Kubernetes API server")
+	flags.StringVar(&logLevel, "loglevel", "warning", "Log level of operator components, logs are written to stderr")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if deploymentPath == "" {
+		return fmt.Errorf("--deployment is required")
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if deploymentPath == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(filepath.Clean(deploymentPath))
+	}
+	if err != nil {
+		return err
+	}
+	csi, err := render.ParseDeployment(data)
+	if err != nil {
+		return err
+	}
+
+	opts := render.Options{Platform: platform, KubernetesVersion: kubernetesVersion}
+	if nodesPath != "" {
+		if data, err = os.ReadFile(filepath.Clean(nodesPath)); err != nil {
+			return err
+		}
+		if opts.Nodes, err = render.ParseNodes(data); err != nil {
+			return err
+		}
+	}
+
+	return render.Render(context.Background(), csi, opts, os.Stdout, InitLogger(logLevel))
+}
+
+// runSupportBundle collects csi-baremetal state, events and logs from cluster into tar.gz archive
+func runSupportBundle(args []string) error {
+	var outputPath, kubeconfig, namespaces, logLevel string
+	var logLines int64
+	flags := flag.NewFlagSet("support-bundle", flag.ExitOnError)
+	flags.StringVar(&outputPath, "output",
+		fmt.Sprintf("csi-baremetal-support-bundle-%s.tar.gz", time.Now().UTC().Format("20060102-150405")),
+		"Path to archive, \"-\" writes it to stdout")
+	flags.StringVar(&kubeconfig, "kubeconfig", "", "Path to kubeconfig, in-cluster or default config is used if it's empty")
+	flags.StringVar(&namespaces, "namespaces", "",
+		"Comma-separated namespaces collected in addition to namespaces of csi Deployments")
+	flags.Int64Var(&logLines, "log-lines", supportbundle.DefaultLogLines,
+		"Number of last log lines collected for each container, 0 collects all lines")
+	flags.StringVar(&logLevel, "loglevel", "info", "Log level, logs are written to stderr")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var (
+		config *rest.Config
+		err    error
+	)
+	if kubeconfig != "" {
+		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	} else {
+		config, err = ctrl.GetConfig()
+	}
+	if err != nil {
+		return err
+	}
+
+	scheme, err := common.PrepareScheme()
+	if err != nil {
+		return err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	cl, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	logger := InitLogger(logLevel)
+	logger.SetOutput(os.Stderr)
+	collector := &supportbundle.Collector{
+		Clientset: clientset,
+		Client:    cl,
+		Scheme:    scheme,
+		LogLines:  logLines,
+		Entry:     logger.WithField("component", "SupportBundle"),
+	}
+	for _, ns := range strings.Split(namespaces, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			collector.Namespaces = append(collector.Namespaces, ns)
+		}
+	}
+
+	if outputPath == "-" {
+		return collector.Collect(context.Background(), os.Stdout)
+	}
+	out, err := os.Create(filepath.Clean(outputPath))
+	if err != nil {
+		return err
+	}
+	if err = collector.Collect(context.Background(), out); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	logger.Infof("Support bundle is written to %s", outputPath)
+	return nil
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		subcommands := map[string]func([]string) error{
+			"render":         runRender,
+			"support-bundle": runSupportBundle,
+		}
+		if run, ok := subcommands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	var metricsAddr string
 	var enableLeaderElection bool
 	var logLevel string
@@ -109,6 +257,7 @@ func main() {
 	}
 
 	acrvalidator.LauncACRValidation(mgr.GetClient(), logrus.WithField("component", "acr_validator"))
+	extenderprobe.LaunchExtenderProbing(mgr.GetClient(), logrus.WithField("component", "extender_probe"))
 
 	ctx := context.Background()
 	logger := InitLogger(logLevel)
@@ -121,6 +270,8 @@ func main() {
 		setupLog.Error(err, "unable to setup event recorder")
 		os.Exit(1)
 	}
+	crauditor.LaunchCRAuditing(mgr.GetClient(), eventRecorder, logrus.WithField("component", "cr_auditor"))
+
 	matcher := rbac.NewMatcher()
 	matchSecurityContextConstraintsPolicies := []rbacv1.PolicyRule{
 		{
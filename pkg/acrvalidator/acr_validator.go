@@ -65,6 +65,7 @@ func (v *ACRValidator) validateACRs() {
 			if err != nil {
 				v.Log.Errorf("failed to delete ACR %s: %s", acr.GetName(), err.Error())
 			} else {
+				acrsDeleted.Inc()
 				v.Log.Infof("ACR %s was successfully deleted", acr.GetName())
 			}
 		}
@@ -72,7 +73,7 @@ func (v *ACRValidator) validateACRs() {
 }
 
 func (v *ACRValidator) needToRemoveACR(ctx context.Context, acr *acrcrd.AvailableCapacityReservation) bool {
-	ns, podName := getPodName(acr)
+	ns, podName := GetPodName(acr)
 
 	pod := &corev1.Pod{}
 	err := v.Client.Get(ctx, client.ObjectKey{Name: podName, Namespace: ns}, pod)
@@ -96,9 +97,9 @@ func (v *ACRValidator) needToRemoveACR(ctx context.Context, acr *acrcrd.Availabl
 	return false
 }
 
-// getPodName returns namespace and pod names for passed acr
+// GetPodName returns namespace and pod names for passed acr
 // must be synced with https://github.com/dell/csi-baremetal/blob/4c0c38da3cdb57a214e63c8ef1373bff8841db49/pkg/scheduler/extender/extender.go#L356
-func getPodName(acr *acrcrd.AvailableCapacityReservation) (string, string) {
+func GetPodName(acr *acrcrd.AvailableCapacityReservation) (string, string) {
 	namespace := acr.Spec.Namespace
 	pod := strings.Replace(acr.GetName(), namespace+"-", "", 1)
 
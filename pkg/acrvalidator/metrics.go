@@ -0,0 +1,26 @@
+package acrvalidator
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	metricsSubsystem = "acr_validator"
+)
+
+var (
+	// acrsDeleted counts ACRs removed because their pods are scheduled or deleted
+	acrsDeleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: constant.MetricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "deleted_total",
+		Help:      "Number of AvailableCapacityReservations deleted by validator",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(acrsDeleted)
+}
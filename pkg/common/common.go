@@ -6,6 +6,7 @@ import (
 	openshiftv1 "github.com/openshift/api/config/v1"
 	ssv1 "github.com/openshift/secondary-scheduler-operator/pkg/apis/secondaryscheduler/v1"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -52,15 +53,41 @@ func MatchLogFormat(format components.Format) string {
 	}
 }
 
-// ConstructFullImageName returns name of image in the following format: <registry>/<image_name>:<image_tag>
-func ConstructFullImageName(image *components.Image, registry string) string {
+// ConstructFullImageName returns name of image in the following format: <registry>/<image_name>:<image_tag>@<image_digest>.
+// Digest is taken from status of csi Deployment if it isn't set for the image, but was resolved by operator
+func ConstructFullImageName(image *components.Image, csi *csibaremetalv1.Deployment) string {
+	imageName := ConstructImageReference(image, &csi.Spec)
+
+	digest := image.Digest
+	if digest == "" {
+		digest = csi.Status.ImageDigests[imageName]
+	}
+	if digest != "" {
+		imageName += "@" + digest
+	}
+	return imageName
+}
+
+// ConstructImageReference returns name of image in the following format: <registry>/<image_name>:<image_tag>,
+// registry of the image overrides the global one and is replaced by its mirror if it's set
+func ConstructImageReference(image *components.Image, spec *components.DeploymentSpec) string {
 	var imageName string
 
+	registry := spec.GlobalRegistry
+	if image.Registry != "" {
+		registry = image.Registry
+	}
+	if mirror, ok := spec.RegistryMirrors[registry]; ok {
+		registry = mirror
+	}
 	if registry != "" {
 		imageName += registry + "/"
 	}
 
-	imageName += image.Name + ":" + image.Tag
+	imageName += image.Name
+	if image.Tag != "" {
+		imageName += ":" + image.Tag
+	}
 	return imageName
 }
 
@@ -112,6 +139,9 @@ func PrepareScheme() (*runtime.Scheme, error) {
 	if err := ssv1.AddToScheme(scheme); err != nil {
 		return nil, err
 	}
+	if err := apiextensionsv1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
 
 	if err := csibaremetalv1.AddToScheme(scheme); err != nil {
 		return nil, err
@@ -172,3 +202,14 @@ func ConstructResourceRequirements(resources *components.ResourceRequirements) c
 	}
 	return corev1.ResourceRequirements{}
 }
+
+// RemoveContainer removes container with the name from pod template
+func RemoveContainer(template *corev1.PodTemplateSpec, name string) {
+	containers := template.Spec.Containers[:0]
+	for _, container := range template.Spec.Containers {
+		if container.Name != name {
+			containers = append(containers, container)
+		}
+	}
+	template.Spec.Containers = containers
+}
@@ -3,7 +3,9 @@ package common
 import (
 	"testing"
 
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
 	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -62,3 +64,49 @@ func Test_MatchFormat(t *testing.T) {
 		})
 	}
 }
+
+func Test_Metrics(t *testing.T) {
+	assert.Equal(t, int32(constant.PrometheusPort), MetricsPort(nil))
+	assert.Equal(t, constant.PrometheusPath, MetricsPath(nil))
+	assert.Equal(t, int32(constant.PrometheusPort), MetricsPort(&components.Metrics{Path: "/custom"}))
+	assert.Equal(t, int32(9000), MetricsPort(&components.Metrics{Port: 9000}))
+	assert.Equal(t, "/custom", MetricsPath(&components.Metrics{Path: "/custom"}))
+	assert.Equal(t, map[string]string{
+		"prometheus.io/scrape": "true",
+		"prometheus.io/port":   "9000",
+		"prometheus.io/path":   "/custom",
+	}, MetricsAnnotations(9000, "/custom"))
+}
+
+func Test_ConstructFullImageName(t *testing.T) {
+	csi := &csibaremetalv1.Deployment{
+		Spec: components.DeploymentSpec{
+			GlobalRegistry:  "registry.example.com",
+			RegistryMirrors: map[string]string{"quay.io": "mirror.local:5000/quay"},
+		},
+		Status: csibaremetalv1.DeploymentStatus{
+			ImageDigests: map[string]string{"registry.example.com/resolved:1.0.0": "sha256:resolved"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		image    *components.Image
+		expected string
+	}{
+		{"Global registry", &components.Image{Name: "node", Tag: "1.0.0"}, "registry.example.com/node:1.0.0"},
+		{"Image registry", &components.Image{Name: "node", Tag: "1.0.0", Registry: "docker.io"}, "docker.io/node:1.0.0"},
+		{"Mirror", &components.Image{Name: "node", Tag: "1.0.0", Registry: "quay.io"}, "mirror.local:5000/quay/node:1.0.0"},
+		{"Digest", &components.Image{Name: "node", Tag: "1.0.0", Digest: "sha256:abc"}, "registry.example.com/node:1.0.0@sha256:abc"},
+		{"Digest without tag", &components.Image{Name: "node", Digest: "sha256:abc"}, "registry.example.com/node@sha256:abc"},
+		{"Resolved digest", &components.Image{Name: "resolved", Tag: "1.0.0"}, "registry.example.com/resolved:1.0.0@sha256:resolved"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ConstructFullImageName(tt.image, csi))
+		})
+	}
+
+	csi.Spec.GlobalRegistry = ""
+	assert.Equal(t, "node:1.0.0", ConstructFullImageName(&components.Image{Name: "node", Tag: "1.0.0"}, csi))
+}
@@ -0,0 +1,166 @@
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	configMapRef = "configmap/"
+	secretRef    = "secret/"
+)
+
+// AddConfigHashAnnotation sets checksum of ConfigMaps and Secrets referenced by pod template as its annotation,
+// so pods are restarted when their content is changed. Missing objects are hashed as empty.
+// Excluded ConfigMaps are not taken into account, e.g. if pods are restarted by other annotation
+func AddConfigHashAnnotation(ctx context.Context, client kubernetes.Interface, csi *csibaremetalv1.Deployment,
+	template *corev1.PodTemplateSpec, excludedConfigMaps ...string) error {
+	excluded := map[string]bool{}
+	for _, name := range excludedConfigMaps {
+		excluded[configMapRef+name] = true
+	}
+
+	var refs []string
+	for ref := range getConfigRefs(&template.Spec) {
+		if !excluded[ref] {
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+	sort.Strings(refs)
+
+	hash := sha256.New()
+	for _, ref := range refs {
+		hash.Write([]byte(ref))
+
+		data, err := getConfigData(ctx, client, csi.GetNamespace(), ref)
+		if err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			hash.Write([]byte(key))
+			hash.Write(data[key])
+		}
+	}
+
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[constant.ConfigHashAnnotation] = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
+
+// IsConfigMapReferenced checks if ConfigMap is used in volumes or environment of pod
+func IsConfigMapReferenced(spec *corev1.PodSpec, name string) bool {
+	return getConfigRefs(spec)[configMapRef+name]
+}
+
+// IsSecretReferenced checks if Secret is used in volumes or environment of pod
+func IsSecretReferenced(spec *corev1.PodSpec, name string) bool {
+	return getConfigRefs(spec)[secretRef+name]
+}
+
+// getConfigRefs returns ConfigMaps and Secrets used in volumes and environment of containers
+func getConfigRefs(spec *corev1.PodSpec) map[string]bool {
+	refs := map[string]bool{}
+	for _, volume := range spec.Volumes {
+		if volume.ConfigMap != nil {
+			refs[configMapRef+volume.ConfigMap.Name] = true
+		}
+		if volume.Secret != nil {
+			refs[secretRef+volume.Secret.SecretName] = true
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					refs[configMapRef+source.ConfigMap.Name] = true
+				}
+				if source.Secret != nil {
+					refs[secretRef+source.Secret.Name] = true
+				}
+			}
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				refs[configMapRef+envFrom.ConfigMapRef.Name] = true
+			}
+			if envFrom.SecretRef != nil {
+				refs[secretRef+envFrom.SecretRef.Name] = true
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				refs[configMapRef+env.ValueFrom.ConfigMapKeyRef.Name] = true
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				refs[secretRef+env.ValueFrom.SecretKeyRef.Name] = true
+			}
+		}
+	}
+	return refs
+}
+
+// getConfigData returns content of ConfigMap or Secret, nil if it's not found
+func getConfigData(ctx context.Context, client kubernetes.Interface, namespace, ref string) (map[string][]byte, error) {
+	data := map[string][]byte{}
+	switch {
+	case strings.HasPrefix(ref, configMapRef):
+		// ConfigMaps, which would be changed in plan-only mode, are hashed with planned data
+		if planned, ok := GetPlan(ctx).getConfigMapData(namespace, strings.TrimPrefix(ref, configMapRef)); ok {
+			for key, value := range planned {
+				data[key] = []byte(value)
+			}
+			return data, nil
+		}
+		cm, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, strings.TrimPrefix(ref, configMapRef), metav1.GetOptions{})
+		if err != nil {
+			if apiErrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		for key, value := range cm.Data {
+			data[key] = []byte(value)
+		}
+		for key, value := range cm.BinaryData {
+			data[key] = value
+		}
+	default:
+		secret, err := client.CoreV1().Secrets(namespace).Get(ctx, strings.TrimPrefix(ref, secretRef), metav1.GetOptions{})
+		if err != nil {
+			if apiErrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		for key, value := range secret.Data {
+			data[key] = value
+		}
+	}
+	return data, nil
+}
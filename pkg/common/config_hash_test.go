@@ -0,0 +1,144 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const hashTestNamespace = "test"
+
+var hashTestDeployment = &csibaremetalv1.Deployment{
+	ObjectMeta: metav1.ObjectMeta{Name: "csi-baremetal", Namespace: hashTestNamespace},
+}
+
+func newHashTestTemplate() *coreV1.PodTemplateSpec {
+	return &coreV1.PodTemplateSpec{
+		Spec: coreV1.PodSpec{
+			Volumes: []coreV1.Volume{
+				{Name: "config", VolumeSource: coreV1.VolumeSource{
+					ConfigMap: &coreV1.ConfigMapVolumeSource{LocalObjectReference: coreV1.LocalObjectReference{Name: "config"}},
+				}},
+				{Name: "readiness", VolumeSource: coreV1.VolumeSource{
+					ConfigMap: &coreV1.ConfigMapVolumeSource{LocalObjectReference: coreV1.LocalObjectReference{Name: "readiness"}},
+				}},
+			},
+			Containers: []coreV1.Container{
+				{Name: "test", Env: []coreV1.EnvVar{
+					{Name: "TOKEN", ValueFrom: &coreV1.EnvVarSource{
+						SecretKeyRef: &coreV1.SecretKeySelector{LocalObjectReference: coreV1.LocalObjectReference{Name: "token"}, Key: "token"},
+					}},
+				}},
+			},
+		},
+	}
+}
+
+func newHashTestConfigMap(name, value string) *coreV1.ConfigMap {
+	return &coreV1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: hashTestNamespace},
+		Data:       map[string]string{"key": value},
+	}
+}
+
+func getConfigHash(t *testing.T, objects ...coreV1.ConfigMap) string {
+	client := fake.NewSimpleClientset(&coreV1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: hashTestNamespace},
+		Data:       map[string][]byte{"token": []byte("token")},
+	})
+	for i := range objects {
+		_, err := client.CoreV1().ConfigMaps(hashTestNamespace).Create(context.Background(), &objects[i], metav1.CreateOptions{})
+		assert.Nil(t, err)
+	}
+
+	template := newHashTestTemplate()
+	assert.Nil(t, AddConfigHashAnnotation(context.Background(), client, hashTestDeployment, template, "readiness"))
+	return template.Annotations[constant.ConfigHashAnnotation]
+}
+
+func Test_AddConfigHashAnnotation(t *testing.T) {
+	t.Run("Hash is stable", func(t *testing.T) {
+		first := getConfigHash(t, *newHashTestConfigMap("config", "a"))
+		assert.NotEmpty(t, first)
+		assert.Equal(t, first, getConfigHash(t, *newHashTestConfigMap("config", "a")))
+	})
+
+	t.Run("Hash is changed with ConfigMap", func(t *testing.T) {
+		assert.NotEqual(t,
+			getConfigHash(t, *newHashTestConfigMap("config", "a")),
+			getConfigHash(t, *newHashTestConfigMap("config", "b")))
+	})
+
+	t.Run("Missing ConfigMap", func(t *testing.T) {
+		assert.NotEqual(t, getConfigHash(t), getConfigHash(t, *newHashTestConfigMap("config", "a")))
+	})
+
+	t.Run("Excluded ConfigMap is ignored", func(t *testing.T) {
+		assert.Equal(t,
+			getConfigHash(t, *newHashTestConfigMap("config", "a"), *newHashTestConfigMap("readiness", "a")),
+			getConfigHash(t, *newHashTestConfigMap("config", "a"), *newHashTestConfigMap("readiness", "b")))
+	})
+
+	t.Run("Hash is changed with Secret", func(t *testing.T) {
+		client := fake.NewSimpleClientset(&coreV1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: hashTestNamespace},
+			Data:       map[string][]byte{"token": []byte("changed")},
+		})
+		template := newHashTestTemplate()
+		assert.Nil(t, AddConfigHashAnnotation(context.Background(), client, hashTestDeployment, template, "readiness"))
+		assert.NotEqual(t, getConfigHash(t), template.Annotations[constant.ConfigHashAnnotation])
+	})
+
+	t.Run("No references", func(t *testing.T) {
+		template := &coreV1.PodTemplateSpec{}
+		assert.Nil(t, AddConfigHashAnnotation(context.Background(), fake.NewSimpleClientset(), hashTestDeployment, template))
+		assert.Nil(t, template.Annotations)
+	})
+}
+
+func Test_getConfigRefs(t *testing.T) {
+	spec := &coreV1.PodSpec{
+		Volumes: []coreV1.Volume{
+			{Name: "projected", VolumeSource: coreV1.VolumeSource{Projected: &coreV1.ProjectedVolumeSource{
+				Sources: []coreV1.VolumeProjection{
+					{ConfigMap: &coreV1.ConfigMapProjection{LocalObjectReference: coreV1.LocalObjectReference{Name: "projected-cm"}}},
+					{Secret: &coreV1.SecretProjection{LocalObjectReference: coreV1.LocalObjectReference{Name: "projected-secret"}}},
+				},
+			}}},
+			{Name: "secret", VolumeSource: coreV1.VolumeSource{Secret: &coreV1.SecretVolumeSource{SecretName: "volume-secret"}}},
+		},
+		InitContainers: []coreV1.Container{
+			{Name: "init", EnvFrom: []coreV1.EnvFromSource{
+				{ConfigMapRef: &coreV1.ConfigMapEnvSource{LocalObjectReference: coreV1.LocalObjectReference{Name: "init-cm"}}},
+			}},
+		},
+		Containers: []coreV1.Container{
+			{Name: "test", Env: []coreV1.EnvVar{
+				{Name: "VALUE", Value: "value"},
+				{Name: "CM", ValueFrom: &coreV1.EnvVarSource{
+					ConfigMapKeyRef: &coreV1.ConfigMapKeySelector{LocalObjectReference: coreV1.LocalObjectReference{Name: "env-cm"}},
+				}},
+			}},
+		},
+	}
+
+	assert.Equal(t, map[string]bool{
+		"configmap/projected-cm":  true,
+		"secret/projected-secret": true,
+		"secret/volume-secret":    true,
+		"configmap/init-cm":       true,
+		"configmap/env-cm":        true,
+	}, getConfigRefs(spec))
+
+	assert.True(t, IsConfigMapReferenced(spec, "env-cm"))
+	assert.False(t, IsConfigMapReferenced(spec, "volume-secret"))
+	assert.True(t, IsSecretReferenced(spec, "volume-secret"))
+	assert.False(t, IsSecretReferenced(spec, "env-cm"))
+}
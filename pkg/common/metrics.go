@@ -0,0 +1,33 @@
+package common
+
+import (
+	"strconv"
+
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+// MetricsPort returns port from metrics configuration, default prometheus port is used if not set
+func MetricsPort(metrics *components.Metrics) int32 {
+	if metrics == nil || metrics.Port == 0 {
+		return constant.PrometheusPort
+	}
+	return int32(metrics.Port)
+}
+
+// MetricsPath returns path from metrics configuration, default prometheus path is used if not set
+func MetricsPath(metrics *components.Metrics) string {
+	if metrics == nil || metrics.Path == "" {
+		return constant.PrometheusPath
+	}
+	return metrics.Path
+}
+
+// MetricsAnnotations creates pod annotations for integration with prometheus
+func MetricsAnnotations(port int32, path string) map[string]string {
+	return map[string]string{
+		"prometheus.io/scrape": "true",
+		"prometheus.io/port":   strconv.Itoa(int(port)),
+		"prometheus.io/path":   path,
+	}
+}
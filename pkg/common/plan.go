@@ -0,0 +1,270 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+// planDeploymentKind distinguishes csi Deployment from apps Deployments in plan
+const planDeploymentKind = "Deployment.csi-baremetal.dell.com"
+
+// planKey is a context key of Plan
+type planKey struct{}
+
+// Plan collects changes, which would be applied to the cluster during reconcile in plan-only mode
+type Plan struct {
+	changes []csibaremetalv1.PlannedChange
+	// configMaps contains planned data of ConfigMaps by <namespace>/<name>,
+	// it's used to compute config hash of pod templates as if ConfigMaps were updated
+	configMaps map[string]map[string]string
+}
+
+// IsPlanOnly checks if csi Deployment has plan-only annotation
+func IsPlanOnly(csi *csibaremetalv1.Deployment) bool {
+	return csi.GetAnnotations()[csibaremetalv1.PlanOnlyAnnotation] == "true"
+}
+
+// WithPlan returns context, which makes update functions record changes in plan instead of applying them
+func WithPlan(ctx context.Context, plan *Plan) context.Context {
+	return context.WithValue(ctx, planKey{}, plan)
+}
+
+// GetPlan returns Plan of context, nil if changes have to be applied
+func GetPlan(ctx context.Context) *Plan {
+	plan, _ := ctx.Value(planKey{}).(*Plan)
+	return plan
+}
+
+// Add records change of the object
+func (p *Plan) Add(action csibaremetalv1.PlannedAction, kind, namespace, name string, details ...string) {
+	p.changes = append(p.changes, csibaremetalv1.PlannedChange{
+		Action:    action,
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+		Details:   details,
+	})
+}
+
+// Result returns planned changes sorted by kind, namespace and name
+func (p *Plan) Result(generation int64) *csibaremetalv1.DeploymentPlan {
+	changes := append([]csibaremetalv1.PlannedChange{}, p.changes...)
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		if changes[i].Namespace != changes[j].Namespace {
+			return changes[i].Namespace < changes[j].Namespace
+		}
+		return changes[i].Name < changes[j].Name
+	})
+
+	counts := map[csibaremetalv1.PlannedAction]int{}
+	for _, change := range changes {
+		counts[change.Action]++
+	}
+	var summary []string
+	for _, action := range []csibaremetalv1.PlannedAction{csibaremetalv1.PlannedCreate,
+		csibaremetalv1.PlannedUpdate, csibaremetalv1.PlannedRecreate, csibaremetalv1.PlannedDelete} {
+		if counts[action] != 0 {
+			summary = append(summary, fmt.Sprintf("%d to %s", counts[action], strings.ToLower(string(action))))
+		}
+	}
+	if len(summary) == 0 {
+		summary = append(summary, "No changes")
+	}
+
+	return &csibaremetalv1.DeploymentPlan{
+		ObservedGeneration: generation,
+		Summary:            strings.Join(summary, ", "),
+		Changes:            changes,
+	}
+}
+
+// addConfigMap records creation of expected ConfigMap if found is nil, or its update
+func (p *Plan) addConfigMap(expected, found *corev1.ConfigMap) {
+	if p.configMaps == nil {
+		p.configMaps = map[string]map[string]string{}
+	}
+	p.configMaps[expected.Namespace+"/"+expected.Name] = expected.Data
+
+	if found == nil {
+		p.Add(csibaremetalv1.PlannedCreate, "ConfigMap", expected.Namespace, expected.Name)
+		return
+	}
+	p.Add(csibaremetalv1.PlannedUpdate, "ConfigMap", expected.Namespace, expected.Name,
+		describeDataChanges(expected.Data, found.Data)...)
+}
+
+// getConfigMapData returns planned data of ConfigMap, false if ConfigMap isn't changed or there is no plan
+func (p *Plan) getConfigMapData(namespace, name string) (map[string]string, bool) {
+	if p == nil {
+		return nil, false
+	}
+	data, ok := p.configMaps[namespace+"/"+name]
+	return data, ok
+}
+
+// describeDataChanges returns added, removed and changed keys of ConfigMap
+func describeDataChanges(expected, found map[string]string) []string {
+	var details []string
+	for key, value := range expected {
+		foundValue, ok := found[key]
+		switch {
+		case !ok:
+			details = append(details, "data."+key+" is added")
+		case foundValue != value:
+			details = append(details, "data."+key+" is changed")
+		}
+	}
+	for key := range found {
+		if _, ok := expected[key]; !ok {
+			details = append(details, "data."+key+" is removed")
+		}
+	}
+	sort.Strings(details)
+	return details
+}
+
+// describeDaemonSetChanges returns fields, which are compared by daemonsetChanged
+func describeDaemonSetChanges(expected, found *appsv1.DaemonSet) []string {
+	var details []string
+	if !equality.Semantic.DeepEqual(expected.Spec.Selector, found.Spec.Selector) {
+		details = append(details, "spec.selector is changed")
+	}
+	details = append(details, describeTemplateChanges(&expected.Spec.Template, &found.Spec.Template)...)
+	if expected.Spec.UpdateStrategy.Type != "" && expected.Spec.UpdateStrategy.Type != found.Spec.UpdateStrategy.Type {
+		details = append(details, fmt.Sprintf("spec.updateStrategy.type: %s -> %s",
+			found.Spec.UpdateStrategy.Type, expected.Spec.UpdateStrategy.Type))
+	}
+	return details
+}
+
+// describeDeploymentChanges returns fields, which are compared by deploymentChanged
+func describeDeploymentChanges(expected, found *appsv1.Deployment) []string {
+	var details []string
+	if !equality.Semantic.DeepEqual(expected.Spec.Replicas, found.Spec.Replicas) {
+		details = append(details, fmt.Sprintf("spec.replicas: %s -> %s",
+			formatReplicas(found.Spec.Replicas), formatReplicas(expected.Spec.Replicas)))
+	}
+	if !equality.Semantic.DeepEqual(expected.Spec.Selector, found.Spec.Selector) {
+		details = append(details, "spec.selector is changed")
+	}
+	return append(details, describeTemplateChanges(&expected.Spec.Template, &found.Spec.Template)...)
+}
+
+// describeTemplateChanges returns changed images, containers and other parts of pod template
+func describeTemplateChanges(expected, found *corev1.PodTemplateSpec) []string {
+	if equality.Semantic.DeepEqual(expected, found) {
+		return nil
+	}
+
+	var details []string
+	if expected.Annotations[constant.ConfigHashAnnotation] != found.Annotations[constant.ConfigHashAnnotation] {
+		details = append(details, "referenced ConfigMaps or Secrets are changed")
+	}
+	expectedMeta, foundMeta := expected.ObjectMeta.DeepCopy(), found.ObjectMeta.DeepCopy()
+	delete(expectedMeta.Annotations, constant.ConfigHashAnnotation)
+	delete(foundMeta.Annotations, constant.ConfigHashAnnotation)
+	if !equality.Semantic.DeepEqual(expectedMeta.Labels, foundMeta.Labels) ||
+		!equality.Semantic.DeepEqual(expectedMeta.Annotations, foundMeta.Annotations) {
+		details = append(details, "spec.template.metadata is changed")
+	}
+
+	details = append(details, describeContainersChanges("initContainer",
+		expected.Spec.InitContainers, found.Spec.InitContainers)...)
+	details = append(details, describeContainersChanges("container",
+		expected.Spec.Containers, found.Spec.Containers)...)
+	if !equality.Semantic.DeepEqual(expected.Spec.Volumes, found.Spec.Volumes) {
+		details = append(details, "spec.template.spec.volumes are changed")
+	}
+
+	expectedSpec, foundSpec := expected.Spec.DeepCopy(), found.Spec.DeepCopy()
+	for _, spec := range []*corev1.PodSpec{expectedSpec, foundSpec} {
+		spec.InitContainers, spec.Containers, spec.Volumes = nil, nil, nil
+	}
+	if !equality.Semantic.DeepEqual(expectedSpec, foundSpec) {
+		details = append(details, "spec.template.spec is changed")
+	}
+
+	if len(details) == 0 {
+		details = append(details, "spec.template is changed")
+	}
+	return details
+}
+
+// describeContainersChanges returns added and removed containers, changed images and other container fields
+func describeContainersChanges(kind string, expected, found []corev1.Container) []string {
+	var details []string
+	foundByName := map[string]*corev1.Container{}
+	for i := range found {
+		foundByName[found[i].Name] = &found[i]
+	}
+	expectedNames := map[string]bool{}
+	for i := range expected {
+		container := &expected[i]
+		expectedNames[container.Name] = true
+		foundContainer, ok := foundByName[container.Name]
+		if !ok {
+			details = append(details, fmt.Sprintf("%s %s is added", kind, container.Name))
+			continue
+		}
+		if container.Image != foundContainer.Image {
+			details = append(details, fmt.Sprintf("%s %s image: %s -> %s", kind, container.Name,
+				foundContainer.Image, container.Image))
+		}
+		expectedCopy, foundCopy := container.DeepCopy(), foundContainer.DeepCopy()
+		expectedCopy.Image, foundCopy.Image = "", ""
+		if !equality.Semantic.DeepEqual(expectedCopy, foundCopy) {
+			details = append(details, fmt.Sprintf("%s %s is changed", kind, container.Name))
+		}
+	}
+	for _, container := range found {
+		if !expectedNames[container.Name] {
+			details = append(details, fmt.Sprintf("%s %s is removed", kind, container.Name))
+		}
+	}
+	// order of containers is a part of pod template
+	if len(details) == 0 && !reflect.DeepEqual(containerNames(expected), containerNames(found)) {
+		details = append(details, kind+"s are reordered")
+	}
+	return details
+}
+
+func containerNames(containers []corev1.Container) []string {
+	names := make([]string, 0, len(containers))
+	for _, container := range containers {
+		names = append(names, container.Name)
+	}
+	return names
+}
+
+func formatReplicas(replicas *int32) string {
+	if replicas == nil {
+		return "<nil>"
+	}
+	return fmt.Sprint(*replicas)
+}
+
+// describeCondition returns planned status condition of csi Deployment
+func describeCondition(condition metav1.Condition) string {
+	description := fmt.Sprintf("status condition %s: %s", condition.Type, condition.Status)
+	if condition.Reason != "" {
+		description += ", " + condition.Reason
+	}
+	if condition.Message != "" {
+		description += ": " + condition.Message
+	}
+	return description
+}
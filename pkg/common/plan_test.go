@@ -0,0 +1,94 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+func Test_Plan(t *testing.T) {
+	ctx := context.Background()
+	log := logrus.WithField("Test name", "PlanTest")
+
+	t.Run("Changes are recorded instead of being applied", func(t *testing.T) {
+		client := fake.NewSimpleClientset(newDaemonSet.DeepCopy(), newConfigMap.DeepCopy())
+		client.ClearActions()
+		plan := &Plan{}
+		planCtx := WithPlan(ctx, plan)
+
+		assert.Nil(t, UpdateDaemonSet(planCtx, client, targetDaemonSet.DeepCopy(), log))
+		assert.Nil(t, UpdateConfigMap(planCtx, client, targetConfigMap.DeepCopy(), log))
+		assert.Nil(t, UpdateDeployment(planCtx, client, newDeployment.DeepCopy(), log))
+		// unchanged objects are not planned
+		assert.Nil(t, UpdateDaemonSet(planCtx, client, newDaemonSet.DeepCopy(), log))
+
+		for _, action := range client.Actions() {
+			assert.Equal(t, "get", action.GetVerb())
+		}
+
+		result := plan.Result(2)
+		assert.Equal(t, int64(2), result.ObservedGeneration)
+		assert.Equal(t, "1 to create, 2 to update", result.Summary)
+		assert.Equal(t, []csibaremetalv1.PlannedChange{
+			{Action: csibaremetalv1.PlannedUpdate, Kind: "ConfigMap", Namespace: "test", Name: "test",
+				Details: []string{"data.test is removed", "data.test-updated is added"}},
+			{Action: csibaremetalv1.PlannedUpdate, Kind: "DaemonSet", Namespace: "test", Name: "test",
+				Details: []string{"container test-update is added", "container test is removed"}},
+			{Action: csibaremetalv1.PlannedCreate, Kind: "Deployment", Namespace: "test", Name: "test"},
+		}, result.Changes)
+	})
+
+	t.Run("Config hash is computed with planned ConfigMaps", func(t *testing.T) {
+		client := fake.NewSimpleClientset(newHashTestConfigMap("config", "a"))
+		template, planned := newHashTestTemplate(), newHashTestTemplate()
+		assert.Nil(t, AddConfigHashAnnotation(ctx, client, hashTestDeployment, template))
+
+		planCtx := WithPlan(ctx, &Plan{})
+		assert.Nil(t, UpdateConfigMap(planCtx, client, newHashTestConfigMap("config", "b"), log))
+		assert.Nil(t, AddConfigHashAnnotation(planCtx, client, hashTestDeployment, planned))
+		assert.NotEqual(t, template.Annotations[constant.ConfigHashAnnotation],
+			planned.Annotations[constant.ConfigHashAnnotation])
+	})
+
+	t.Run("No changes", func(t *testing.T) {
+		assert.Equal(t, "No changes", (&Plan{}).Result(1).Summary)
+	})
+}
+
+func Test_describeTemplateChanges(t *testing.T) {
+	found := &coreV1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{constant.ConfigHashAnnotation: "a"}},
+		Spec: coreV1.PodSpec{
+			Containers: []coreV1.Container{
+				{Name: "node", Image: "csi-baremetal-node:1.6.1"},
+				{Name: "drivemgr", Image: "csi-baremetal-drivemgr:1.6.1", Args: []string{"--loglevel=info"}},
+			},
+		},
+	}
+	expected := found.DeepCopy()
+	assert.Nil(t, describeTemplateChanges(expected, found))
+
+	expected.Annotations[constant.ConfigHashAnnotation] = "b"
+	expected.Spec.Containers[0].Image = "csi-baremetal-node:1.6.2"
+	expected.Spec.Containers[1].Args = []string{"--loglevel=debug"}
+	expected.Spec.NodeSelector = map[string]string{"key": "value"}
+	assert.Equal(t, []string{
+		"referenced ConfigMaps or Secrets are changed",
+		"container node image: csi-baremetal-node:1.6.1 -> csi-baremetal-node:1.6.2",
+		"container drivemgr is changed",
+		"spec.template.spec is changed",
+	}, describeTemplateChanges(expected, found))
+
+	replicas := int32(2)
+	assert.Equal(t, []string{"spec.replicas: <nil> -> 2"}, describeDeploymentChanges(
+		&appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: &replicas}}, &appsv1.Deployment{}))
+}
@@ -0,0 +1,100 @@
+package common
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+)
+
+// ApplyPodOverrides merges user defined pod settings into the generated pod template
+// Operator labels, env and mounts have priority to keep selectors and components configuration consistent
+func ApplyPodOverrides(template *corev1.PodTemplateSpec, overrides *components.PodOverrides) {
+	if overrides == nil {
+		return
+	}
+
+	spec := &template.Spec
+	spec.Tolerations = append(spec.Tolerations, overrides.Tolerations...)
+	if overrides.Affinity != nil {
+		spec.Affinity = overrides.Affinity.DeepCopy()
+	}
+	if overrides.PriorityClassName != "" {
+		spec.PriorityClassName = overrides.PriorityClassName
+	}
+	spec.TopologySpreadConstraints = append(spec.TopologySpreadConstraints, overrides.TopologySpreadConstraints...)
+
+	if len(overrides.NodeSelector) != 0 && spec.NodeSelector == nil {
+		spec.NodeSelector = map[string]string{}
+	}
+	for key, value := range overrides.NodeSelector {
+		spec.NodeSelector[key] = value
+	}
+
+	if len(overrides.PodAnnotations) != 0 && template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	for key, value := range overrides.PodAnnotations {
+		template.Annotations[key] = value
+	}
+
+	if len(overrides.PodLabels) != 0 && template.Labels == nil {
+		template.Labels = map[string]string{}
+	}
+	for key, value := range overrides.PodLabels {
+		if _, ok := template.Labels[key]; !ok {
+			template.Labels[key] = value
+		}
+	}
+
+	spec.Volumes = appendVolumes(spec.Volumes, overrides.Volumes)
+	for i := range spec.Containers {
+		spec.Containers[i].Env = appendEnv(spec.Containers[i].Env, overrides.Env)
+		spec.Containers[i].VolumeMounts = appendVolumeMounts(spec.Containers[i].VolumeMounts, overrides.VolumeMounts)
+	}
+}
+
+// appendEnv adds env variables, which are not set in the container
+func appendEnv(env, extra []corev1.EnvVar) []corev1.EnvVar {
+	names := make(map[string]struct{}, len(env))
+	for _, e := range env {
+		names[e.Name] = struct{}{}
+	}
+
+	for _, e := range extra {
+		if _, ok := names[e.Name]; !ok {
+			env = append(env, e)
+		}
+	}
+	return env
+}
+
+// appendVolumes adds volumes, which are not defined in the pod
+func appendVolumes(volumes, extra []corev1.Volume) []corev1.Volume {
+	names := make(map[string]struct{}, len(volumes))
+	for _, v := range volumes {
+		names[v.Name] = struct{}{}
+	}
+
+	for _, v := range extra {
+		if _, ok := names[v.Name]; !ok {
+			volumes = append(volumes, v)
+		}
+	}
+	return volumes
+}
+
+// appendVolumeMounts adds mounts, which mount paths are not used in the container
+func appendVolumeMounts(mounts, extra []corev1.VolumeMount) []corev1.VolumeMount {
+	paths := make(map[string]struct{}, len(mounts))
+	for _, m := range mounts {
+		paths[m.MountPath] = struct{}{}
+	}
+
+	for _, m := range extra {
+		if _, ok := paths[m.MountPath]; !ok {
+			mounts = append(mounts, m)
+			paths[m.MountPath] = struct{}{}
+		}
+	}
+	return mounts
+}
@@ -0,0 +1,102 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+func Test_ApplyPodOverrides(t *testing.T) {
+	newTemplate := func() *corev1.PodTemplateSpec {
+		return &corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: ConstructLabelMap("test", "test"),
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "first", Env: []corev1.EnvVar{{Name: "ADDRESS", Value: "/csi/csi.sock"}}},
+					{Name: "second"},
+				},
+				Volumes:     []corev1.Volume{constant.CrashVolume},
+				Tolerations: []corev1.Toleration{{Key: "CriticalAddonsOnly", Operator: corev1.TolerationOpExists}},
+			},
+		}
+	}
+
+	t.Run("Nil overrides", func(t *testing.T) {
+		template := newTemplate()
+		ApplyPodOverrides(template, nil)
+		assert.Equal(t, newTemplate(), template)
+	})
+
+	t.Run("Merge overrides", func(t *testing.T) {
+		var (
+			template  = newTemplate()
+			affinity  = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}}
+			overrides = &components.PodOverrides{
+				Tolerations: []corev1.Toleration{
+					{Key: "node-role.kubernetes.io/control-plane", Effect: corev1.TaintEffectNoSchedule},
+				},
+				Affinity:          affinity,
+				NodeSelector:      map[string]string{"disktype": "ssd"},
+				PriorityClassName: "system-node-critical",
+				PodAnnotations:    map[string]string{"example.com/annotation": "value"},
+				PodLabels: map[string]string{
+					"example.com/label":  "value",
+					constant.SelectorKey: "overridden",
+				},
+				Env: []corev1.EnvVar{
+					{Name: "ADDRESS", Value: "overridden"},
+					{Name: "HTTP_PROXY", Value: "http://proxy"},
+				},
+				Volumes: []corev1.Volume{
+					constant.CrashVolume,
+					{Name: "extra", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+				},
+				VolumeMounts: []corev1.VolumeMount{{Name: "extra", MountPath: "/extra"}},
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+					{MaxSkew: 1, TopologyKey: "kubernetes.io/hostname", WhenUnsatisfiable: corev1.ScheduleAnyway},
+				},
+			}
+		)
+
+		ApplyPodOverrides(template, overrides)
+
+		assert.Len(t, template.Spec.Tolerations, 2)
+		assert.Equal(t, affinity, template.Spec.Affinity)
+		assert.Equal(t, "ssd", template.Spec.NodeSelector["disktype"])
+		assert.Equal(t, "system-node-critical", template.Spec.PriorityClassName)
+		assert.Equal(t, "value", template.Annotations["example.com/annotation"])
+		assert.Equal(t, "value", template.Labels["example.com/label"])
+		assert.Equal(t, "test", template.Labels[constant.SelectorKey])
+		assert.Len(t, template.Spec.Volumes, 2)
+		assert.Len(t, template.Spec.TopologySpreadConstraints, 1)
+
+		first := template.Spec.Containers[0]
+		assert.Equal(t, []corev1.EnvVar{{Name: "ADDRESS", Value: "/csi/csi.sock"}, {Name: "HTTP_PROXY", Value: "http://proxy"}}, first.Env)
+		for _, container := range template.Spec.Containers {
+			assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: "extra", MountPath: "/extra"})
+		}
+	})
+
+	t.Run("Mounts with used mount paths are skipped", func(t *testing.T) {
+		template := newTemplate()
+		template.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: "logs", MountPath: "/var/log"}}
+
+		ApplyPodOverrides(template, &components.PodOverrides{VolumeMounts: []corev1.VolumeMount{
+			{Name: "host-logs", MountPath: "/var/log"},
+			{Name: "extra", MountPath: "/extra"},
+			{Name: "extra-copy", MountPath: "/extra"},
+		}})
+
+		assert.Equal(t, []corev1.VolumeMount{{Name: "logs", MountPath: "/var/log"}, {Name: "extra", MountPath: "/extra"}},
+			template.Spec.Containers[0].VolumeMounts)
+		assert.Equal(t, []corev1.VolumeMount{{Name: "host-logs", MountPath: "/var/log"}, {Name: "extra", MountPath: "/extra"}},
+			template.Spec.Containers[1].VolumeMounts)
+	})
+}
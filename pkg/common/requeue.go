@@ -0,0 +1,58 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RequeueError reports that reconciliation is waiting for external changes
+// and has to be repeated after the delay. It is not a failure
+type RequeueError struct {
+	After  time.Duration
+	Reason string
+}
+
+// NewRequeueError creates RequeueError
+func NewRequeueError(after time.Duration, reason string) *RequeueError {
+	return &RequeueError{After: after, Reason: reason}
+}
+
+func (e *RequeueError) Error() string {
+	return fmt.Sprintf("requeue after %s: %s", e.After, e.Reason)
+}
+
+// IsRequeueError checks that err is RequeueError and returns it
+func IsRequeueError(err error) (*RequeueError, bool) {
+	var requeueErr *RequeueError
+	if errors.As(err, &requeueErr) {
+		return requeueErr, true
+	}
+	return nil, false
+}
+
+// RequeueCollector keeps the earliest RequeueError of several steps,
+// so the wait of one step doesn't block the others
+type RequeueCollector struct {
+	requeue *RequeueError
+}
+
+// Collect returns err if it isn't RequeueError, RequeueError is kept to be returned by Result
+func (c *RequeueCollector) Collect(err error) error {
+	requeueErr, ok := IsRequeueError(err)
+	if !ok {
+		return err
+	}
+	if c.requeue == nil || requeueErr.After < c.requeue.After {
+		c.requeue = requeueErr
+	}
+	return nil
+}
+
+// Result returns the earliest collected RequeueError, nil if nothing is collected
+func (c *RequeueCollector) Result() error {
+	if c.requeue == nil {
+		return nil
+	}
+	return c.requeue
+}
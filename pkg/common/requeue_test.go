@@ -0,0 +1,37 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRequeueError(t *testing.T) {
+	requeueErr, ok := IsRequeueError(fmt.Errorf("wrapped: %w", NewRequeueError(time.Second, "waiting")))
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, requeueErr.After)
+	assert.Equal(t, "waiting", requeueErr.Reason)
+
+	_, ok = IsRequeueError(errors.New("error"))
+	assert.False(t, ok)
+
+	_, ok = IsRequeueError(nil)
+	assert.False(t, ok)
+}
+
+func TestRequeueCollector(t *testing.T) {
+	collector := &RequeueCollector{}
+	assert.Nil(t, collector.Result())
+
+	assert.Nil(t, collector.Collect(nil))
+	assert.Nil(t, collector.Collect(NewRequeueError(time.Minute, "patching")))
+	assert.Nil(t, collector.Collect(NewRequeueError(time.Second, "upgrade")))
+	assert.Equal(t, "error", collector.Collect(errors.New("error")).Error())
+
+	requeueErr, ok := IsRequeueError(collector.Result())
+	assert.True(t, ok)
+	assert.Equal(t, "upgrade", requeueErr.Reason)
+}
@@ -0,0 +1,40 @@
+package common
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+)
+
+// UpdateStatusCondition sets condition of csi Deployment and updates its status if the condition was changed.
+// Status isn't updated if context has Plan, the change is recorded instead
+func UpdateStatusCondition(ctx context.Context, c client.Client, csi *csibaremetalv1.Deployment, condition metav1.Condition) error {
+	condition.ObservedGeneration = csi.GetGeneration()
+	if !meta.SetStatusCondition(&csi.Status.Conditions, condition) {
+		return nil
+	}
+
+	if plan := GetPlan(ctx); plan != nil {
+		plan.Add(csibaremetalv1.PlannedUpdate, planDeploymentKind, csi.Namespace, csi.Name, describeCondition(condition))
+		return nil
+	}
+	return c.Status().Update(ctx, csi)
+}
+
+// RemoveStatusCondition removes condition of csi Deployment and updates its status if the condition existed
+func RemoveStatusCondition(ctx context.Context, c client.Client, csi *csibaremetalv1.Deployment, conditionType string) error {
+	if !meta.RemoveStatusCondition(&csi.Status.Conditions, conditionType) {
+		return nil
+	}
+
+	if plan := GetPlan(ctx); plan != nil {
+		plan.Add(csibaremetalv1.PlannedUpdate, planDeploymentKind, csi.Namespace, csi.Name,
+			"status condition "+conditionType+" is removed")
+		return nil
+	}
+	return c.Status().Update(ctx, csi)
+}
@@ -13,9 +13,12 @@ import (
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
 )
 
-// UpdateConfigMap updates found configmap with Spec from expected, creates one if not found
+// UpdateConfigMap updates found configmap with Spec from expected, creates one if not found.
+// Changes are only recorded if context has Plan, the same applies to UpdateDaemonSet and UpdateDeployment
 func UpdateConfigMap(ctx context.Context, client kubernetes.Interface, expected *coreV1.ConfigMap, log *logrus.Entry) error {
 	cfClient := client.CoreV1().ConfigMaps(expected.Namespace)
 
@@ -28,6 +31,10 @@ func UpdateConfigMap(ctx context.Context, client kubernetes.Interface, expected
 
 	// create if not found
 	if apiErrors.IsNotFound(err) {
+		if plan := GetPlan(ctx); plan != nil {
+			plan.addConfigMap(expected, nil)
+			return nil
+		}
 		_, err = cfClient.Create(ctx, expected, metav1.CreateOptions{})
 		if err != nil {
 			log.Error(err, "Failed to create configmap "+expected.Name)
@@ -39,6 +46,10 @@ func UpdateConfigMap(ctx context.Context, client kubernetes.Interface, expected
 
 	// update with new data
 	if !reflect.DeepEqual(found.Data, expected.Data) {
+		if plan := GetPlan(ctx); plan != nil {
+			plan.addConfigMap(expected, found)
+			return nil
+		}
 		found.Data = expected.Data
 		_, err = cfClient.Update(ctx, found, metav1.UpdateOptions{})
 		if err != nil {
@@ -59,6 +70,10 @@ func UpdateDaemonSet(ctx context.Context, client kubernetes.Interface, expected
 	found, err := dsClient.Get(ctx, expected.Name, metav1.GetOptions{})
 	if err != nil {
 		if apiErrors.IsNotFound(err) {
+			if plan := GetPlan(ctx); plan != nil {
+				plan.Add(csibaremetalv1.PlannedCreate, "DaemonSet", expected.Namespace, expected.Name)
+				return nil
+			}
 			if _, err := dsClient.Create(ctx, expected, metav1.CreateOptions{}); err != nil {
 				log.Error(err, "Failed to create daemonset "+expected.Name)
 				return err
@@ -73,6 +88,11 @@ func UpdateDaemonSet(ctx context.Context, client kubernetes.Interface, expected
 	}
 
 	if daemonsetChanged(expected, found) {
+		if plan := GetPlan(ctx); plan != nil {
+			plan.Add(csibaremetalv1.PlannedUpdate, "DaemonSet", expected.Namespace, expected.Name,
+				describeDaemonSetChanges(expected, found)...)
+			return nil
+		}
 		found.Spec = expected.Spec
 		if _, err := dsClient.Update(ctx, found, metav1.UpdateOptions{}); err != nil {
 			log.Error(err, "Failed to update daemonset "+expected.Name)
@@ -92,6 +112,10 @@ func UpdateDeployment(ctx context.Context, client kubernetes.Interface, expected
 	found, err := dsClient.Get(ctx, expected.Name, metav1.GetOptions{})
 	if err != nil {
 		if apiErrors.IsNotFound(err) {
+			if plan := GetPlan(ctx); plan != nil {
+				plan.Add(csibaremetalv1.PlannedCreate, "Deployment", expected.Namespace, expected.Name)
+				return nil
+			}
 			if _, err := dsClient.Create(ctx, expected, metav1.CreateOptions{}); err != nil {
 				log.Error(err, "Failed to create deployment "+expected.Name)
 				return err
@@ -106,6 +130,11 @@ func UpdateDeployment(ctx context.Context, client kubernetes.Interface, expected
 	}
 
 	if deploymentChanged(expected, found, log) {
+		if plan := GetPlan(ctx); plan != nil {
+			plan.Add(csibaremetalv1.PlannedUpdate, "Deployment", expected.Namespace, expected.Name,
+				describeDeploymentChanges(expected, found)...)
+			return nil
+		}
 		found.Spec = expected.Spec
 		if _, err := dsClient.Update(ctx, found, metav1.UpdateOptions{}); err != nil {
 			log.Error(err, "Failed to update deployment "+expected.Name)
@@ -172,5 +201,10 @@ func daemonsetChanged(expected *appsv1.DaemonSet, found *appsv1.DaemonSet) bool
 		return true
 	}
 
+	// strategy parameters are defaulted by API server, only type is compared if it's set
+	if expected.Spec.UpdateStrategy.Type != "" && expected.Spec.UpdateStrategy.Type != found.Spec.UpdateStrategy.Type {
+		return true
+	}
+
 	return false
 }
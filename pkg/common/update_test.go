@@ -98,6 +98,29 @@ var (
 		},
 	}
 
+	targetDaemonSetStrategy = &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Template: coreV1.PodTemplateSpec{
+				Spec: coreV1.PodSpec{
+					Containers: []coreV1.Container{
+						{
+							Name:  "test",
+							Image: "test",
+						},
+					},
+				},
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"selector-test": "selector-test"},
+			},
+			UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.OnDeleteDaemonSetStrategyType},
+		},
+	}
+
 	newDeployment = &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "test",
@@ -230,6 +253,10 @@ func Test_UpdateDaemonSet(t *testing.T) {
 			testTarget:      "selector",
 			targetDaemonSet: targetDaemonSetSelector,
 		},
+		{
+			testTarget:      "strategy",
+			targetDaemonSet: targetDaemonSetStrategy,
+		},
 	}
 	for _, tt := range tests {
 		t.Run("Check changed daemonset "+tt.testTarget, func(t *testing.T) {
@@ -0,0 +1,469 @@
+package compatibility
+
+import (
+	"context"
+	_ "embed" // compatibility matrix is embedded into operator binary
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/masterminds/semver"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	// CompatibleReason is set for Compatible condition when all checks are passed
+	CompatibleReason = "VersionsCompatible"
+	// IncompatibleReason is set for Compatible condition when changes of csi Deployment are refused
+	IncompatibleReason = "IncompatibleVersions"
+	// OverriddenReason is set for Compatible condition when incompatible changes are applied due to override annotation
+	OverriddenReason = "CompatibilityCheckOverridden"
+	// NotUpgradedReason is set for Compatible condition when checks are failed, but changes are applied
+	// since versions are not changed, e.g. on fresh install or after Kubernetes upgrade
+	NotUpgradedReason = "IncompatibleVersionsNotUpgraded"
+
+	// discoveryCacheTTL is a period of caching of Kubernetes version
+	discoveryCacheTTL = 10 * time.Minute
+	// operatorKey is a key of operator version in applied versions
+	operatorKey = "operator"
+	// controllerDeploymentName is used to detect fresh install of csi Deployment
+	controllerDeploymentName = constant.CSIName + "-controller"
+)
+
+// OperatorVersion is a version of csi-baremetal-operator, it's set at build time with
+// -ldflags "-X github.com/dell/csi-baremetal-operator/pkg/compatibility.OperatorVersion=<version>".
+// Compatibility is not checked for versions, which are not semver
+var OperatorVersion = "devel"
+
+//go:embed matrix.yaml
+var matrixData []byte
+
+// Entry describes versions compatible with the range of operator versions
+type Entry struct {
+	Operator     string `yaml:"operator"`
+	CSIBaremetal string `yaml:"csiBaremetal"`
+	Kubernetes   string `yaml:"kubernetes"`
+	CRDs         []CRD  `yaml:"crds"`
+}
+
+// CRD describes custom resources, which have to be served and stored in the group version by API server
+type CRD struct {
+	GroupVersion string   `yaml:"groupVersion"`
+	Resources    []string `yaml:"resources"`
+}
+
+// Checker verifies csi Deployment against compatibility matrix before changes are applied
+type Checker struct {
+	Clientset kubernetes.Interface
+	Client    client.Client
+	*logrus.Entry
+
+	// Kubernetes version is rarely changed, so discovery result is cached
+	cacheMu       sync.Mutex
+	cacheTime     time.Time
+	serverVersion *version.Info
+}
+
+// Check reports result of compatibility checks in Compatible condition of csi Deployment.
+// Returns false if changes have to be refused: operator or csi-baremetal versions are upgraded to incompatible ones
+// and override annotation isn't set. Fresh install and unchanged versions are not refused
+func (c *Checker) Check(ctx context.Context, csi *csibaremetalv1.Deployment) (bool, error) {
+	matrix, err := LoadMatrix()
+	if err != nil {
+		return false, err
+	}
+
+	problems, err := c.getIncompatibilities(ctx, csi, matrix)
+	if err != nil {
+		return false, err
+	}
+
+	if len(problems) == 0 {
+		return true, common.UpdateStatusCondition(ctx, c.Client, csi, metav1.Condition{
+			Type:    csibaremetalv1.CompatibleCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  CompatibleReason,
+			Message: fmt.Sprintf("Versions are compatible with operator %s", OperatorVersion),
+		})
+	}
+
+	message := strings.Join(problems, "; ")
+	upgraded, err := c.isUpgraded(ctx, csi, getAppliedVersions(csi))
+	if err != nil {
+		return false, err
+	}
+
+	var condition metav1.Condition
+	switch {
+	case !upgraded:
+		c.Warnf("Versions are incompatible, changes are applied since versions are not upgraded: %s", message)
+		condition = metav1.Condition{Reason: NotUpgradedReason, Message: message}
+	case csi.GetAnnotations()[csibaremetalv1.SkipCompatibilityCheckAnnotation] == "true":
+		c.Warnf("Compatibility check is overridden: %s", message)
+		condition = metav1.Condition{Reason: OverriddenReason, Message: message}
+	default:
+		c.Errorf("Changes of csi Deployment are refused: %s", message)
+		return false, common.UpdateStatusCondition(ctx, c.Client, csi, metav1.Condition{
+			Type:    csibaremetalv1.CompatibleCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  IncompatibleReason,
+			Message: message,
+		})
+	}
+
+	condition.Type = csibaremetalv1.CompatibleCondition
+	condition.Status = metav1.ConditionFalse
+	return true, common.UpdateStatusCondition(ctx, c.Client, csi, condition)
+}
+
+// isUpgraded checks if operator or csi-baremetal versions differ from applied ones.
+// Versions are not upgraded on fresh install, components of csi Deployment applied by previous operator
+// without versions in status are treated as upgraded
+func (c *Checker) isUpgraded(ctx context.Context, csi *csibaremetalv1.Deployment, versions map[string]string) (bool, error) {
+	if csi.Status.AppliedVersions != nil {
+		return !equality.Semantic.DeepEqual(csi.Status.AppliedVersions, versions), nil
+	}
+
+	_, err := c.Clientset.AppsV1().Deployments(csi.GetNamespace()).Get(ctx, controllerDeploymentName, metav1.GetOptions{})
+	if k8sError.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// RecordAppliedVersions saves versions, which changes are applied with, in status of csi Deployment.
+// It has to be called after components of csi Deployment are updated
+func (c *Checker) RecordAppliedVersions(ctx context.Context, csi *csibaremetalv1.Deployment) error {
+	versions := getAppliedVersions(csi)
+	if equality.Semantic.DeepEqual(csi.Status.AppliedVersions, versions) || common.GetPlan(ctx) != nil {
+		return nil
+	}
+	csi.Status.AppliedVersions = versions
+	return c.Client.Status().Update(ctx, csi)
+}
+
+// LoadMatrix parses embedded compatibility matrix
+func LoadMatrix() ([]Entry, error) {
+	var matrix []Entry
+	if err := yaml.Unmarshal(matrixData, &matrix); err != nil {
+		return nil, fmt.Errorf("failed to parse compatibility matrix: %s", err.Error())
+	}
+	return matrix, nil
+}
+
+// getIncompatibilities returns descriptions of failed checks,
+// versions, which are not semver (e.g. image tag "latest"), are not checked
+func (c *Checker) getIncompatibilities(ctx context.Context, csi *csibaremetalv1.Deployment, matrix []Entry) ([]string, error) {
+	operatorVersion, err := parseVersion(OperatorVersion)
+	if err != nil {
+		c.Debugf("Operator version %s is not semver, compatibility check is skipped", OperatorVersion)
+		return nil, nil
+	}
+
+	entry, err := findEntry(matrix, operatorVersion)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return []string{fmt.Sprintf("operator %s is not found in compatibility matrix", OperatorVersion)}, nil
+	}
+
+	problems, err := checkComponents(entry, getComponentVersions(csi))
+	if err != nil {
+		return nil, err
+	}
+
+	kubernetesProblems, err := c.checkKubernetes(entry)
+	if err != nil {
+		return nil, err
+	}
+	problems = append(problems, kubernetesProblems...)
+
+	crdProblems, err := c.checkCRDs(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	return append(problems, crdProblems...), nil
+}
+
+func findEntry(matrix []Entry, operatorVersion *semver.Version) (*Entry, error) {
+	for i := range matrix {
+		constraint, err := semver.NewConstraint(matrix[i].Operator)
+		if err != nil {
+			return nil, fmt.Errorf("invalid operator constraint %q in compatibility matrix: %s", matrix[i].Operator, err.Error())
+		}
+		if constraint.Check(operatorVersion) {
+			return &matrix[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// checkComponents verifies that csi-baremetal components have the same minor version from the supported range
+func checkComponents(entry *Entry, versions map[string]*semver.Version) ([]string, error) {
+	constraint, err := semver.NewConstraint(entry.CSIBaremetal)
+	if err != nil {
+		return nil, fmt.Errorf("invalid csiBaremetal constraint %q in compatibility matrix: %s", entry.CSIBaremetal, err.Error())
+	}
+
+	names := make([]string, 0, len(versions))
+	for name := range versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		problems []string
+		minors   = map[string][]string{}
+	)
+	for _, name := range names {
+		version := versions[name]
+		if !constraint.Check(version) {
+			problems = append(problems, fmt.Sprintf("%s %s is not supported, expected %s", name, version, entry.CSIBaremetal))
+		}
+		minor := fmt.Sprintf("%d.%d", version.Major(), version.Minor())
+		minors[minor] = append(minors[minor], name)
+	}
+	if len(minors) > 1 {
+		var groups []string
+		for minor, components := range minors {
+			groups = append(groups, fmt.Sprintf("%s: %s", minor, strings.Join(components, ", ")))
+		}
+		sort.Strings(groups)
+		problems = append(problems, fmt.Sprintf("csi-baremetal components have different versions (%s)", strings.Join(groups, "; ")))
+	}
+	return problems, nil
+}
+
+// checkKubernetes verifies version of API server
+func (c *Checker) checkKubernetes(entry *Entry) ([]string, error) {
+	constraint, err := semver.NewConstraint(entry.Kubernetes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubernetes constraint %q in compatibility matrix: %s", entry.Kubernetes, err.Error())
+	}
+
+	info, err := c.getServerVersion()
+	if err != nil {
+		return nil, err
+	}
+	version, err := parseVersion(info.GitVersion)
+	if err != nil {
+		c.Debugf("Kubernetes version %s is not semver, check is skipped", info.GitVersion)
+		return nil, nil
+	}
+	if !constraint.Check(version) {
+		return []string{fmt.Sprintf("kubernetes %s is not supported, expected %s", info.GitVersion, entry.Kubernetes)}, nil
+	}
+	return nil, nil
+}
+
+// checkCRDs verifies that installed CRDs serve and store custom resources in the group version from matrix entry
+func (c *Checker) checkCRDs(ctx context.Context, entry *Entry) ([]string, error) {
+	var problems []string
+	for _, crd := range entry.CRDs {
+		groupVersion, err := schema.ParseGroupVersion(crd.GroupVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CRD group version %q in compatibility matrix: %s", crd.GroupVersion, err.Error())
+		}
+
+		var missing []string
+		for _, name := range crd.Resources {
+			installed := &apiextensionsv1.CustomResourceDefinition{}
+			err = c.Client.Get(ctx, client.ObjectKey{Name: name + "." + groupVersion.Group}, installed)
+			if k8sError.IsNotFound(err) {
+				missing = append(missing, name)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			problems = append(problems, checkCRDVersions(installed, groupVersion.Version)...)
+		}
+		switch {
+		case len(missing) == 0:
+		case len(missing) == len(crd.Resources):
+			problems = append(problems, fmt.Sprintf("CRDs of %s are not installed", crd.GroupVersion))
+		default:
+			problems = append(problems, fmt.Sprintf("%s resources are not found: %s", crd.GroupVersion, strings.Join(missing, ", ")))
+		}
+	}
+	return problems, nil
+}
+
+// checkCRDVersions verifies that CRD serves the version, uses it as storage version
+// and objects are not stored in other versions
+func checkCRDVersions(crd *apiextensionsv1.CustomResourceDefinition, expected string) []string {
+	var (
+		problems []string
+		served   bool
+		storage  string
+	)
+	for _, crdVersion := range crd.Spec.Versions {
+		if crdVersion.Name == expected {
+			served = crdVersion.Served
+		}
+		if crdVersion.Storage {
+			storage = crdVersion.Name
+		}
+	}
+	if !served {
+		problems = append(problems, fmt.Sprintf("CRD %s doesn't serve %s", crd.GetName(), expected))
+	}
+	if storage != expected {
+		problems = append(problems, fmt.Sprintf("CRD %s stores %s, expected %s", crd.GetName(), storage, expected))
+	}
+
+	var stored []string
+	for _, storedVersion := range crd.Status.StoredVersions {
+		if storedVersion != expected {
+			stored = append(stored, storedVersion)
+		}
+	}
+	if len(stored) != 0 {
+		problems = append(problems, fmt.Sprintf("objects of CRD %s are stored in %s, expected %s",
+			crd.GetName(), strings.Join(stored, ", "), expected))
+	}
+	return problems
+}
+
+// NewCRDs returns CRDs, which serve and store custom resources of matrix entries.
+// It's used to render objects against fake cluster
+func NewCRDs(matrix []Entry) ([]*apiextensionsv1.CustomResourceDefinition, error) {
+	var (
+		crds []*apiextensionsv1.CustomResourceDefinition
+		seen = map[string]bool{}
+	)
+	for _, entry := range matrix {
+		for _, crd := range entry.CRDs {
+			groupVersion, err := schema.ParseGroupVersion(crd.GroupVersion)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CRD group version %q in compatibility matrix: %s", crd.GroupVersion, err.Error())
+			}
+			for _, name := range crd.Resources {
+				fullName := name + "." + groupVersion.Group
+				if seen[fullName] {
+					continue
+				}
+				seen[fullName] = true
+				crds = append(crds, &apiextensionsv1.CustomResourceDefinition{
+					ObjectMeta: metav1.ObjectMeta{Name: fullName},
+					Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+						Group: groupVersion.Group,
+						Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: name},
+						Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+							{Name: groupVersion.Version, Served: true, Storage: true},
+						},
+					},
+					Status: apiextensionsv1.CustomResourceDefinitionStatus{StoredVersions: []string{groupVersion.Version}},
+				})
+			}
+		}
+	}
+	return crds, nil
+}
+
+// getServerVersion returns cached version of API server
+func (c *Checker) getServerVersion() (*version.Info, error) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	c.expireCache()
+
+	if c.serverVersion == nil {
+		info, err := c.Clientset.Discovery().ServerVersion()
+		if err != nil {
+			return nil, err
+		}
+		c.serverVersion = info
+	}
+	return c.serverVersion, nil
+}
+
+// expireCache drops discovery result after TTL, cacheMu has to be held
+func (c *Checker) expireCache() {
+	if time.Since(c.cacheTime) < discoveryCacheTTL {
+		return
+	}
+	c.cacheTime = time.Now()
+	c.serverVersion = nil
+}
+
+// getAppliedVersions returns operator version and image tags of csi-baremetal components
+func getAppliedVersions(csi *csibaremetalv1.Deployment) map[string]string {
+	versions := map[string]string{operatorKey: OperatorVersion}
+	for name, image := range getComponentImages(csi) {
+		if image != nil {
+			versions[name] = image.Tag
+		}
+	}
+	return versions
+}
+
+// getComponentVersions returns versions of csi-baremetal images with semver tags
+func getComponentVersions(csi *csibaremetalv1.Deployment) map[string]*semver.Version {
+	versions := map[string]*semver.Version{}
+	for name, image := range getComponentImages(csi) {
+		if image == nil {
+			continue
+		}
+		if version, err := parseVersion(image.Tag); err == nil {
+			versions[name] = version
+		}
+	}
+	return versions
+}
+
+// getComponentImages returns images of csi-baremetal components by names
+func getComponentImages(csi *csibaremetalv1.Deployment) map[string]*components.Image {
+	images := map[string]*components.Image{}
+	if driver := csi.Spec.Driver; driver != nil {
+		if driver.Controller != nil {
+			images["controller"] = driver.Controller.Image
+		}
+		if driver.Node != nil {
+			images["node"] = driver.Node.Image
+			if driver.Node.DriveMgr != nil {
+				images["drivemgr"] = driver.Node.DriveMgr.Image
+				for _, group := range driver.Node.DriveMgr.NodeGroups {
+					if group.Image != nil {
+						images["drivemgr "+group.Name] = group.Image
+					}
+				}
+			}
+		}
+	}
+	if scheduler := csi.Spec.Scheduler; scheduler != nil {
+		images["extender"] = scheduler.Image
+		if scheduler.Patcher != nil {
+			images["patcher"] = scheduler.Patcher.Image
+		}
+	}
+	if csi.Spec.NodeController != nil {
+		images["nodeController"] = csi.Spec.NodeController.Image
+	}
+	return images
+}
+
+// parseVersion returns major, minor and patch of version, e.g. 1.6.2 for v1.6.2-123.abcdef
+func parseVersion(value string) (*semver.Version, error) {
+	version, err := semver.NewVersion(value)
+	if err != nil {
+		return nil, err
+	}
+	return semver.NewVersion(fmt.Sprintf("%d.%d.%d", version.Major(), version.Minor(), version.Patch()))
+}
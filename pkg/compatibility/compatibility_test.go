@@ -0,0 +1,261 @@
+package compatibility
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+)
+
+// testOperatorVersion is set instead of version of development build, which isn't checked
+const testOperatorVersion = "1.7.0"
+
+var crdResources = []string{"availablecapacities", "availablecapacityreservations", "deployments", "drives",
+	"logicalvolumegroups", "nodes", "storagegroups", "volumes"}
+
+func prepareDeployment(tag string) *csibaremetalv1.Deployment {
+	return &csibaremetalv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "csi-baremetal.dell.com/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test-deployment", Namespace: "test-csi"},
+		Spec: components.DeploymentSpec{
+			Driver: &components.Driver{
+				Controller: &components.Controller{Image: &components.Image{Name: "csi-baremetal-controller", Tag: tag}},
+				Node: &components.Node{
+					Image:    &components.Image{Name: "csi-baremetal-node", Tag: tag},
+					DriveMgr: &components.DriveMgr{Image: &components.Image{Name: "csi-baremetal-basemgr", Tag: tag}},
+				},
+			},
+			Scheduler: &components.Scheduler{
+				Image:   &components.Image{Name: "csi-baremetal-scheduler-extender", Tag: tag},
+				Patcher: &components.Patcher{Image: &components.Image{Name: "csi-baremetal-scheduler-patcher", Tag: tag}},
+			},
+			NodeController: &components.NodeController{Image: &components.Image{Name: "csi-baremetal-node-controller", Tag: tag}},
+		},
+	}
+}
+
+func prepareChecker(csi *csibaremetalv1.Deployment, k8sVersion string, resources ...string) *Checker {
+	clientSet := fake.NewSimpleClientset()
+	fakeDiscovery := clientSet.Discovery().(*fakediscovery.FakeDiscovery)
+	fakeDiscovery.FakedServerVersion = &version.Info{GitVersion: k8sVersion}
+
+	crds, _ := NewCRDs([]Entry{{CRDs: []CRD{{GroupVersion: csibaremetalv1.GroupVersion.String(), Resources: resources}}}})
+	objects := []client.Object{csi}
+	for _, crd := range crds {
+		objects = append(objects, crd)
+	}
+
+	scheme, _ := common.PrepareScheme()
+	cl := fakeClient.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).
+		WithStatusSubresource(&csibaremetalv1.Deployment{}).Build()
+	return &Checker{Clientset: clientSet, Client: cl, Entry: logrus.WithField("Test name", "CompatibilityTest")}
+}
+
+func TestMain(m *testing.M) {
+	OperatorVersion = testOperatorVersion
+	os.Exit(m.Run())
+}
+
+func Test_LoadMatrix(t *testing.T) {
+	matrix, err := LoadMatrix()
+	assert.Nil(t, err)
+	assert.NotEmpty(t, matrix)
+
+	operatorVersion, err := parseVersion(testOperatorVersion)
+	assert.Nil(t, err)
+	entry, err := findEntry(matrix, operatorVersion)
+	assert.Nil(t, err)
+	assert.NotNil(t, entry)
+}
+
+func Test_Check(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Compatible versions", func(t *testing.T) {
+		csi := prepareDeployment("1.6.2-123.abcdef")
+		checker := prepareChecker(csi, "v1.27.3+rke2r1", crdResources...)
+
+		compatible, err := checker.Check(ctx, csi)
+		assert.Nil(t, err)
+		assert.True(t, compatible)
+		assert.Nil(t, csi.Status.AppliedVersions)
+
+		condition := meta.FindStatusCondition(csi.Status.Conditions, csibaremetalv1.CompatibleCondition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, CompatibleReason, condition.Reason)
+
+		assert.Nil(t, checker.RecordAppliedVersions(ctx, csi))
+		assert.Equal(t, testOperatorVersion, csi.Status.AppliedVersions[operatorKey])
+		assert.Equal(t, "1.6.2-123.abcdef", csi.Status.AppliedVersions["controller"])
+	})
+
+	t.Run("Non semver tags are not checked", func(t *testing.T) {
+		csi := prepareDeployment("green")
+		checker := prepareChecker(csi, "v1.27.3", crdResources...)
+
+		compatible, err := checker.Check(ctx, csi)
+		assert.Nil(t, err)
+		assert.True(t, compatible)
+	})
+
+	t.Run("Incompatible versions are refused on upgrade", func(t *testing.T) {
+		csi := prepareDeployment("1.6.2")
+		csi.Status.AppliedVersions = getAppliedVersions(csi)
+		csi.Spec.Driver.Node.Image.Tag = "1.5.0"
+		checker := prepareChecker(csi, "v1.31.0", "drives", "volumes")
+
+		compatible, err := checker.Check(ctx, csi)
+		assert.Nil(t, err)
+		assert.False(t, compatible)
+		assert.Equal(t, "1.6.2", csi.Status.AppliedVersions["node"])
+
+		condition := meta.FindStatusCondition(csi.Status.Conditions, csibaremetalv1.CompatibleCondition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, IncompatibleReason, condition.Reason)
+		assert.Contains(t, condition.Message, "node 1.5.0 is not supported")
+		assert.Contains(t, condition.Message, "csi-baremetal components have different versions (1.5: node; 1.6: ")
+		assert.Contains(t, condition.Message, "kubernetes v1.31.0 is not supported")
+		assert.Contains(t, condition.Message, "csi-baremetal.dell.com/v1 resources are not found: availablecapacities")
+	})
+
+	t.Run("CRDs are not installed", func(t *testing.T) {
+		csi := prepareDeployment("1.6.2")
+		csi.Status.AppliedVersions = map[string]string{operatorKey: "1.6.0"}
+		checker := prepareChecker(csi, "v1.27.3")
+
+		compatible, err := checker.Check(ctx, csi)
+		assert.Nil(t, err)
+		assert.False(t, compatible)
+		assert.Contains(t, csi.Status.Conditions[0].Message, "CRDs of csi-baremetal.dell.com/v1 are not installed")
+	})
+
+	t.Run("CRD versions differ from matrix", func(t *testing.T) {
+		csi := prepareDeployment("1.6.2")
+		csi.Status.AppliedVersions = map[string]string{operatorKey: "1.6.0"}
+		checker := prepareChecker(csi, "v1.27.3", crdResources...)
+
+		drives := &apiextensionsv1.CustomResourceDefinition{}
+		assert.Nil(t, checker.Client.Get(ctx, client.ObjectKey{Name: "drives.csi-baremetal.dell.com"}, drives))
+		drives.Spec.Versions = []apiextensionsv1.CustomResourceDefinitionVersion{
+			{Name: "v1", Served: false, Storage: false},
+			{Name: "v2", Served: true, Storage: true},
+		}
+		assert.Nil(t, checker.Client.Update(ctx, drives))
+
+		volumes := &apiextensionsv1.CustomResourceDefinition{}
+		assert.Nil(t, checker.Client.Get(ctx, client.ObjectKey{Name: "volumes.csi-baremetal.dell.com"}, volumes))
+		volumes.Status.StoredVersions = []string{"v1alpha1", "v1"}
+		assert.Nil(t, checker.Client.Status().Update(ctx, volumes))
+
+		compatible, err := checker.Check(ctx, csi)
+		assert.Nil(t, err)
+		assert.False(t, compatible)
+
+		condition := meta.FindStatusCondition(csi.Status.Conditions, csibaremetalv1.CompatibleCondition)
+		assert.Contains(t, condition.Message, "CRD drives.csi-baremetal.dell.com doesn't serve v1")
+		assert.Contains(t, condition.Message, "CRD drives.csi-baremetal.dell.com stores v2, expected v1")
+		assert.Contains(t, condition.Message, "objects of CRD volumes.csi-baremetal.dell.com are stored in v1alpha1, expected v1")
+		assert.NotContains(t, condition.Message, "nodes.csi-baremetal.dell.com")
+	})
+
+	t.Run("Applied versions are not recorded in plan", func(t *testing.T) {
+		csi := prepareDeployment("1.6.2")
+		checker := prepareChecker(csi, "v1.27.3", crdResources...)
+
+		assert.Nil(t, checker.RecordAppliedVersions(common.WithPlan(ctx, &common.Plan{}), csi))
+		assert.Nil(t, csi.Status.AppliedVersions)
+	})
+
+	t.Run("Incompatible versions are applied on fresh install", func(t *testing.T) {
+		csi := prepareDeployment("1.4.0")
+		checker := prepareChecker(csi, "v1.27.3", crdResources...)
+
+		compatible, err := checker.Check(ctx, csi)
+		assert.Nil(t, err)
+		assert.True(t, compatible)
+
+		condition := meta.FindStatusCondition(csi.Status.Conditions, csibaremetalv1.CompatibleCondition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, NotUpgradedReason, condition.Reason)
+
+		assert.Nil(t, checker.RecordAppliedVersions(ctx, csi))
+		assert.Equal(t, "1.4.0", csi.Status.AppliedVersions["node"])
+	})
+
+	t.Run("Installation applied without versions in status is upgraded", func(t *testing.T) {
+		csi := prepareDeployment("1.4.0")
+		checker := prepareChecker(csi, "v1.27.3", crdResources...)
+		_, err := checker.Clientset.AppsV1().Deployments(csi.Namespace).Create(ctx, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: controllerDeploymentName, Namespace: csi.Namespace}}, metav1.CreateOptions{})
+		assert.Nil(t, err)
+
+		compatible, err := checker.Check(ctx, csi)
+		assert.Nil(t, err)
+		assert.False(t, compatible)
+		assert.Nil(t, csi.Status.AppliedVersions)
+	})
+
+	t.Run("Unchanged versions are applied after Kubernetes upgrade", func(t *testing.T) {
+		csi := prepareDeployment("1.6.2")
+		csi.Status.AppliedVersions = getAppliedVersions(csi)
+		checker := prepareChecker(csi, "v1.31.0", crdResources...)
+
+		compatible, err := checker.Check(ctx, csi)
+		assert.Nil(t, err)
+		assert.True(t, compatible)
+
+		condition := meta.FindStatusCondition(csi.Status.Conditions, csibaremetalv1.CompatibleCondition)
+		assert.Equal(t, NotUpgradedReason, condition.Reason)
+		assert.Contains(t, condition.Message, "kubernetes v1.31.0 is not supported")
+	})
+
+	t.Run("Discovery result is cached", func(t *testing.T) {
+		csi := prepareDeployment("1.6.2")
+		checker := prepareChecker(csi, "v1.27.3", crdResources...)
+		clientSet := checker.Clientset.(*fake.Clientset)
+
+		_, err := checker.Check(ctx, csi)
+		assert.Nil(t, err)
+		actions := len(clientSet.Actions())
+
+		_, err = checker.Check(ctx, csi)
+		assert.Nil(t, err)
+		assert.Equal(t, actions, len(clientSet.Actions()))
+
+		checker.cacheTime = time.Now().Add(-discoveryCacheTTL)
+		_, err = checker.Check(ctx, csi)
+		assert.Nil(t, err)
+		assert.Greater(t, len(clientSet.Actions()), actions)
+	})
+
+	t.Run("Incompatible versions are applied with override annotation", func(t *testing.T) {
+		csi := prepareDeployment("1.4.0")
+		csi.Status.AppliedVersions = map[string]string{operatorKey: "1.6.0"}
+		csi.Annotations = map[string]string{csibaremetalv1.SkipCompatibilityCheckAnnotation: "true"}
+		checker := prepareChecker(csi, "v1.27.3", crdResources...)
+
+		compatible, err := checker.Check(ctx, csi)
+		assert.Nil(t, err)
+		assert.True(t, compatible)
+
+		condition := meta.FindStatusCondition(csi.Status.Conditions, csibaremetalv1.CompatibleCondition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, OverriddenReason, condition.Reason)
+	})
+}
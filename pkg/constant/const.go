@@ -28,6 +28,12 @@ const (
 
 	// PrometheusPort - default prometeus port
 	PrometheusPort = 8787
+	// PrometheusPath - default prometeus path
+	PrometheusPath = "/metrics"
+	// MetricsNamespace - namespace of operator prometheus metrics
+	MetricsNamespace = "csi_baremetal_operator"
+	// NodeControllerMetricsPort - default metrics port of node-controller, default one of controller-runtime manager
+	NodeControllerMetricsPort = 8080
 	// LivenessPort - default liveness port
 	LivenessPort = "liveness-port"
 
@@ -52,6 +58,14 @@ const (
 	DriverRegistrarName = "csi-node-driver-registrar"
 	// LivenessProbeName - name of livenessprobe sidecar
 	LivenessProbeName = "livenessprobe"
+	// SnapshotterName - name of csi-snapshotter sidecar
+	SnapshotterName = "csi-snapshotter"
+	// HealthMonitorControllerName - name of csi-external-health-monitor-controller sidecar
+	HealthMonitorControllerName = "csi-external-health-monitor-controller"
+	// HealthMonitorAgentName - name of csi-external-health-monitor-agent sidecar
+	HealthMonitorAgentName = "csi-external-health-monitor-agent"
+	// ControllerServiceAccountName - name of csi-baremetal-controller service account
+	ControllerServiceAccountName = "csi-controller-sa"
 
 	// AppLabelKey matches CSI CRs with csi-baremetal app
 	AppLabelKey = "app.kubernetes.io/name"
@@ -68,6 +82,8 @@ const (
 	SelectorKey = "name"
 	// RsysLabelKey are used for directory layout in rsyslog
 	RsysLabelKey = "app.kubernetes.io/instance"
+	// ConfigHashAnnotation contains checksum of ConfigMaps and Secrets used by pods, pods are restarted when it's changed
+	ConfigHashAnnotation = "csi-baremetal.dell.com/config-hash"
 	// DefaultNamespace is the default namespace
 	DefaultNamespace = "default"
 	// LogLevelSlogan parameter definition
@@ -2,6 +2,7 @@ package pkg
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 
@@ -13,12 +14,16 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
 	"github.com/dell/csi-baremetal-operator/api/v1/components"
 	"github.com/dell/csi-baremetal-operator/pkg/common"
 	"github.com/dell/csi-baremetal-operator/pkg/constant"
+	securityverifier "github.com/dell/csi-baremetal-operator/pkg/feature/security_verifier"
+	verifierModels "github.com/dell/csi-baremetal-operator/pkg/feature/security_verifier/models"
+	"github.com/dell/csi-baremetal-operator/pkg/logreceiver"
 )
 
 const (
@@ -27,7 +32,7 @@ const (
 	replicasCount  = 1
 
 	controllerRoleKey            = "csi-do"
-	controllerServiceAccountName = "csi-controller-sa"
+	controllerServiceAccountName = constant.ControllerServiceAccountName
 
 	// ports
 	healthPort = 9999
@@ -40,25 +45,72 @@ const (
 
 // Controller controls csi-baremetal-controller
 type Controller struct {
-	Clientset kubernetes.Interface
+	Clientset             kubernetes.Interface
+	Client                client.Client
+	HealthMonitorVerifier securityverifier.SecurityVerifier
 	*logrus.Entry
 }
 
 // Update updates csi-baremetal-controller or creates if not found
 func (c *Controller) Update(ctx context.Context, csi *csibaremetalv1.Deployment, scheme *runtime.Scheme) error {
+	withHealthMonitor, err := c.verifyHealthMonitor(ctx, csi)
+	if err != nil {
+		return err
+	}
+
 	// create deployment
 	expected := createControllerDeployment(csi)
+	if !withHealthMonitor {
+		common.RemoveContainer(&expected.Spec.Template, constant.HealthMonitorControllerName)
+	}
 	if err := controllerutil.SetControllerReference(csi, expected, scheme); err != nil {
 		return err
 	}
 
+	if err := common.AddConfigHashAnnotation(ctx, c.Clientset, csi, &expected.Spec.Template); err != nil {
+		return err
+	}
+
 	if err := common.UpdateDeployment(ctx, c.Clientset, expected, c.Entry); err != nil {
 		return err
 	}
 
+	if err := c.updateSnapshotClasses(ctx, csi); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// verifyHealthMonitor validates cluster role bindings of controller service account in case of health monitor
+// sidecar enabled and reports failure in ControllerHealthMonitorVerified condition.
+// Returns false if the sidecar mustn't be deployed
+func (c *Controller) verifyHealthMonitor(ctx context.Context, csi *csibaremetalv1.Deployment) (bool, error) {
+	conditionType := csibaremetalv1.ControllerHealthMonitorVerifiedCondition
+	if csi.Spec.Driver.Controller.Sidecars[constant.HealthMonitorControllerName] == nil {
+		return false, common.RemoveStatusCondition(ctx, c.Client, csi, conditionType)
+	}
+
+	if err := c.HealthMonitorVerifier.Verify(ctx, csi, verifierModels.Controller); err != nil {
+		var verifierError securityverifier.Error
+		err = c.HealthMonitorVerifier.HandleError(ctx, csi, controllerServiceAccountName, err)
+		if !errors.As(err, &verifierError) {
+			return false, err
+		}
+		return false, common.UpdateStatusCondition(ctx, c.Client, csi, securityverifier.NewHealthMonitorCondition(conditionType, err))
+	}
+	return true, common.RemoveStatusCondition(ctx, c.Client, csi, conditionType)
+}
+
+// Uninstall deletes resources created by csi-baremetal-controller reconciler
+func (c *Controller) Uninstall(ctx context.Context, csi *csibaremetalv1.Deployment) error {
+	if !isSnapshotterEnabled(csi) {
+		return nil
+	}
+
+	return c.deleteSnapshotClasses(ctx)
+}
+
 func createControllerDeployment(csi *csibaremetalv1.Deployment) *v1.Deployment {
 	var (
 		selectors = common.ConstructSelectorMap(controllerName)
@@ -68,7 +120,7 @@ func createControllerDeployment(csi *csibaremetalv1.Deployment) *v1.Deployment {
 	selectors["role"] = controllerRoleKey
 	labels["role"] = controllerRoleKey
 
-	return &v1.Deployment{
+	deployment := &v1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      controllerName,
 			Namespace: csi.GetNamespace(),
@@ -87,11 +139,8 @@ func createControllerDeployment(csi *csibaremetalv1.Deployment) *v1.Deployment {
 					// labels
 					Labels: labels,
 					// integration with monitoring
-					Annotations: map[string]string{
-						"prometheus.io/scrape": "true",
-						"prometheus.io/port":   strconv.Itoa(constant.PrometheusPort),
-						"prometheus.io/path":   "/metrics",
-					},
+					Annotations: common.MetricsAnnotations(common.MetricsPort(csi.Spec.Driver.Metrics),
+						common.MetricsPath(csi.Spec.Driver.Metrics)),
 				},
 				Spec: corev1.PodSpec{
 					Volumes: []corev1.Volume{
@@ -117,6 +166,11 @@ func createControllerDeployment(csi *csibaremetalv1.Deployment) *v1.Deployment {
 			},
 		},
 	}
+
+	logreceiver.AddSidecar(&deployment.Spec.Template, csi)
+	common.ApplyPodOverrides(&deployment.Spec.Template, csi.Spec.Driver.Controller.PodOverrides)
+
+	return deployment
 }
 
 func createControllerContainers(csi *csibaremetalv1.Deployment) []corev1.Container {
@@ -126,10 +180,10 @@ func createControllerContainers(csi *csibaremetalv1.Deployment) []corev1.Contain
 		liveness    = csi.Spec.Driver.Controller.Sidecars[constant.LivenessProbeName]
 		c           = csi.Spec.Driver.Controller
 	)
-	return []corev1.Container{
+	containers := []corev1.Container{
 		{
 			Name:            controller,
-			Image:           common.ConstructFullImageName(c.Image, csi.Spec.GlobalRegistry),
+			Image:           common.ConstructFullImageName(c.Image, csi),
 			ImagePullPolicy: corev1.PullPolicy(csi.Spec.PullPolicy),
 			Args: []string{
 				"--endpoint=$(CSI_ENDPOINT)",
@@ -137,8 +191,8 @@ func createControllerContainers(csi *csibaremetalv1.Deployment) []corev1.Contain
 				"--extender=true",
 				constant.LogLevelSlogan + common.MatchLogLevel(c.Log.Level),
 				"--healthport=" + strconv.Itoa(healthPort),
-				"--metrics-address=:" + strconv.Itoa(constant.PrometheusPort),
-				"--metrics-path=/metrics",
+				"--metrics-address=:" + strconv.Itoa(int(common.MetricsPort(csi.Spec.Driver.Metrics))),
+				"--metrics-path=" + common.MetricsPath(csi.Spec.Driver.Metrics),
 				"--sequential-lvg-reservation=" + strconv.FormatBool(csi.Spec.SequentialLVGReservation),
 			},
 			Env: []corev1.EnvVar{
@@ -167,7 +221,7 @@ func createControllerContainers(csi *csibaremetalv1.Deployment) []corev1.Contain
 			},
 			Ports: []corev1.ContainerPort{
 				{Name: constant.LivenessPort, ContainerPort: 9808, Protocol: corev1.ProtocolTCP},
-				{Name: "metrics", ContainerPort: constant.PrometheusPort, Protocol: corev1.ProtocolTCP},
+				{Name: "metrics", ContainerPort: common.MetricsPort(csi.Spec.Driver.Metrics), Protocol: corev1.ProtocolTCP},
 			},
 			LivenessProbe: &corev1.Probe{
 				ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{
@@ -196,7 +250,7 @@ func createControllerContainers(csi *csibaremetalv1.Deployment) []corev1.Contain
 		},
 		{
 			Name:  constant.ProvisionerName,
-			Image: common.ConstructFullImageName(provisioner.Image, csi.Spec.GlobalRegistry),
+			Image: common.ConstructFullImageName(provisioner.Image, csi),
 			Args: append(
 				[]string{
 					// default csi-provisioner args
@@ -226,7 +280,7 @@ func createControllerContainers(csi *csibaremetalv1.Deployment) []corev1.Contain
 		},
 		{
 			Name:            constant.ResizerName,
-			Image:           common.ConstructFullImageName(resizer.Image, csi.Spec.GlobalRegistry),
+			Image:           common.ConstructFullImageName(resizer.Image, csi),
 			ImagePullPolicy: corev1.PullPolicy(csi.Spec.PullPolicy),
 			Command:         []string{"/csi-resizer"},
 			Args: []string{
@@ -247,7 +301,7 @@ func createControllerContainers(csi *csibaremetalv1.Deployment) []corev1.Contain
 		},
 		{
 			Name:            constant.LivenessProbeName,
-			Image:           common.ConstructFullImageName(liveness.Image, csi.Spec.GlobalRegistry),
+			Image:           common.ConstructFullImageName(liveness.Image, csi),
 			ImagePullPolicy: corev1.PullPolicy(csi.Spec.PullPolicy),
 			Args:            []string{"--csi-address=$(ADDRESS)"},
 			Env: []corev1.EnvVar{
@@ -262,6 +316,96 @@ func createControllerContainers(csi *csibaremetalv1.Deployment) []corev1.Contain
 			Resources:                common.ConstructResourceRequirements(liveness.Resources),
 		},
 	}
+
+	if snapshotter := c.Sidecars[constant.SnapshotterName]; snapshotter != nil {
+		containers = append(containers, createSnapshotterContainer(csi, snapshotter))
+	}
+
+	if healthMonitor := c.Sidecars[constant.HealthMonitorControllerName]; healthMonitor != nil {
+		containers = append(containers, createHealthMonitorControllerContainer(csi, healthMonitor))
+	}
+
+	return containers
+}
+
+func createSnapshotterContainer(csi *csibaremetalv1.Deployment, snapshotter *components.Sidecar) corev1.Container {
+	var (
+		timeout       = defaultSnapshotterTimeout
+		workerThreads = 0
+	)
+	if snapshotter.Args != nil {
+		if snapshotter.Args.Timeout != "" {
+			timeout = snapshotter.Args.Timeout
+		}
+		workerThreads = snapshotter.Args.WorkerThreads
+	}
+
+	args := []string{
+		"--csi-address=$(ADDRESS)",
+		"--v=5",
+		"--leader-election",
+		fmt.Sprintf("--timeout=%v", timeout),
+	}
+	if workerThreads > 0 {
+		args = append(args, fmt.Sprintf("--worker-threads=%v", workerThreads))
+	}
+
+	return corev1.Container{
+		Name:            constant.SnapshotterName,
+		Image:           common.ConstructFullImageName(snapshotter.Image, csi),
+		ImagePullPolicy: corev1.PullPolicy(csi.Spec.PullPolicy),
+		Args:            args,
+		Env: []corev1.EnvVar{
+			{Name: "ADDRESS", Value: "/csi/csi.sock"},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: constant.CSISocketDirVolume, MountPath: "/csi"},
+			constant.CrashMountVolume,
+		},
+		TerminationMessagePath:   constant.TerminationMessagePath,
+		TerminationMessagePolicy: constant.TerminationMessagePolicy,
+		Resources:                common.ConstructResourceRequirements(snapshotter.Resources),
+	}
+}
+
+func createHealthMonitorControllerContainer(csi *csibaremetalv1.Deployment, healthMonitor *components.Sidecar) corev1.Container {
+	args := []string{
+		"--csi-address=$(ADDRESS)",
+		"--v=5",
+		"--leader-election",
+	}
+	if healthMonitor.Args != nil {
+		if healthMonitor.Args.Timeout != "" {
+			args = append(args, fmt.Sprintf("--timeout=%v", healthMonitor.Args.Timeout))
+		}
+		if healthMonitor.Args.MonitorInterval != "" {
+			args = append(args, fmt.Sprintf("--monitor-interval=%v", healthMonitor.Args.MonitorInterval))
+		}
+		if healthMonitor.Args.ListVolumesInterval != "" {
+			args = append(args, fmt.Sprintf("--list-volumes-interval=%v", healthMonitor.Args.ListVolumesInterval))
+		}
+		if healthMonitor.Args.WorkerThreads > 0 {
+			args = append(args, fmt.Sprintf("--worker-threads=%v", healthMonitor.Args.WorkerThreads))
+		}
+		args = append(args, fmt.Sprintf("--enable-node-watcher=%v", healthMonitor.Args.EnableNodeWatcher))
+	}
+
+	return corev1.Container{
+		Name:            constant.HealthMonitorControllerName,
+		Image:           common.ConstructFullImageName(healthMonitor.Image, csi),
+		ImagePullPolicy: corev1.PullPolicy(csi.Spec.PullPolicy),
+		Args:            args,
+		Env: []corev1.EnvVar{
+			{Name: "ADDRESS", Value: "/csi/csi.sock"},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: constant.CSISocketDirVolume, MountPath: "/csi"},
+			constant.CrashMountVolume,
+		},
+		TerminationMessagePath:   constant.TerminationMessagePath,
+		TerminationMessagePolicy: constant.TerminationMessagePolicy,
+		Resources:                common.ConstructResourceRequirements(healthMonitor.Resources),
+	}
 }
 
 func createControllerSecurityContext(ctx *components.SecurityContext) *corev1.PodSecurityContext {
@@ -0,0 +1,292 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	apiV1 "github.com/dell/csi-baremetal/api/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	snapshotGroupVersion      = "snapshot.storage.k8s.io/v1"
+	volumeSnapshotClassKind   = "VolumeSnapshotClass"
+	snapshotClassPrefix       = constant.CSIName + "-snapshotclass-"
+	snapshotStorageTypeParam  = "storageType"
+	snapshotControllerLabel   = "snapshot-controller"
+	snapshotDeletionPolicy    = "Delete"
+	snapshotDriverName        = constant.CSIName
+	defaultSnapshotterTimeout = "300s"
+
+	// SnapshotCRDsMissingReason is set for SnapshotReady condition if snapshot.storage.k8s.io CRDs are not installed
+	SnapshotCRDsMissingReason = "SnapshotCRDsMissing"
+	// SnapshotControllerMissingReason is set for SnapshotReady condition if snapshot-controller is not running
+	SnapshotControllerMissingReason = "SnapshotControllerMissing"
+	// SnapshotClassesCreatedReason is set for SnapshotReady condition if VolumeSnapshotClasses are created
+	SnapshotClassesCreatedReason = "SnapshotClassesCreated"
+)
+
+var (
+	// snapshotResources are snapshot.storage.k8s.io resources required by csi-snapshotter
+	snapshotResources = []string{"volumesnapshotclasses", "volumesnapshots", "volumesnapshotcontents"}
+
+	// snapshotStorageTypes are csi-baremetal storage types, VolumeSnapshotClass is created for each of them
+	snapshotStorageTypes = []string{
+		apiV1.StorageClassAny,
+		apiV1.StorageClassHDD,
+		apiV1.StorageClassSSD,
+		apiV1.StorageClassNVMe,
+		apiV1.StorageClassHDDLVG,
+		apiV1.StorageClassSSDLVG,
+		apiV1.StorageClassNVMeLVG,
+		apiV1.StorageClassSystemLVG,
+	}
+
+	volumeSnapshotClassGVK = schema.FromAPIVersionAndKind(snapshotGroupVersion, volumeSnapshotClassKind)
+
+	// snapshotClassFields are top-level fields of VolumeSnapshotClass, which are managed by operator
+	snapshotClassFields = []string{"driver", "deletionPolicy", "parameters"}
+)
+
+// isSnapshotterEnabled checks that csi-snapshotter sidecar is set in csi Deployment
+func isSnapshotterEnabled(csi *csibaremetalv1.Deployment) bool {
+	return csi.Spec.Driver.Controller.Sidecars[constant.SnapshotterName] != nil
+}
+
+// updateSnapshotClasses creates VolumeSnapshotClasses for csi-baremetal storage types
+// and reports SnapshotReady condition of csi Deployment
+func (c *Controller) updateSnapshotClasses(ctx context.Context, csi *csibaremetalv1.Deployment) error {
+	if !isSnapshotterEnabled(csi) {
+		return common.RemoveStatusCondition(ctx, c.Client, csi, csibaremetalv1.SnapshotReadyCondition)
+	}
+
+	missing, err := c.getMissingSnapshotResources()
+	if err != nil {
+		return err
+	}
+	if len(missing) != 0 {
+		c.Warnf("Snapshot CRDs are not installed: %s", strings.Join(missing, ", "))
+		return common.UpdateStatusCondition(ctx, c.Client, csi, metav1.Condition{
+			Type:    csibaremetalv1.SnapshotReadyCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  SnapshotCRDsMissingReason,
+			Message: fmt.Sprintf("%s resources are not found: %s", snapshotGroupVersion, strings.Join(missing, ", ")),
+		})
+	}
+
+	if err = c.createSnapshotClasses(ctx); err != nil {
+		return err
+	}
+
+	found, err := c.isSnapshotControllerRunning(ctx)
+	if err != nil {
+		return err
+	}
+	if !found {
+		c.Warn("Snapshot controller is not found")
+		return common.UpdateStatusCondition(ctx, c.Client, csi, metav1.Condition{
+			Type:    csibaremetalv1.SnapshotReadyCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  SnapshotControllerMissingReason,
+			Message: "Snapshot controller pods are not found, VolumeSnapshots won't be processed",
+		})
+	}
+
+	return common.UpdateStatusCondition(ctx, c.Client, csi, metav1.Condition{
+		Type:    csibaremetalv1.SnapshotReadyCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  SnapshotClassesCreatedReason,
+		Message: "VolumeSnapshotClasses are created",
+	})
+}
+
+// getMissingSnapshotResources returns snapshot.storage.k8s.io resources, which are not served by API server
+func (c *Controller) getMissingSnapshotResources() ([]string, error) {
+	resources, err := c.Clientset.Discovery().ServerResourcesForGroupVersion(snapshotGroupVersion)
+	if err != nil {
+		if k8sError.IsNotFound(err) {
+			return snapshotResources, nil
+		}
+		return nil, err
+	}
+
+	served := map[string]bool{}
+	for _, resource := range resources.APIResources {
+		served[resource.Name] = true
+	}
+
+	var missing []string
+	for _, name := range snapshotResources {
+		if !served[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+// isSnapshotControllerRunning checks that snapshot-controller pods exist in any namespace
+func (c *Controller) isSnapshotControllerRunning(ctx context.Context) (bool, error) {
+	for _, key := range []string{constant.AppLabelKey, constant.AppLabelShortKey} {
+		pods, err := c.Clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s", key, snapshotControllerLabel),
+		})
+		if err != nil {
+			return false, err
+		}
+		if len(pods.Items) != 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// createSnapshotClasses creates VolumeSnapshotClass per storage type or updates it if driver,
+// deletionPolicy or parameters are changed
+func (c *Controller) createSnapshotClasses(ctx context.Context) error {
+	var errMsgs []string
+	for _, storageType := range snapshotStorageTypes {
+		expected := createSnapshotClass(storageType)
+
+		found := &unstructured.Unstructured{}
+		found.SetGroupVersionKind(volumeSnapshotClassGVK)
+		err := c.Client.Get(ctx, client.ObjectKey{Name: expected.GetName()}, found)
+		if err == nil {
+			if err = c.updateSnapshotClass(ctx, expected, found); err != nil {
+				errMsgs = append(errMsgs, err.Error())
+			}
+			continue
+		}
+		if !k8sError.IsNotFound(err) {
+			errMsgs = append(errMsgs, err.Error())
+			continue
+		}
+
+		if plan := common.GetPlan(ctx); plan != nil {
+			plan.Add(csibaremetalv1.PlannedCreate, volumeSnapshotClassKind, "", expected.GetName())
+			continue
+		}
+		if err = c.Client.Create(ctx, expected); err != nil {
+			errMsgs = append(errMsgs, err.Error())
+			continue
+		}
+		c.Infof("VolumeSnapshotClass %s created", expected.GetName())
+	}
+
+	if len(errMsgs) != 0 {
+		return fmt.Errorf(strings.Join(errMsgs, "\n"))
+	}
+	return nil
+}
+
+// updateSnapshotClass updates found VolumeSnapshotClass if it differs from expected,
+// the class is recreated if the update is rejected
+func (c *Controller) updateSnapshotClass(ctx context.Context, expected, found *unstructured.Unstructured) error {
+	changes := describeSnapshotClassChanges(expected, found)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// it's unknown before the update whether it's rejected, so the recreation isn't planned
+	if plan := common.GetPlan(ctx); plan != nil {
+		plan.Add(csibaremetalv1.PlannedUpdate, volumeSnapshotClassKind, "", expected.GetName(), changes...)
+		return nil
+	}
+
+	for _, field := range snapshotClassFields {
+		found.Object[field] = expected.Object[field]
+	}
+	labels := found.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for key, value := range expected.GetLabels() {
+		labels[key] = value
+	}
+	found.SetLabels(labels)
+
+	err := c.Client.Update(ctx, found)
+	if err == nil {
+		c.Infof("VolumeSnapshotClass %s updated", expected.GetName())
+		return nil
+	}
+	if !k8sError.IsInvalid(err) {
+		return err
+	}
+
+	c.Warnf("VolumeSnapshotClass %s can't be updated, recreating: %s", expected.GetName(), err.Error())
+	if err = c.Client.Delete(ctx, found); err != nil && !k8sError.IsNotFound(err) {
+		return err
+	}
+	if err = c.Client.Create(ctx, expected); err != nil {
+		return err
+	}
+	c.Infof("VolumeSnapshotClass %s recreated", expected.GetName())
+	return nil
+}
+
+// describeSnapshotClassChanges returns changed fields and labels of VolumeSnapshotClass, which are managed by operator
+func describeSnapshotClassChanges(expected, found *unstructured.Unstructured) []string {
+	var details []string
+	for _, field := range snapshotClassFields {
+		if !equality.Semantic.DeepEqual(expected.Object[field], found.Object[field]) {
+			details = append(details, field+" is changed")
+		}
+	}
+	var labels []string
+	for key, value := range expected.GetLabels() {
+		if found.GetLabels()[key] != value {
+			labels = append(labels, "label "+key+" is changed")
+		}
+	}
+	sort.Strings(labels)
+	return append(details, labels...)
+}
+
+// deleteSnapshotClasses removes VolumeSnapshotClasses created by operator
+func (c *Controller) deleteSnapshotClasses(ctx context.Context) error {
+	missing, err := c.getMissingSnapshotResources()
+	if err != nil {
+		return err
+	}
+	if len(missing) != 0 {
+		return nil
+	}
+
+	var errMsgs []string
+	for _, storageType := range snapshotStorageTypes {
+		if err = c.Client.Delete(ctx, createSnapshotClass(storageType)); err != nil && !k8sError.IsNotFound(err) {
+			errMsgs = append(errMsgs, err.Error())
+		}
+	}
+
+	if len(errMsgs) != 0 {
+		return fmt.Errorf(strings.Join(errMsgs, "\n"))
+	}
+	return nil
+}
+
+func createSnapshotClass(storageType string) *unstructured.Unstructured {
+	class := &unstructured.Unstructured{}
+	class.SetGroupVersionKind(volumeSnapshotClassGVK)
+	class.SetName(getSnapshotClassName(storageType))
+	class.SetLabels(common.ConstructLabelAppMap())
+	class.Object["driver"] = snapshotDriverName
+	class.Object["deletionPolicy"] = snapshotDeletionPolicy
+	class.Object["parameters"] = map[string]interface{}{snapshotStorageTypeParam: storageType}
+	return class
+}
+
+func getSnapshotClassName(storageType string) string {
+	return snapshotClassPrefix + strings.ToLower(storageType)
+}
@@ -0,0 +1,180 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+func Test_createSnapshotterContainer(t *testing.T) {
+	deployment := prepareSnapshotDeployment()
+	containers := createControllerContainers(deployment)
+	assert.Len(t, containers, 4)
+
+	deployment.Spec.Driver.Controller.Sidecars[constant.SnapshotterName] = &components.Sidecar{
+		Image: &components.Image{Name: "snapshotter"},
+		Args:  &components.Args{Timeout: "60s", WorkerThreads: 2},
+	}
+	containers = createControllerContainers(deployment)
+	assert.Len(t, containers, 5)
+
+	snapshotter := containers[4]
+	assert.Equal(t, constant.SnapshotterName, snapshotter.Name)
+	assert.Contains(t, snapshotter.Args, "--timeout=60s")
+	assert.Contains(t, snapshotter.Args, "--worker-threads=2")
+	assert.Equal(t, "/csi", snapshotter.VolumeMounts[0].MountPath)
+}
+
+func Test_updateSnapshotClasses(t *testing.T) {
+	var (
+		ctx           = context.Background()
+		deployment    = prepareSnapshotDeployment()
+		controllerPod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "snapshot-controller-0",
+				Namespace: "kube-system",
+				Labels:    map[string]string{constant.AppLabelShortKey: snapshotControllerLabel},
+			},
+		}
+	)
+	deployment.Spec.Driver.Controller.Sidecars[constant.SnapshotterName] = &components.Sidecar{
+		Image: &components.Image{Name: "snapshotter"},
+	}
+
+	scheme, _ := common.PrepareScheme()
+	scheme.AddKnownTypeWithName(volumeSnapshotClassGVK, &unstructured.Unstructured{})
+	clientSet := fake.NewSimpleClientset()
+	c := prepareSnapshotController(clientSet, prepareSnapshotClient(scheme, deployment))
+
+	// snapshot CRDs are not installed
+	err := c.updateSnapshotClasses(ctx, deployment)
+	assert.Nil(t, err)
+	assertSnapshotCondition(t, deployment, metav1.ConditionFalse, SnapshotCRDsMissingReason)
+
+	// snapshot controller is not running
+	addSnapshotResources(t, clientSet)
+	err = c.updateSnapshotClasses(ctx, deployment)
+	assert.Nil(t, err)
+	assertSnapshotCondition(t, deployment, metav1.ConditionFalse, SnapshotControllerMissingReason)
+
+	for _, storageType := range snapshotStorageTypes {
+		class := &unstructured.Unstructured{}
+		class.SetGroupVersionKind(volumeSnapshotClassGVK)
+		err = c.Client.Get(ctx, client.ObjectKey{Name: getSnapshotClassName(storageType)}, class)
+		assert.Nil(t, err)
+		assert.Equal(t, constant.CSIName, class.Object["driver"])
+	}
+
+	// snapshot controller is running
+	_, err = clientSet.CoreV1().Pods(controllerPod.Namespace).Create(ctx, controllerPod, metav1.CreateOptions{})
+	assert.Nil(t, err)
+	err = c.updateSnapshotClasses(ctx, deployment)
+	assert.Nil(t, err)
+	assertSnapshotCondition(t, deployment, metav1.ConditionTrue, SnapshotClassesCreatedReason)
+
+	// changed snapshot class is updated
+	changed := &unstructured.Unstructured{}
+	changed.SetGroupVersionKind(volumeSnapshotClassGVK)
+	assert.Nil(t, c.Client.Get(ctx, client.ObjectKey{Name: getSnapshotClassName(snapshotStorageTypes[0])}, changed))
+	changed.Object["deletionPolicy"] = "Retain"
+	changed.Object["parameters"] = map[string]interface{}{snapshotStorageTypeParam: "unknown"}
+	assert.Nil(t, c.Client.Update(ctx, changed))
+
+	// changes are only recorded in plan-only mode
+	assert.Nil(t, c.Client.Delete(ctx, createSnapshotClass(snapshotStorageTypes[1])))
+	plan := &common.Plan{}
+	err = c.createSnapshotClasses(common.WithPlan(ctx, plan))
+	assert.Nil(t, err)
+	assert.Equal(t, []v1.PlannedChange{
+		{Action: v1.PlannedUpdate, Kind: volumeSnapshotClassKind, Name: getSnapshotClassName(snapshotStorageTypes[0]),
+			Details: []string{"deletionPolicy is changed", "parameters is changed"}},
+		{Action: v1.PlannedCreate, Kind: volumeSnapshotClassKind, Name: getSnapshotClassName(snapshotStorageTypes[1])},
+	}, plan.Result(1).Changes)
+	class := &unstructured.Unstructured{}
+	class.SetGroupVersionKind(volumeSnapshotClassGVK)
+	err = c.Client.Get(ctx, client.ObjectKey{Name: getSnapshotClassName(snapshotStorageTypes[1])}, class)
+	assert.True(t, k8sError.IsNotFound(err))
+
+	err = c.updateSnapshotClasses(ctx, deployment)
+	assert.Nil(t, err)
+	assert.Nil(t, c.Client.Get(ctx, client.ObjectKey{Name: getSnapshotClassName(snapshotStorageTypes[0])}, changed))
+	assert.Equal(t, snapshotDeletionPolicy, changed.Object["deletionPolicy"])
+	assert.Equal(t, map[string]interface{}{snapshotStorageTypeParam: snapshotStorageTypes[0]}, changed.Object["parameters"])
+
+	// snapshot classes are removed on uninstall
+	err = c.Uninstall(ctx, deployment)
+	assert.Nil(t, err)
+	err = c.Client.Get(ctx, client.ObjectKey{Name: getSnapshotClassName(snapshotStorageTypes[0])}, class)
+	assert.NotNil(t, err)
+
+	// condition is removed if snapshotter is disabled
+	deployment.Spec.Driver.Controller.Sidecars = nil
+	err = c.updateSnapshotClasses(ctx, deployment)
+	assert.Nil(t, err)
+	assert.Nil(t, meta.FindStatusCondition(deployment.Status.Conditions, v1.SnapshotReadyCondition))
+}
+
+// prepareSnapshotDeployment copies testControllerDeployment with its own sidecars map,
+// Deployment DeepCopy doesn't copy Spec pointers
+func prepareSnapshotDeployment() *v1.Deployment {
+	deployment := testControllerDeployment.DeepCopy()
+	driver := *deployment.Spec.Driver
+	controller := *driver.Controller
+	controller.Sidecars = map[string]*components.Sidecar{}
+	for name, sidecar := range testControllerDeployment.Spec.Driver.Controller.Sidecars {
+		controller.Sidecars[name] = sidecar
+	}
+	driver.Controller = &controller
+	deployment.Spec.Driver = &driver
+	return deployment
+}
+
+func assertSnapshotCondition(t *testing.T, deployment *v1.Deployment, status metav1.ConditionStatus, reason string) {
+	condition := meta.FindStatusCondition(deployment.Status.Conditions, v1.SnapshotReadyCondition)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, status, condition.Status)
+		assert.Equal(t, reason, condition.Reason)
+	}
+}
+
+func addSnapshotResources(t *testing.T, clientSet *fake.Clientset) {
+	fakeDiscovery, ok := clientSet.Discovery().(*fakediscovery.FakeDiscovery)
+	if !ok {
+		t.Fatalf("couldn't convert Discovery() to *FakeDiscovery")
+	}
+
+	resources := &metav1.APIResourceList{GroupVersion: snapshotGroupVersion}
+	for _, name := range snapshotResources {
+		resources.APIResources = append(resources.APIResources, metav1.APIResource{Name: name})
+	}
+	fakeDiscovery.Resources = append(fakeDiscovery.Resources, resources)
+}
+
+func prepareSnapshotController(clientSet kubernetes.Interface, client client.Client) *Controller {
+	c := prepareController(clientSet)
+	c.Client = client
+	return c
+}
+
+func prepareSnapshotClient(scheme *runtime.Scheme, objects ...client.Object) client.Client {
+	builder := fakeClient.ClientBuilder{}
+	builderWithScheme := builder.WithScheme(scheme)
+	return builderWithScheme.WithObjects(objects...).WithStatusSubresource(&v1.Deployment{}).Build()
+}
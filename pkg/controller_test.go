@@ -5,15 +5,21 @@ import (
 	"context"
 	"testing"
 
+	"github.com/dell/csi-baremetal/pkg/events/mocks"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"k8s.io/client-go/kubernetes"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "github.com/dell/csi-baremetal-operator/api/v1"
 	"github.com/dell/csi-baremetal-operator/api/v1/components"
 	"github.com/dell/csi-baremetal-operator/pkg/common"
 	"github.com/dell/csi-baremetal-operator/pkg/constant"
+	securityverifier "github.com/dell/csi-baremetal-operator/pkg/feature/security_verifier"
+	"github.com/dell/csi-baremetal-operator/pkg/validator"
+	"github.com/dell/csi-baremetal-operator/pkg/validator/rbac"
 )
 
 var (
@@ -90,9 +96,62 @@ func Test_Update_Controller(t *testing.T) {
 	})
 }
 
+func Test_Update_Controller_HealthMonitorUnverified(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		deployment = testControllerDeployment.DeepCopy()
+	)
+	deployment.Spec.Driver.Controller.Sidecars[constant.HealthMonitorControllerName] = &components.Sidecar{
+		Image: &components.Image{Name: "health-monitor"}}
+	scheme, _ := common.PrepareScheme()
+	clientSet := prepareNodeClientSet()
+
+	eventRecorder := new(mocks.EventRecorder)
+	eventRecorder.On("Eventf", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	cl := prepareFakeValidatorClient(scheme, deployment)
+	controller := &Controller{
+		Clientset: clientSet,
+		Client:    cl,
+		HealthMonitorVerifier: securityverifier.NewHealthMonitorVerifier(
+			validator.NewValidator(rbac.NewValidator(cl, logControllerEntry, rbac.NewMatcher())),
+			eventRecorder,
+			logControllerEntry,
+		),
+		Entry: logControllerEntry,
+	}
+
+	err := controller.Update(ctx, deployment, scheme)
+	assert.Nil(t, err)
+	eventRecorder.AssertCalled(t, "Eventf", mock.Anything, mock.Anything, "HealthMonitorVerificationFailed",
+		mock.Anything, mock.Anything, mock.Anything)
+
+	condition := meta.FindStatusCondition(deployment.Status.Conditions, v1.ControllerHealthMonitorVerifiedCondition)
+	assert.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+
+	// controller is deployed without the sidecar
+	controllerDeployment, err := clientSet.AppsV1().Deployments(deployment.Namespace).Get(ctx, controllerName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	for _, container := range controllerDeployment.Spec.Template.Spec.Containers {
+		assert.NotEqual(t, constant.HealthMonitorControllerName, container.Name)
+	}
+}
+
 func prepareController(clientSet kubernetes.Interface) *Controller {
 	return &Controller{
 		Clientset: clientSet,
 		Entry:    logControllerEntry,
 	}
 }
+
+func Test_createHealthMonitorControllerContainer(t *testing.T) {
+	container := createHealthMonitorControllerContainer(testControllerDeployment.DeepCopy(), &components.Sidecar{
+		Image: &components.Image{Name: "health-monitor"},
+		Args:  &components.Args{MonitorInterval: "1m", EnableNodeWatcher: true},
+	})
+
+	assert.Equal(t, constant.HealthMonitorControllerName, container.Name)
+	assert.Contains(t, container.Args, "--monitor-interval=1m")
+	assert.Contains(t, container.Args, "--enable-node-watcher=true")
+	assert.Contains(t, container.Args, "--leader-election")
+}
@@ -0,0 +1,94 @@
+package controlplane
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+)
+
+// controlplane package discovers control-plane nodes. kubeadm sets master role label
+// before Kubernetes 1.24 and only control-plane one after, so both of them are recognized
+// together with user defined selector for clusters with custom node roles
+
+const (
+	// MasterLabelKey is the legacy control-plane node role label and taint
+	MasterLabelKey = "node-role.kubernetes.io/master"
+	// ControlPlaneLabelKey is the control-plane node role label and taint
+	ControlPlaneLabelKey = "node-role.kubernetes.io/control-plane"
+
+	criticalAddonsOnlyTaintKey = "CriticalAddonsOnly"
+)
+
+// roleLabels are node labels, which mark control-plane nodes
+var roleLabels = []string{MasterLabelKey, ControlPlaneLabelKey}
+
+// Tolerations returns tolerations for control-plane nodes taints
+func Tolerations() []corev1.Toleration {
+	return []corev1.Toleration{
+		{Key: criticalAddonsOnlyTaintKey, Operator: corev1.TolerationOpExists},
+		{Key: MasterLabelKey, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+		{Key: ControlPlaneLabelKey, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	}
+}
+
+// Affinity returns node affinity to schedule pods on control-plane nodes only
+// selector is optional user defined control-plane node selector
+func Affinity(selector *components.NodeSelector) *corev1.Affinity {
+	// node selector terms are ORed
+	terms := make([]corev1.NodeSelectorTerm, 0, len(roleLabels)+1)
+	for _, label := range roleLabels {
+		terms = append(terms, corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+			{Key: label, Operator: corev1.NodeSelectorOpExists},
+		}})
+	}
+	if isSelectorSet(selector) {
+		terms = append(terms, corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+			{Key: selector.Key, Operator: corev1.NodeSelectorOpIn, Values: []string{selector.Value}},
+		}})
+	}
+
+	return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+	}}
+}
+
+// IsControlPlane checks that node has one of control-plane role labels or matches user defined selector
+func IsControlPlane(node *corev1.Node, selector *components.NodeSelector) bool {
+	nodeLabels := node.GetLabels()
+	for _, label := range roleLabels {
+		if _, ok := nodeLabels[label]; ok {
+			return true
+		}
+	}
+
+	if isSelectorSet(selector) {
+		if value, ok := nodeLabels[selector.Key]; ok && value == selector.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// ListNodes returns control-plane nodes of the cluster
+func ListNodes(ctx context.Context, clientset kubernetes.Interface, selector *components.NodeSelector) ([]corev1.Node, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var controlPlaneNodes []corev1.Node
+	for i := range nodes.Items {
+		if IsControlPlane(&nodes.Items[i], selector) {
+			controlPlaneNodes = append(controlPlaneNodes, nodes.Items[i])
+		}
+	}
+	return controlPlaneNodes, nil
+}
+
+func isSelectorSet(selector *components.NodeSelector) bool {
+	return selector != nil && selector.Key != ""
+}
@@ -0,0 +1,90 @@
+package controlplane
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+)
+
+var (
+	masterNode = &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:   "master",
+		Labels: map[string]string{MasterLabelKey: ""},
+	}}
+	controlPlaneNode = &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:   "control-plane",
+		Labels: map[string]string{ControlPlaneLabelKey: ""},
+	}}
+	customNode = &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:   "custom",
+		Labels: map[string]string{"example.com/role": "scheduler"},
+	}}
+	workerNode = &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:   "worker",
+		Labels: map[string]string{"node-role.kubernetes.io/worker": ""},
+	}}
+
+	customSelector = &components.NodeSelector{Key: "example.com/role", Value: "scheduler"}
+)
+
+func Test_IsControlPlane(t *testing.T) {
+	assert.True(t, IsControlPlane(masterNode, nil))
+	assert.True(t, IsControlPlane(controlPlaneNode, nil))
+	assert.False(t, IsControlPlane(customNode, nil))
+	assert.True(t, IsControlPlane(customNode, customSelector))
+	assert.False(t, IsControlPlane(workerNode, customSelector))
+	assert.False(t, IsControlPlane(workerNode, &components.NodeSelector{}))
+}
+
+func Test_ListNodes(t *testing.T) {
+	clientSet := fake.NewSimpleClientset(masterNode, controlPlaneNode, customNode, workerNode)
+
+	nodes, err := ListNodes(context.Background(), clientSet, nil)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"master", "control-plane"}, nodeNames(nodes))
+
+	nodes, err = ListNodes(context.Background(), clientSet, customSelector)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"master", "control-plane", "custom"}, nodeNames(nodes))
+}
+
+func Test_Affinity(t *testing.T) {
+	terms := Affinity(nil).NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	assert.Len(t, terms, 2)
+	assert.Equal(t, MasterLabelKey, terms[0].MatchExpressions[0].Key)
+	assert.Equal(t, ControlPlaneLabelKey, terms[1].MatchExpressions[0].Key)
+
+	terms = Affinity(customSelector).NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	assert.Len(t, terms, 3)
+	assert.Equal(t, corev1.NodeSelectorRequirement{Key: "example.com/role", Operator: corev1.NodeSelectorOpIn,
+		Values: []string{"scheduler"}}, terms[2].MatchExpressions[0])
+}
+
+func Test_Tolerations(t *testing.T) {
+	for _, taint := range []corev1.Taint{
+		{Key: MasterLabelKey, Effect: corev1.TaintEffectNoSchedule},
+		{Key: ControlPlaneLabelKey, Effect: corev1.TaintEffectNoSchedule},
+	} {
+		tolerated := false
+		for _, toleration := range Tolerations() {
+			if toleration.ToleratesTaint(&taint) {
+				tolerated = true
+			}
+		}
+		assert.True(t, tolerated, taint.Key)
+	}
+}
+
+func nodeNames(nodes []corev1.Node) []string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	return names
+}
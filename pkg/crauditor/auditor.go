@@ -0,0 +1,172 @@
+package crauditor
+
+import (
+	"context"
+	"time"
+
+	acrcrd "github.com/dell/csi-baremetal/api/v1/acreservationcrd"
+	accrd "github.com/dell/csi-baremetal/api/v1/availablecapacitycrd"
+	"github.com/dell/csi-baremetal/api/v1/drivecrd"
+	"github.com/dell/csi-baremetal/api/v1/lvgcrd"
+	"github.com/dell/csi-baremetal/api/v1/nodecrd"
+	"github.com/dell/csi-baremetal/api/v1/volumecrd"
+	"github.com/dell/csi-baremetal/pkg/eventing"
+	"github.com/dell/csi-baremetal/pkg/events"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/pkg/crconsistency"
+)
+
+const (
+	ctxTimeout   = 30 * time.Second
+	auditTimeout = 5 * time.Minute
+
+	// InconsistencyDetectedReason is set for Event about new inconsistency of csi-baremetal CRs
+	InconsistencyDetectedReason = "CRInconsistencyDetected"
+	// InconsistencyRepairedReason is set for Event about inconsistency fixed by auditor
+	InconsistencyRepairedReason = "CRInconsistencyRepaired"
+)
+
+// crauditor package implements a watcher, which periodically checks
+// invariants between csi-baremetal CRs, Kubernetes nodes and PVs.
+// csi-baremetal components update related CRs one by one, so crashes
+// between updates leave CRs inconsistent, which breaks scheduling and provisioning
+
+// Auditor is the watcher to check consistency of csi-baremetal CRs
+type Auditor struct {
+	Client        client.Client
+	EventRecorder events.EventRecorder
+	Log           *logrus.Entry
+
+	// reported contains violations found by the previous audit
+	reported map[string]bool
+}
+
+// LaunchCRAuditing creates an instance of Auditor and
+// start the infinite loop to audit csi-baremetal CRs by timeout
+func LaunchCRAuditing(client client.Client, eventRecorder events.EventRecorder, log *logrus.Entry) {
+	auditor := &Auditor{
+		Client:        client,
+		EventRecorder: eventRecorder,
+		Log:           log,
+	}
+
+	go func() {
+		for {
+			time.Sleep(auditTimeout)
+			auditor.audit()
+		}
+	}()
+}
+
+func (a *Auditor) audit() {
+	ctx, cancelFn := context.WithTimeout(context.Background(), ctxTimeout)
+	defer cancelFn()
+
+	deployments := &csibaremetalv1.DeploymentList{}
+	if err := a.Client.List(ctx, deployments); err != nil {
+		a.Log.Errorf("failed to get csi Deployment List: %s", err.Error())
+		return
+	}
+	// CRs are not managed without csi Deployment
+	if len(deployments.Items) == 0 {
+		return
+	}
+
+	state, err := a.readState(ctx)
+	if err != nil {
+		auditErrors.Inc()
+		a.Log.Errorf("failed to read csi-baremetal CRs: %s", err.Error())
+		return
+	}
+
+	autoRepair := false
+	for i := range deployments.Items {
+		if deployments.Items[i].GetAnnotations()[csibaremetalv1.AuditAutoRepairAnnotation] == "true" {
+			autoRepair = true
+		}
+	}
+
+	counts := map[string]float64{}
+	for _, check := range crconsistency.Checks {
+		counts[check] = 0
+	}
+	current := map[string]bool{}
+	for _, v := range crconsistency.FindViolations(state) {
+		// violation is repaired only if it persists since the previous audit,
+		// csi-baremetal updates related CRs one by one, so short-lived violations are expected
+		if autoRepair && isRepairable(v) && a.reported[v.Message] {
+			if err = a.repair(ctx, v); err != nil {
+				a.Log.Errorf("failed to repair %s: %s", v.Message, err.Error())
+			} else {
+				repairs.WithLabelValues(v.Check).Inc()
+				a.Log.Infof("Repaired: %s", v.Message)
+				a.recordEvent(deployments, eventing.NormalType, InconsistencyRepairedReason, v.Message)
+				continue
+			}
+		}
+
+		counts[v.Check]++
+		current[v.Message] = true
+		if !a.reported[v.Message] {
+			a.Log.Warnf("Inconsistency of csi-baremetal CRs: %s", v.Message)
+			a.recordEvent(deployments, eventing.WarningType, InconsistencyDetectedReason, v.Message)
+		}
+	}
+
+	for check, count := range counts {
+		violations.WithLabelValues(check).Set(count)
+	}
+	a.reported = current
+}
+
+// recordEvent sends Event to all csi Deployments
+func (a *Auditor) recordEvent(deployments *csibaremetalv1.DeploymentList, eventType, reason, message string) {
+	for i := range deployments.Items {
+		a.EventRecorder.Eventf(&deployments.Items[i], eventType, reason, "%s", message)
+	}
+}
+
+// isRepairable checks if violation can be fixed safely. Only ACs on missing locations are deleted,
+// other violations are reported only
+func isRepairable(v crconsistency.Violation) bool {
+	return v.Check == crconsistency.ACLocationCheck && v.Object != nil
+}
+
+// repair deletes inconsistent object of violation
+func (a *Auditor) repair(ctx context.Context, v crconsistency.Violation) error {
+	return client.IgnoreNotFound(a.Client.Delete(ctx, v.Object))
+}
+
+func (a *Auditor) readState(ctx context.Context) (*crconsistency.State, error) {
+	var (
+		nodes      = &corev1.NodeList{}
+		pvs        = &corev1.PersistentVolumeList{}
+		csibmnodes = &nodecrd.NodeList{}
+		drives     = &drivecrd.DriveList{}
+		acs        = &accrd.AvailableCapacityList{}
+		acrs       = &acrcrd.AvailableCapacityReservationList{}
+		lvgs       = &lvgcrd.LogicalVolumeGroupList{}
+		volumes    = &volumecrd.VolumeList{}
+	)
+
+	for _, list := range []client.ObjectList{nodes, pvs, csibmnodes, drives, acs, acrs, lvgs, volumes} {
+		if err := a.Client.List(ctx, list); err != nil {
+			return nil, err
+		}
+	}
+
+	return &crconsistency.State{
+		Nodes:      nodes.Items,
+		PVs:        pvs.Items,
+		CSIBMNodes: csibmnodes.Items,
+		Drives:     drives.Items,
+		ACs:        acs.Items,
+		ACRs:       acrs.Items,
+		LVGs:       lvgs.Items,
+		Volumes:    volumes.Items,
+	}, nil
+}
@@ -0,0 +1,138 @@
+package crauditor
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/dell/csi-baremetal/api/generated/v1"
+	accrd "github.com/dell/csi-baremetal/api/v1/availablecapacitycrd"
+	"github.com/dell/csi-baremetal/api/v1/drivecrd"
+	"github.com/dell/csi-baremetal/api/v1/nodecrd"
+	"github.com/dell/csi-baremetal/api/v1/volumecrd"
+	"github.com/dell/csi-baremetal/pkg/eventing"
+	"github.com/dell/csi-baremetal/pkg/events/mocks"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/crconsistency"
+)
+
+const (
+	driveSize = int64(1024 * 1024 * 1024)
+)
+
+var (
+	ctx = context.Background()
+)
+
+// newInconsistentState returns node with Drive AC exceeding free space and AC on missing Drive
+func newInconsistentState() *crconsistency.State {
+	return &crconsistency.State{
+		Nodes: []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}},
+		CSIBMNodes: []nodecrd.Node{{ObjectMeta: metav1.ObjectMeta{Name: "csibmnode-1"}, Spec: api.Node{UUID: "uuid-1",
+			Addresses: map[string]string{string(corev1.NodeHostName): "node-1"}}}},
+		Drives: []drivecrd.Drive{{ObjectMeta: metav1.ObjectMeta{Name: "drive-1"},
+			Spec: api.Drive{UUID: "drive-1", NodeId: "uuid-1", Size: driveSize}}},
+		ACs: []accrd.AvailableCapacity{newAC("ac-1", "drive-1"), newAC("ac-2", "drive-2")},
+		Volumes: []volumecrd.Volume{{ObjectMeta: metav1.ObjectMeta{Name: "pvc-1", Namespace: "app"},
+			Spec: api.Volume{Id: "pvc-1", NodeId: "uuid-1", Location: "drive-1", Size: driveSize}}},
+	}
+}
+
+func newAC(name, location string) accrd.AvailableCapacity {
+	return accrd.AvailableCapacity{ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: api.AvailableCapacity{Location: location, NodeId: "uuid-1", Size: driveSize}}
+}
+
+func setupAuditor(t *testing.T, state *crconsistency.State, csi *csibaremetalv1.Deployment) (*Auditor, *mocks.EventRecorder) {
+	scheme, err := common.PrepareScheme()
+	assert.Nil(t, err)
+
+	objects := []client.Object{csi}
+	for i := range state.Nodes {
+		objects = append(objects, &state.Nodes[i])
+	}
+	for i := range state.CSIBMNodes {
+		objects = append(objects, &state.CSIBMNodes[i])
+	}
+	for i := range state.Drives {
+		objects = append(objects, &state.Drives[i])
+	}
+	for i := range state.ACs {
+		objects = append(objects, &state.ACs[i])
+	}
+	for i := range state.Volumes {
+		objects = append(objects, &state.Volumes[i])
+	}
+
+	eventRecorder := new(mocks.EventRecorder)
+	eventRecorder.On("Eventf", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	return &Auditor{
+		Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		EventRecorder: eventRecorder,
+		Log:           logrus.WithField("Test name", "CRAuditorTest"),
+	}, eventRecorder
+}
+
+func Test_audit(t *testing.T) {
+	newCSI := func() *csibaremetalv1.Deployment {
+		return &csibaremetalv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "csi", Namespace: "default"}}
+	}
+
+	t.Run("Events are sent for new violations only", func(t *testing.T) {
+		auditor, eventRecorder := setupAuditor(t, newInconsistentState(), newCSI())
+		auditor.audit()
+		eventRecorder.AssertNumberOfCalls(t, "Eventf", 2)
+		eventRecorder.AssertCalled(t, "Eventf", mock.Anything, eventing.WarningType, InconsistencyDetectedReason, "%s",
+			"AvailableCapacity ac-2 refers to missing Drive or LogicalVolumeGroup drive-2")
+
+		auditor.audit()
+		eventRecorder.AssertNumberOfCalls(t, "Eventf", 2)
+		assert.Len(t, auditor.reported, 2)
+
+		// nothing is repaired without annotation
+		ac := &accrd.AvailableCapacity{}
+		assert.Nil(t, auditor.Client.Get(ctx, client.ObjectKey{Name: "ac-2"}, ac))
+	})
+
+	t.Run("Persistent violations are repaired", func(t *testing.T) {
+		csi := newCSI()
+		csi.Annotations = map[string]string{csibaremetalv1.AuditAutoRepairAnnotation: "true"}
+		auditor, eventRecorder := setupAuditor(t, newInconsistentState(), csi)
+
+		// violations are reported by the first audit
+		auditor.audit()
+		eventRecorder.AssertNumberOfCalls(t, "Eventf", 2)
+		ac := &accrd.AvailableCapacity{}
+		assert.Nil(t, auditor.Client.Get(ctx, client.ObjectKey{Name: "ac-2"}, ac))
+
+		// and repaired by the next one, AC exceeding free space is reported only
+		auditor.audit()
+		eventRecorder.AssertNumberOfCalls(t, "Eventf", 3)
+		eventRecorder.AssertCalled(t, "Eventf", mock.Anything, eventing.NormalType, InconsistencyRepairedReason, "%s",
+			"AvailableCapacity ac-2 refers to missing Drive or LogicalVolumeGroup drive-2")
+		assert.Equal(t, map[string]bool{"AvailableCapacity ac-1 has 1073741824 bytes, but drive-1 has 0 free bytes": true},
+			auditor.reported)
+
+		err := auditor.Client.Get(ctx, client.ObjectKey{Name: "ac-2"}, ac)
+		assert.True(t, k8serrors.IsNotFound(err))
+		assert.Nil(t, auditor.Client.Get(ctx, client.ObjectKey{Name: "ac-1"}, ac))
+		assert.Equal(t, driveSize, ac.Spec.Size)
+	})
+
+	t.Run("Nothing is audited without csi Deployment", func(t *testing.T) {
+		auditor, eventRecorder := setupAuditor(t, newInconsistentState(), newCSI())
+		assert.Nil(t, auditor.Client.Delete(ctx, newCSI()))
+		auditor.audit()
+		eventRecorder.AssertNotCalled(t, "Eventf")
+		assert.Empty(t, auditor.reported)
+	})
+}
@@ -0,0 +1,42 @@
+package crauditor
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	metricsSubsystem = "cr_auditor"
+)
+
+var (
+	// violations tracks number of inconsistencies found by the last audit
+	violations = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: constant.MetricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "violations",
+		Help:      "Number of csi-baremetal CR inconsistencies found by the last audit",
+	}, []string{"check"})
+
+	// repairs counts inconsistencies fixed by auditor
+	repairs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: constant.MetricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "repairs_total",
+		Help:      "Number of csi-baremetal CR inconsistencies repaired by auditor",
+	}, []string{"check"})
+
+	// auditErrors counts audits failed to read cluster state
+	auditErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: constant.MetricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "errors_total",
+		Help:      "Number of audits failed to read csi-baremetal CRs",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(violations, repairs, auditErrors)
+}
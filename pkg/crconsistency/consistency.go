@@ -0,0 +1,371 @@
+package crconsistency
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apiV1 "github.com/dell/csi-baremetal/api/v1"
+	acrcrd "github.com/dell/csi-baremetal/api/v1/acreservationcrd"
+	accrd "github.com/dell/csi-baremetal/api/v1/availablecapacitycrd"
+	"github.com/dell/csi-baremetal/api/v1/drivecrd"
+	"github.com/dell/csi-baremetal/api/v1/lvgcrd"
+	"github.com/dell/csi-baremetal/api/v1/nodecrd"
+	"github.com/dell/csi-baremetal/api/v1/volumecrd"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/dell/csi-baremetal-operator/pkg/acrvalidator"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+// crconsistency package contains checks of invariants between csi-baremetal CRs, Kubernetes nodes, pods and PVs,
+// which are shared by support bundle and CR auditor
+
+const (
+	// NodeMappingCheck finds csibmnodes, which are not mapped to exactly one Kubernetes node
+	NodeMappingCheck = "node_mapping"
+	// NodeReferenceCheck finds Drives, LVGs, ACs and Volumes on missing csibmnodes
+	NodeReferenceCheck = "node_reference"
+	// LVGLocationCheck finds LVGs on missing Drives
+	LVGLocationCheck = "lvg_location"
+	// ACLocationCheck finds ACs on missing Drives or LVGs
+	ACLocationCheck = "ac_location"
+	// ACCapacityCheck finds ACs exceeding free space of their locations
+	ACCapacityCheck = "ac_capacity"
+	// ACRConflictCheck finds Drive ACs reserved by several ACRs
+	ACRConflictCheck = "acr_conflict"
+	// ACRPodCheck finds ACRs of missing pods
+	ACRPodCheck = "acr_pod"
+	// VolumeLocationCheck finds Volumes on missing Drives or LVGs
+	VolumeLocationCheck = "volume_location"
+	// PVVolumeCheck finds csi-baremetal PVs without Volume
+	PVVolumeCheck = "pv_volume"
+)
+
+// Checks contains names of all checks
+var Checks = []string{
+	NodeMappingCheck, NodeReferenceCheck, LVGLocationCheck, ACLocationCheck, ACCapacityCheck,
+	ACRConflictCheck, ACRPodCheck, VolumeLocationCheck, PVVolumeCheck,
+}
+
+// State contains objects, which are checked for inconsistencies
+type State struct {
+	Nodes      []corev1.Node
+	PVs        []corev1.PersistentVolume
+	CSIBMNodes []nodecrd.Node
+	Drives     []drivecrd.Drive
+	ACs        []accrd.AvailableCapacity
+	ACRs       []acrcrd.AvailableCapacityReservation
+	LVGs       []lvgcrd.LogicalVolumeGroup
+	Volumes    []volumecrd.Volume
+	// MissingPods contains <namespace>/<name> of pods, which ACRs are created for, but which are not found.
+	// ACR pods are not checked if it's nil
+	MissingPods map[string]bool
+}
+
+// Violation is an inconsistency found by one of checks
+type Violation struct {
+	Check   string
+	Message string
+	// Object is the inconsistent object
+	Object client.Object
+}
+
+// FindViolations checks invariants of cluster state, violations are sorted by messages
+func FindViolations(state *State) []Violation {
+	var result []Violation
+	result = append(result, checkNodeMapping(state)...)
+	result = append(result, checkNodeReferences(state)...)
+	result = append(result, checkLVGLocations(state)...)
+	result = append(result, checkACs(state)...)
+	result = append(result, checkACRConflicts(state)...)
+	result = append(result, checkACRPods(state)...)
+	result = append(result, checkVolumeLocations(state)...)
+	result = append(result, checkPVVolumes(state)...)
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Message < result[j].Message
+	})
+	return result
+}
+
+// checkNodeMapping checks that every csibmnode maps to exactly one Kubernetes node and vice versa
+func checkNodeMapping(state *State) []Violation {
+	nodes := map[string]bool{}
+	for _, node := range state.Nodes {
+		nodes[node.Name] = true
+	}
+
+	var (
+		result    []Violation
+		hostnames = map[string][]string{}
+	)
+	for i := range state.CSIBMNodes {
+		bmnode := &state.CSIBMNodes[i]
+		hostname := bmnode.Spec.Addresses[string(corev1.NodeHostName)]
+		if !nodes[hostname] {
+			result = append(result, Violation{
+				Check:   NodeMappingCheck,
+				Message: fmt.Sprintf("csibmnode %s refers to missing Kubernetes node %q", bmnode.Name, hostname),
+				Object:  bmnode,
+			})
+			continue
+		}
+		hostnames[hostname] = append(hostnames[hostname], bmnode.Name)
+	}
+
+	for hostname, bmnodes := range hostnames {
+		if len(bmnodes) > 1 {
+			sort.Strings(bmnodes)
+			result = append(result, Violation{
+				Check: NodeMappingCheck,
+				Message: fmt.Sprintf("Kubernetes node %s is referred by several csibmnodes: %s",
+					hostname, strings.Join(bmnodes, ", ")),
+			})
+		}
+	}
+	return result
+}
+
+// checkNodeReferences checks that Drives, LVGs, ACs and Volumes are placed on existing csibmnodes
+func checkNodeReferences(state *State) []Violation {
+	bmnodes := map[string]bool{}
+	for _, bmnode := range state.CSIBMNodes {
+		bmnodes[bmnode.Spec.UUID] = true
+	}
+
+	var result []Violation
+	check := func(obj client.Object, kind, nodeID string) {
+		if !bmnodes[nodeID] {
+			result = append(result, Violation{
+				Check:   NodeReferenceCheck,
+				Message: fmt.Sprintf("%s %s is on missing csibmnode %s", kind, getObjectName(obj), nodeID),
+				Object:  obj,
+			})
+		}
+	}
+	for i := range state.Drives {
+		check(&state.Drives[i], "Drive", state.Drives[i].Spec.NodeId)
+	}
+	for i := range state.LVGs {
+		check(&state.LVGs[i], "LogicalVolumeGroup", state.LVGs[i].Spec.Node)
+	}
+	for i := range state.ACs {
+		check(&state.ACs[i], "AvailableCapacity", state.ACs[i].Spec.NodeId)
+	}
+	for i := range state.Volumes {
+		check(&state.Volumes[i], "Volume", state.Volumes[i].Spec.NodeId)
+	}
+	return result
+}
+
+// checkLVGLocations checks that every LVG is placed on existing Drives
+func checkLVGLocations(state *State) []Violation {
+	drives := getDrives(state)
+
+	var result []Violation
+	for i := range state.LVGs {
+		lvg := &state.LVGs[i]
+		for _, location := range lvg.Spec.Locations {
+			if drives[location] == nil {
+				result = append(result, Violation{
+					Check:   LVGLocationCheck,
+					Message: fmt.Sprintf("LogicalVolumeGroup %s uses missing Drive %s", lvg.Name, location),
+					Object:  lvg,
+				})
+			}
+		}
+	}
+	return result
+}
+
+// checkACs checks that every AC is placed on existing Drive or LVG and doesn't exceed its free space.
+// AC can be less than free space because of size alignment and LVM metadata
+func checkACs(state *State) []Violation {
+	var (
+		drives = getDrives(state)
+		lvgs   = getLVGs(state)
+		// used contains sizes of volumes by their locations
+		used = map[string]int64{}
+		// withVolumes contains Drives and LVGs with volumes
+		withVolumes = map[string]bool{}
+	)
+	for _, volume := range state.Volumes {
+		used[volume.Spec.Location] += volume.Spec.Size
+		withVolumes[volume.Spec.Location] = true
+	}
+	// Drive in LVG is used by LVG AC
+	for _, lvg := range state.LVGs {
+		for _, location := range lvg.Spec.Locations {
+			withVolumes[location] = true
+		}
+	}
+
+	var result []Violation
+	for i := range state.ACs {
+		ac := &state.ACs[i]
+
+		var free int64
+		switch location := ac.Spec.Location; {
+		case drives[location] != nil:
+			// volume on Drive takes it fully
+			if !withVolumes[location] {
+				free = drives[location].Spec.Size
+			}
+		case lvgs[location] != nil:
+			free = lvgs[location].Spec.Size - used[location]
+			if free < 0 {
+				free = 0
+			}
+		default:
+			result = append(result, Violation{
+				Check:   ACLocationCheck,
+				Message: fmt.Sprintf("AvailableCapacity %s refers to missing Drive or LogicalVolumeGroup %s", ac.Name, location),
+				Object:  ac,
+			})
+			continue
+		}
+
+		if ac.Spec.Size > free {
+			result = append(result, Violation{
+				Check: ACCapacityCheck,
+				Message: fmt.Sprintf("AvailableCapacity %s has %d bytes, but %s has %d free bytes",
+					ac.Name, ac.Spec.Size, ac.Spec.Location, free),
+				Object: ac,
+			})
+		}
+	}
+	return result
+}
+
+// checkACRConflicts checks that Drive AC is reserved by one ACR only. LVG AC can be shared by several volumes
+func checkACRConflicts(state *State) []Violation {
+	drives := getDrives(state)
+	driveACs := map[string]bool{}
+	for _, ac := range state.ACs {
+		if drives[ac.Spec.Location] != nil {
+			driveACs[ac.Name] = true
+		}
+	}
+
+	reservedBy := map[string][]string{}
+	for _, acr := range state.ACRs {
+		if acr.Spec.Status != apiV1.ReservationConfirmed {
+			continue
+		}
+		for _, request := range acr.Spec.ReservationRequests {
+			if request == nil {
+				continue
+			}
+			for _, ac := range request.Reservations {
+				if driveACs[ac] {
+					reservedBy[ac] = append(reservedBy[ac], acr.Name)
+				}
+			}
+		}
+	}
+
+	var result []Violation
+	for ac, acrs := range reservedBy {
+		if len(acrs) > 1 {
+			sort.Strings(acrs)
+			result = append(result, Violation{
+				Check: ACRConflictCheck,
+				Message: fmt.Sprintf("AvailableCapacity %s is reserved by several AvailableCapacityReservations: %s",
+					ac, strings.Join(acrs, ", ")),
+			})
+		}
+	}
+	return result
+}
+
+// checkACRPods checks that pods of ACRs exist, it's skipped if missing pods are not set in state
+func checkACRPods(state *State) []Violation {
+	if state.MissingPods == nil {
+		return nil
+	}
+
+	var result []Violation
+	for i := range state.ACRs {
+		acr := &state.ACRs[i]
+		namespace, name := acrvalidator.GetPodName(acr)
+		if state.MissingPods[namespace+"/"+name] {
+			result = append(result, Violation{
+				Check:   ACRPodCheck,
+				Message: fmt.Sprintf("AvailableCapacityReservation %s has no pod %s/%s", acr.Name, namespace, name),
+				Object:  acr,
+			})
+		}
+	}
+	return result
+}
+
+// checkVolumeLocations checks that every Volume is placed on existing Drive or LVG.
+// Location of Volume is empty until it's created
+func checkVolumeLocations(state *State) []Violation {
+	drives, lvgs := getDrives(state), getLVGs(state)
+
+	var result []Violation
+	for i := range state.Volumes {
+		volume := &state.Volumes[i]
+		location := volume.Spec.Location
+		if location != "" && drives[location] == nil && lvgs[location] == nil {
+			result = append(result, Violation{
+				Check: VolumeLocationCheck,
+				Message: fmt.Sprintf("Volume %s/%s refers to missing Drive or LogicalVolumeGroup %s",
+					volume.Namespace, volume.Name, location),
+				Object: volume,
+			})
+		}
+	}
+	return result
+}
+
+// checkPVVolumes checks that every csi-baremetal PV has Volume CR
+func checkPVVolumes(state *State) []Violation {
+	volumes := map[string]bool{}
+	for _, volume := range state.Volumes {
+		volumes[volume.Name] = true
+	}
+
+	var result []Violation
+	for i := range state.PVs {
+		pv := &state.PVs[i]
+		if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != constant.CSIName || !pv.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		if !volumes[pv.Spec.CSI.VolumeHandle] {
+			result = append(result, Violation{
+				Check:   PVVolumeCheck,
+				Message: fmt.Sprintf("PersistentVolume %s has no Volume %s", pv.Name, pv.Spec.CSI.VolumeHandle),
+				Object:  pv,
+			})
+		}
+	}
+	return result
+}
+
+// getObjectName returns <namespace>/<name> of namespaced object and <name> of cluster-scoped one
+func getObjectName(obj client.Object) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+func getDrives(state *State) map[string]*drivecrd.Drive {
+	drives := map[string]*drivecrd.Drive{}
+	for i := range state.Drives {
+		drives[state.Drives[i].Spec.UUID] = &state.Drives[i]
+	}
+	return drives
+}
+
+func getLVGs(state *State) map[string]*lvgcrd.LogicalVolumeGroup {
+	lvgs := map[string]*lvgcrd.LogicalVolumeGroup{}
+	for i := range state.LVGs {
+		lvgs[state.LVGs[i].Name] = &state.LVGs[i]
+	}
+	return lvgs
+}
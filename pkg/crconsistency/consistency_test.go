@@ -0,0 +1,182 @@
+package crconsistency
+
+import (
+	"testing"
+
+	api "github.com/dell/csi-baremetal/api/generated/v1"
+	apiV1 "github.com/dell/csi-baremetal/api/v1"
+	acrcrd "github.com/dell/csi-baremetal/api/v1/acreservationcrd"
+	accrd "github.com/dell/csi-baremetal/api/v1/availablecapacitycrd"
+	"github.com/dell/csi-baremetal/api/v1/drivecrd"
+	"github.com/dell/csi-baremetal/api/v1/lvgcrd"
+	"github.com/dell/csi-baremetal/api/v1/nodecrd"
+	"github.com/dell/csi-baremetal/api/v1/volumecrd"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	driveSize = int64(1024 * 1024 * 1024)
+)
+
+// newConsistentState returns node with one Drive used by Volume and LVG on another Drive with one Volume
+func newConsistentState() *State {
+	return &State{
+		Nodes: []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}},
+		PVs: []corev1.PersistentVolume{
+			newPV("pvc-1"),
+			{ObjectMeta: metav1.ObjectMeta{Name: "other"},
+				Spec: corev1.PersistentVolumeSpec{PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{Driver: "other", VolumeHandle: "other"}}}},
+		},
+		CSIBMNodes: []nodecrd.Node{newCSIBMNode("csibmnode-1", "uuid-1", "node-1")},
+		Drives:     []drivecrd.Drive{newDrive("drive-1"), newDrive("drive-2")},
+		LVGs: []lvgcrd.LogicalVolumeGroup{{ObjectMeta: metav1.ObjectMeta{Name: "lvg-1"},
+			Spec: api.LogicalVolumeGroup{Node: "uuid-1", Locations: []string{"drive-2"}, Size: driveSize}}},
+		ACs: []accrd.AvailableCapacity{
+			newAC("ac-1", "drive-1", 0),
+			newAC("ac-2", "lvg-1", driveSize/2),
+		},
+		ACRs: []acrcrd.AvailableCapacityReservation{
+			newACR("app-pod-1", "ac-2"),
+			newACR("app-pod-2", "ac-2"),
+		},
+		Volumes: []volumecrd.Volume{
+			newVolume("pvc-1", "drive-1", driveSize),
+			newVolume("pvc-2", "lvg-1", driveSize/2),
+		},
+	}
+}
+
+func newPV(name string) corev1.PersistentVolume {
+	return corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeSpec{PersistentVolumeSource: corev1.PersistentVolumeSource{
+			CSI: &corev1.CSIPersistentVolumeSource{Driver: constant.CSIName, VolumeHandle: name}}},
+	}
+}
+
+func newCSIBMNode(name, uuid, hostname string) nodecrd.Node {
+	return nodecrd.Node{ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: api.Node{UUID: uuid, Addresses: map[string]string{string(corev1.NodeHostName): hostname}}}
+}
+
+func newDrive(uuid string) drivecrd.Drive {
+	return drivecrd.Drive{ObjectMeta: metav1.ObjectMeta{Name: uuid},
+		Spec: api.Drive{UUID: uuid, NodeId: "uuid-1", Size: driveSize}}
+}
+
+func newAC(name, location string, size int64) accrd.AvailableCapacity {
+	return accrd.AvailableCapacity{ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: api.AvailableCapacity{Location: location, NodeId: "uuid-1", Size: size}}
+}
+
+func newACR(name string, acs ...string) acrcrd.AvailableCapacityReservation {
+	return acrcrd.AvailableCapacityReservation{ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: api.AvailableCapacityReservation{Namespace: "app", Status: apiV1.ReservationConfirmed,
+			ReservationRequests: []*api.ReservationRequest{{Reservations: acs}}}}
+}
+
+func newVolume(name, location string, size int64) volumecrd.Volume {
+	return volumecrd.Volume{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "app"},
+		Spec: api.Volume{Id: name, NodeId: "uuid-1", Location: location, Size: size}}
+}
+
+func getMessages(result []Violation) []string {
+	var messages []string
+	for _, v := range result {
+		messages = append(messages, v.Message)
+	}
+	return messages
+}
+
+func Test_FindViolations(t *testing.T) {
+	t.Run("Consistent state", func(t *testing.T) {
+		assert.Empty(t, FindViolations(newConsistentState()))
+	})
+
+	t.Run("Volume on missing location", func(t *testing.T) {
+		state := newConsistentState()
+		state.Volumes = append(state.Volumes, newVolume("pvc-3", "drive-3", driveSize))
+		state.PVs = append(state.PVs, newPV("pvc-3"))
+		assert.Equal(t, []string{"Volume app/pvc-3 refers to missing Drive or LogicalVolumeGroup drive-3"},
+			getMessages(FindViolations(state)))
+	})
+
+	t.Run("AC on missing location or exceeding free space", func(t *testing.T) {
+		state := newConsistentState()
+		state.ACs[0].Spec.Size = driveSize
+		state.ACs[1].Spec.Size = driveSize
+		state.ACs = append(state.ACs, newAC("ac-3", "drive-3", driveSize))
+		assert.Equal(t, []string{
+			"AvailableCapacity ac-1 has 1073741824 bytes, but drive-1 has 0 free bytes",
+			"AvailableCapacity ac-2 has 1073741824 bytes, but lvg-1 has 536870912 free bytes",
+			"AvailableCapacity ac-3 refers to missing Drive or LogicalVolumeGroup drive-3",
+		}, getMessages(FindViolations(state)))
+	})
+
+	t.Run("Drive AC reserved twice", func(t *testing.T) {
+		state := newConsistentState()
+		state.Drives = append(state.Drives, newDrive("drive-3"))
+		state.ACs = append(state.ACs, newAC("ac-3", "drive-3", driveSize))
+		state.ACRs = append(state.ACRs, newACR("app-pod-3", "ac-3"), newACR("app-pod-4", "ac-3"))
+		rejected := newACR("app-pod-5", "ac-3")
+		rejected.Spec.Status = apiV1.ReservationRejected
+		state.ACRs = append(state.ACRs, rejected)
+		assert.Equal(t, []string{
+			"AvailableCapacity ac-3 is reserved by several AvailableCapacityReservations: app-pod-3, app-pod-4",
+		}, getMessages(FindViolations(state)))
+	})
+
+	t.Run("csibmnode mapping", func(t *testing.T) {
+		state := newConsistentState()
+		state.CSIBMNodes = append(state.CSIBMNodes,
+			newCSIBMNode("csibmnode-2", "uuid-2", "node-1"),
+			newCSIBMNode("csibmnode-3", "uuid-3", "node-3"))
+		assert.Equal(t, []string{
+			"Kubernetes node node-1 is referred by several csibmnodes: csibmnode-1, csibmnode-2",
+			"csibmnode csibmnode-3 refers to missing Kubernetes node \"node-3\"",
+		}, getMessages(FindViolations(state)))
+	})
+
+	t.Run("Objects on missing csibmnode", func(t *testing.T) {
+		state := newConsistentState()
+		state.Drives[0].Spec.NodeId = "uuid-2"
+		state.Volumes[0].Spec.NodeId = "uuid-2"
+		assert.Equal(t, []string{
+			"Drive drive-1 is on missing csibmnode uuid-2",
+			"Volume app/pvc-1 is on missing csibmnode uuid-2",
+		}, getMessages(FindViolations(state)))
+	})
+
+	t.Run("LVG on missing Drive", func(t *testing.T) {
+		state := newConsistentState()
+		state.LVGs[0].Spec.Locations = append(state.LVGs[0].Spec.Locations, "drive-3")
+		assert.Equal(t, []string{"LogicalVolumeGroup lvg-1 uses missing Drive drive-3"},
+			getMessages(FindViolations(state)))
+	})
+
+	t.Run("Volume without location is being created", func(t *testing.T) {
+		state := newConsistentState()
+		state.Volumes = append(state.Volumes, newVolume("pvc-3", "", 0))
+		assert.Empty(t, FindViolations(state))
+	})
+
+	t.Run("ACR of missing pod", func(t *testing.T) {
+		state := newConsistentState()
+		assert.Empty(t, FindViolations(state))
+
+		state.MissingPods = map[string]bool{"app/pod-2": true}
+		assert.Equal(t, []string{"AvailableCapacityReservation app-pod-2 has no pod app/pod-2"},
+			getMessages(FindViolations(state)))
+	})
+
+	t.Run("PV without Volume", func(t *testing.T) {
+		state := newConsistentState()
+		state.PVs = append(state.PVs, newPV("pvc-3"))
+		assert.Equal(t, []string{"PersistentVolume pvc-3 has no Volume pvc-3"}, getMessages(FindViolations(state)))
+	})
+}
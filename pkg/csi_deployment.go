@@ -7,13 +7,18 @@ import (
 
 	"github.com/sirupsen/logrus"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/compatibility"
 	"github.com/dell/csi-baremetal-operator/pkg/constant"
 	securityverifier "github.com/dell/csi-baremetal-operator/pkg/feature/security_verifier"
+	"github.com/dell/csi-baremetal-operator/pkg/imagedigest"
+	"github.com/dell/csi-baremetal-operator/pkg/logreceiver"
 	"github.com/dell/csi-baremetal-operator/pkg/node"
 	"github.com/dell/csi-baremetal-operator/pkg/nodeoperations"
 	"github.com/dell/csi-baremetal-operator/pkg/patcher"
@@ -24,12 +29,19 @@ import (
 
 // CSIDeployment contains controllers of CSI resources
 type CSIDeployment struct {
+	client                   client.Client
 	node                     *node.Node
 	controller               Controller
 	extender                 SchedulerExtender
 	patcher                  patcher.SchedulerPatcher
 	nodeController           NodeController
 	nodeOperationsController *nodeoperations.Controller
+	logReceiver              logreceiver.LogReceiver
+	monitoring               Monitoring
+	storageClasses           StorageClasses
+	nodeUpgrade              node.Upgrade
+	compatibility            *compatibility.Checker
+	imageDigests             *imagedigest.Resolver
 }
 
 // NewCSIDeployment creates CSIDeployment
@@ -38,8 +50,10 @@ func NewCSIDeployment(clientSet kubernetes.Interface, client client.Client,
 	eventRecorder events.EventRecorder, log *logrus.Logger,
 ) CSIDeployment {
 	return CSIDeployment{
+		client: client,
 		node: node.NewNode(
 			clientSet,
+			client,
 			securityverifier.NewPodSecurityPolicyVerifier(
 				validator.NewValidator(rbac.NewValidator(
 					client,
@@ -60,11 +74,30 @@ func NewCSIDeployment(clientSet kubernetes.Interface, client client.Client,
 				matchSecurityContextConstraintsPolicies,
 				log.WithField(constant.CSIName, "node"),
 			),
+			securityverifier.NewHealthMonitorVerifier(
+				validator.NewValidator(rbac.NewValidator(
+					client,
+					log.WithField(constant.CSIName, "rbacNodeValidator"),
+					matcher),
+				),
+				eventRecorder,
+				log.WithField(constant.CSIName, "node"),
+			),
 			log.WithField(constant.CSIName, "node"),
 		),
 		controller: Controller{
 			Clientset: clientSet,
-			Entry:     log.WithField(constant.CSIName, "controller"),
+			Client:    client,
+			HealthMonitorVerifier: securityverifier.NewHealthMonitorVerifier(
+				validator.NewValidator(rbac.NewValidator(
+					client,
+					log.WithField(constant.CSIName, "rbacControllerValidator"),
+					matcher),
+				),
+				eventRecorder,
+				log.WithField(constant.CSIName, "controller"),
+			),
+			Entry: log.WithField(constant.CSIName, "controller"),
 		},
 		extender: SchedulerExtender{
 			Clientset: clientSet,
@@ -73,7 +106,7 @@ func NewCSIDeployment(clientSet kubernetes.Interface, client client.Client,
 				validator.NewValidator(rbac.NewValidator(
 					client,
 					log.WithField(constant.CSIName, "rbacExtenderValidator"),
-					rbac.NewMatcher()),
+					matcher),
 				),
 				eventRecorder,
 				matchPodSecurityPolicyTemplate,
@@ -83,7 +116,7 @@ func NewCSIDeployment(clientSet kubernetes.Interface, client client.Client,
 				validator.NewValidator(rbac.NewValidator(
 					client,
 					log.WithField(constant.CSIName, "rbacExtenderValidator"),
-					rbac.NewMatcher()),
+					matcher),
 				),
 				eventRecorder,
 				matchSecurityContextConstraintsPolicies,
@@ -98,7 +131,7 @@ func NewCSIDeployment(clientSet kubernetes.Interface, client client.Client,
 				validator.NewValidator(rbac.NewValidator(
 					client,
 					log.WithField(constant.CSIName, "rbacPatcherValidator"),
-					rbac.NewMatcher()),
+					matcher),
 				),
 				eventRecorder,
 				matchPodSecurityPolicyTemplate,
@@ -114,36 +147,176 @@ func NewCSIDeployment(clientSet kubernetes.Interface, client client.Client,
 			client,
 			log.WithField(constant.CSIName, "nodeRemovalController"),
 		),
+		logReceiver: logreceiver.LogReceiver{
+			Clientset: clientSet,
+			Entry:     log.WithField(constant.CSIName, "logReceiver"),
+		},
+		monitoring: Monitoring{
+			Clientset: clientSet,
+			Client:    client,
+			Entry:     log.WithField(constant.CSIName, "monitoring"),
+		},
+		storageClasses: StorageClasses{
+			Clientset: clientSet,
+			Client:    client,
+			Entry:     log.WithField(constant.CSIName, "storageClasses"),
+		},
+		nodeUpgrade: node.Upgrade{
+			Clientset: clientSet,
+			Client:    client,
+			Entry:     log.WithField(constant.CSIName, "nodeUpgrade"),
+		},
+		compatibility: &compatibility.Checker{
+			Clientset: clientSet,
+			Client:    client,
+			Entry:     log.WithField(constant.CSIName, "compatibility"),
+		},
+		imageDigests: &imagedigest.Resolver{
+			Clientset: clientSet,
+			Client:    client,
+			Entry:     log.WithField(constant.CSIName, "imageDigests"),
+		},
 	}
 }
 
-// Update performs Update functions of contained resources
+// DisableDigestResolution makes CSIDeployment use only image digests, which are already recorded in status
+// of csi Deployment. It's used to render objects without access to registries
+func (c *CSIDeployment) DisableDigestResolution() {
+	c.imageDigests.Offline = true
+}
+
+// Update performs Update functions of contained resources.
+// Changes are only reported in status.plan if csi Deployment has plan-only annotation
 func (c *CSIDeployment) Update(ctx context.Context, csi *csibaremetalv1.Deployment, scheme *runtime.Scheme) error {
-	if err := c.nodeController.Update(ctx, csi, scheme); err != nil {
+	if common.IsPlanOnly(csi) {
+		return c.updatePlan(ctx, csi, scheme)
+	}
+
+	// plan is outdated as soon as changes are applied
+	if csi.Status.Plan != nil {
+		csi.Status.Plan = nil
+		if err := c.client.Status().Update(ctx, csi); err != nil {
+			return err
+		}
+	}
+
+	// changes are not applied if versions are incompatible, Compatible condition describes the reason
+	compatible, err := c.compatibility.Check(ctx, csi)
+	if err != nil || !compatible {
 		return err
 	}
 
-	if err := c.node.Update(ctx, csi, scheme); err != nil {
+	// waiting components don't block the others, the earliest requeue is returned
+	requeue := &common.RequeueCollector{}
+
+	// digests are resolved before components are updated to be used in all of them,
+	// images, which are not resolved, are used without digest until retry
+	if err := requeue.Collect(c.imageDigests.Resolve(ctx, csi)); err != nil {
 		return err
 	}
 
-	if err := c.controller.Update(ctx, csi, scheme); err != nil {
+	if err := requeue.Collect(c.updateComponents(ctx, csi, scheme)); err != nil {
 		return err
 	}
 
-	if err := c.extender.Update(ctx, csi, scheme); err != nil {
+	// node pods are restarted in waves after all components are updated
+	if err := requeue.Collect(c.nodeUpgrade.Update(ctx, csi)); err != nil {
 		return err
 	}
 
-	if err := c.patcher.Update(ctx, csi, scheme); err != nil {
+	// versions are recorded only when changes are applied, so failed upgrade is checked again on retry
+	if err := c.compatibility.RecordAppliedVersions(ctx, csi); err != nil {
 		return err
 	}
 
-	return nil
+	return requeue.Result()
 }
 
-// ReconcileNodes performs node removal procedure
+// updatePlan reconciles copy of csi Deployment with Plan in context, so changes of objects, node labels
+// and status conditions are recorded instead of being applied, and reports them in status.plan.
+// Image digests are not resolved and node pods are not restarted while planning
+func (c *CSIDeployment) updatePlan(ctx context.Context, csi *csibaremetalv1.Deployment, scheme *runtime.Scheme) error {
+	plan := &common.Plan{}
+	planCtx := common.WithPlan(ctx, plan)
+	planned := csi.DeepCopy()
+
+	compatible, err := c.compatibility.Check(planCtx, planned)
+	if err != nil {
+		return err
+	}
+	if compatible {
+		err = c.updateComponents(planCtx, planned, scheme)
+		if _, ok := common.IsRequeueError(err); err != nil && !ok {
+			return err
+		}
+	}
+
+	result := plan.Result(csi.GetGeneration())
+	if !equality.Semantic.DeepEqual(csi.Status.Plan, result) {
+		csi.Status.Plan = result
+		if updateErr := c.client.Status().Update(ctx, csi); updateErr != nil {
+			return updateErr
+		}
+	}
+	return err
+}
+
+// updateComponents performs Update functions of csi-baremetal components.
+// RequeueError of a component is returned after the remaining components are updated
+func (c *CSIDeployment) updateComponents(ctx context.Context, csi *csibaremetalv1.Deployment, scheme *runtime.Scheme) error {
+	steps := []func() error{
+		func() error {
+			return c.logReceiver.Update(ctx, csi, scheme)
+		},
+		func() error {
+			return observeReconcile(nodeControllerComponent, func() error {
+				return c.nodeController.Update(ctx, csi, scheme)
+			})
+		},
+		func() error {
+			return observeReconcile(nodeComponent, func() error {
+				return c.node.Update(ctx, csi, scheme)
+			})
+		},
+		func() error {
+			return observeReconcile(controllerComponent, func() error {
+				return c.controller.Update(ctx, csi, scheme)
+			})
+		},
+		func() error {
+			return observeReconcile(extenderComponent, func() error {
+				return c.extender.Update(ctx, csi, scheme)
+			})
+		},
+		func() error {
+			return observeReconcile(patcherComponent, func() error {
+				return c.patcher.Update(ctx, csi, scheme)
+			})
+		},
+		func() error {
+			return c.monitoring.Update(ctx, csi, scheme)
+		},
+		func() error {
+			return c.storageClasses.Update(ctx, csi)
+		},
+	}
+
+	requeue := &common.RequeueCollector{}
+	for _, step := range steps {
+		if err := requeue.Collect(step()); err != nil {
+			return err
+		}
+	}
+
+	return requeue.Result()
+}
+
+// ReconcileNodes performs node removal procedure, it's postponed while csi Deployment is in plan-only mode
 func (c *CSIDeployment) ReconcileNodes(ctx context.Context, csi *csibaremetalv1.Deployment) error {
+	if common.IsPlanOnly(csi) {
+		return nil
+	}
+
 	if err := c.nodeOperationsController.Reconcile(ctx, csi); err != nil {
 		return err
 	}
@@ -152,10 +325,11 @@ func (c *CSIDeployment) ReconcileNodes(ctx context.Context, csi *csibaremetalv1.
 }
 
 // Uninstall cleans CSI
-func (c *CSIDeployment) Uninstall(ctx context.Context, csi *csibaremetalv1.Deployment) error {
+// Returns true when kube-scheduler configuration is restored and csi Deployment can be removed
+func (c *CSIDeployment) Uninstall(ctx context.Context, csi *csibaremetalv1.Deployment) (bool, error) {
 	var errMsgs []string
 
-	err := c.patcher.Uninstall(ctx, csi)
+	restored, err := c.patcher.Uninstall(ctx, csi)
 	if err != nil {
 		errMsgs = append(errMsgs, err.Error())
 	}
@@ -165,9 +339,24 @@ func (c *CSIDeployment) Uninstall(ctx context.Context, csi *csibaremetalv1.Deplo
 		errMsgs = append(errMsgs, err.Error())
 	}
 
+	err = c.controller.Uninstall(ctx, csi)
+	if err != nil {
+		errMsgs = append(errMsgs, err.Error())
+	}
+
+	err = c.logReceiver.Uninstall(ctx, csi)
+	if err != nil {
+		errMsgs = append(errMsgs, err.Error())
+	}
+
+	err = c.storageClasses.Uninstall(ctx, csi)
+	if err != nil {
+		errMsgs = append(errMsgs, err.Error())
+	}
+
 	if len(errMsgs) != 0 {
-		return fmt.Errorf(strings.Join(errMsgs, "\n"))
+		return restored, fmt.Errorf(strings.Join(errMsgs, "\n"))
 	}
 
-	return nil
+	return restored, nil
 }
@@ -7,14 +7,20 @@ import (
 	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
 	"github.com/dell/csi-baremetal-operator/api/v1/components"
 	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/compatibility"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
 	"github.com/dell/csi-baremetal-operator/pkg/validator/rbac"
 	"github.com/dell/csi-baremetal/pkg/events/mocks"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -196,9 +202,10 @@ func Test_CSIDeployment_Update(t *testing.T) {
 		eventRecorder := new(mocks.EventRecorder)
 		eventRecorder.On("Eventf", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
 
+		cl := prepareFakeValidatorClient(scheme, roleBinding, role, deployment.DeepCopy())
 		csiDeployment := NewCSIDeployment(
-			prepareFakeNodeClientSet(),
-			prepareFakeValidatorClient(scheme, roleBinding, role),
+			prepareCompatibleClientSet(),
+			cl,
 			rbac.NewMatcher(),
 			deploymentMatchSecurityContextConstraintsPolicies,
 			deploymentMatchPodSecurityPolicyPolicy,
@@ -207,12 +214,89 @@ func Test_CSIDeployment_Update(t *testing.T) {
 
 		assert.NotNil(t, csiDeployment)
 
-		err := csiDeployment.Update(ctx, &deployment, scheme)
+		csi := &csibaremetalv1.Deployment{}
+		assert.Nil(t, cl.Get(ctx, client.ObjectKeyFromObject(&deployment), csi))
+		err := csiDeployment.Update(ctx, csi, scheme)
 
 		assert.Nil(t, err)
+		assert.True(t, meta.IsStatusConditionTrue(csi.Status.Conditions, csibaremetalv1.CompatibleCondition))
+		assert.Equal(t, compatibility.OperatorVersion, csi.Status.AppliedVersions["operator"])
 	})
 }
 
+func Test_CSIDeployment_Update_PlanOnly(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		roleBinding = deploymentTestRoleBinding.DeepCopy()
+		role        = deploymentTestRolePodSecurityPolicy.DeepCopy()
+		planned     = deployment.DeepCopy()
+	)
+	planned.Annotations = map[string]string{csibaremetalv1.PlanOnlyAnnotation: "true"}
+	planned.Spec.Driver.Node = &components.Node{
+		ServiceAccount:    deployment.Spec.Driver.Node.ServiceAccount,
+		PodSecurityPolicy: deployment.Spec.Driver.Node.PodSecurityPolicy,
+		Image:             &components.Image{Name: "csi-baremetal-node"},
+		DriveMgr: &components.DriveMgr{
+			Image:    &components.Image{Name: "csi-baremetal-basemgr"},
+			Endpoint: "tcp://localhost:8888",
+		},
+		Sidecars: map[string]*components.Sidecar{
+			constant.LivenessProbeName:   {Image: &components.Image{Name: "livenessprobe"}},
+			constant.DriverRegistrarName: {Image: &components.Image{Name: "csi-node-driver-registrar"}},
+		},
+		Log: &components.Log{Level: "debug"},
+	}
+
+	scheme, _ := common.PrepareScheme()
+	eventRecorder := new(mocks.EventRecorder)
+	eventRecorder.On("Eventf", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	clientSet := prepareCompatibleClientSet().(*fake.Clientset)
+	_, err := clientSet.CoreV1().Nodes().Create(ctx, &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"key": "value"}},
+		Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{KernelVersion: "5.4.0-generic"}},
+	}, metav1.CreateOptions{})
+	assert.Nil(t, err)
+	clientSet.ClearActions()
+
+	cl := prepareFakeValidatorClient(scheme, roleBinding, role, planned)
+	csiDeployment := NewCSIDeployment(clientSet, cl, rbac.NewMatcher(), deploymentMatchSecurityContextConstraintsPolicies,
+		deploymentMatchPodSecurityPolicyPolicy, eventRecorder, logEntryDeployment)
+
+	csi := &csibaremetalv1.Deployment{}
+	assert.Nil(t, cl.Get(ctx, client.ObjectKeyFromObject(planned), csi))
+	assert.Nil(t, csiDeployment.Update(ctx, csi, scheme))
+
+	// nothing is changed in cluster
+	for _, action := range clientSet.Actions() {
+		assert.Contains(t, []string{"get", "list"}, action.GetVerb())
+	}
+	assert.Nil(t, meta.FindStatusCondition(csi.Status.Conditions, csibaremetalv1.CompatibleCondition))
+	assert.Nil(t, csi.Status.AppliedVersions)
+
+	plan := csi.Status.Plan
+	assert.NotNil(t, plan)
+	changes := map[string]csibaremetalv1.PlannedAction{}
+	for _, change := range plan.Changes {
+		changes[change.Kind+"/"+change.Name] = change.Action
+	}
+	assert.Equal(t, csibaremetalv1.PlannedCreate, changes["Deployment/csi-baremetal-controller"])
+	assert.Equal(t, csibaremetalv1.PlannedCreate, changes["DaemonSet/csi-baremetal-node-kernel-5.4"])
+	assert.Equal(t, csibaremetalv1.PlannedUpdate, changes["Node/node-1"])
+	assert.Equal(t, csibaremetalv1.PlannedUpdate, changes["Deployment.csi-baremetal.dell.com/test-deployment"])
+
+	// plan is stored in status
+	stored := &csibaremetalv1.Deployment{}
+	assert.Nil(t, cl.Get(ctx, client.ObjectKeyFromObject(planned), stored))
+	assert.Equal(t, plan, stored.Status.Plan)
+
+	// plan is removed when changes are applied
+	delete(csi.Annotations, csibaremetalv1.PlanOnlyAnnotation)
+	assert.Nil(t, csiDeployment.Update(ctx, csi, scheme))
+	assert.Nil(t, csi.Status.Plan)
+	assert.True(t, meta.IsStatusConditionTrue(csi.Status.Conditions, csibaremetalv1.CompatibleCondition))
+}
+
 func Test_CSIDeployment_Uninstall(t *testing.T) {
 	t.Run("Test CSIDeployment Uninstall function", func(t *testing.T) {
 		var (
@@ -236,9 +320,10 @@ func Test_CSIDeployment_Uninstall(t *testing.T) {
 
 		assert.NotNil(t, csiDeployment)
 
-		err := csiDeployment.Uninstall(ctx, &deployment)
+		restored, err := csiDeployment.Uninstall(ctx, &deployment)
 
 		assert.Nil(t, err)
+		assert.True(t, restored)
 	})
 }
 
@@ -249,5 +334,13 @@ func prepareFakeNodeClientSet(objects ...runtime.Object) kubernetes.Interface {
 func prepareFakeValidatorClient(scheme *runtime.Scheme, objects ...client.Object) client.Client {
 	builder := fakeClient.ClientBuilder{}
 	builderWithScheme := builder.WithScheme(scheme)
-	return builderWithScheme.WithObjects(objects...).Build()
+	return builderWithScheme.WithObjects(objects...).WithStatusSubresource(&csibaremetalv1.Deployment{}).Build()
+}
+
+// prepareCompatibleClientSet returns clientset with Kubernetes version supported by compatibility matrix
+func prepareCompatibleClientSet() kubernetes.Interface {
+	clientSet := fake.NewSimpleClientset()
+	fakeDiscovery := clientSet.Discovery().(*fakediscovery.FakeDiscovery)
+	fakeDiscovery.FakedServerVersion = &version.Info{GitVersion: "v1.27.3"}
+	return clientSet
 }
@@ -0,0 +1,224 @@
+package extenderprobe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	ctxTimeout     = 30 * time.Second
+	probeTimeout   = 60 * time.Second
+	requestTimeout = 5 * time.Second
+
+	extenderName        = constant.CSIName + "-se"
+	defaultExtenderPort = "8889"
+	probePodName        = constant.CSIName + "-extender-probe"
+
+	filterVerb     = "filter"
+	prioritizeVerb = "prioritize"
+
+	// ExtenderProbeFailedReason is set for Degraded condition if one of scheduler extenders doesn't respond
+	ExtenderProbeFailedReason = "ExtenderProbeFailed"
+	// ExtenderProbeSucceededReason is set for Degraded condition if all scheduler extenders respond
+	ExtenderProbeSucceededReason = "ExtenderProbeSucceeded"
+)
+
+// extenderprobe package implements a watcher, which periodically sends
+// synthetic filter and prioritize requests to each scheduler extender pod.
+// Extender is registered in kube-scheduler as ignorable, so its failures
+// silently disable csi-baremetal scheduling without the watcher
+
+// ExtenderProber is the watcher to check scheduler extenders health
+type ExtenderProber struct {
+	Client     client.Client
+	HTTPClient *http.Client
+	Log        *logrus.Entry
+}
+
+// extenderArgs is a copy of kube-scheduler ExtenderArgs,
+// which is used by kube-scheduler for extenders with nodeCacheCapable false
+type extenderArgs struct {
+	Pod   *corev1.Pod
+	Nodes *corev1.NodeList
+}
+
+// extenderFilterResult is a copy of kube-scheduler ExtenderFilterResult
+type extenderFilterResult struct {
+	Nodes       *corev1.NodeList
+	FailedNodes map[string]string
+	Error       string
+}
+
+// hostPriority is a copy of kube-scheduler HostPriority
+type hostPriority struct {
+	Host  string
+	Score int64
+}
+
+// LaunchExtenderProbing creates an instance of ExtenderProber and
+// start the infinite loop to probe scheduler extenders by timeout
+func LaunchExtenderProbing(client client.Client, log *logrus.Entry) {
+	prober := &ExtenderProber{
+		Client:     client,
+		HTTPClient: &http.Client{Timeout: requestTimeout},
+		Log:        log,
+	}
+
+	go func() {
+		for {
+			time.Sleep(probeTimeout)
+			prober.probeExtenders()
+		}
+	}()
+}
+
+func (p *ExtenderProber) probeExtenders() {
+	ctx, cancelFn := context.WithTimeout(context.Background(), ctxTimeout)
+	defer cancelFn()
+
+	deployments := &csibaremetalv1.DeploymentList{}
+	if err := p.Client.List(ctx, deployments); err != nil {
+		p.Log.Errorf("failed to get csi Deployment List: %s", err.Error())
+		return
+	}
+
+	for i := range deployments.Items {
+		csi := &deployments.Items[i]
+		if csi.Spec.Scheduler == nil || !csi.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		errMsgs, err := p.probeDeployment(ctx, csi)
+		if err != nil {
+			p.Log.Errorf("failed to probe scheduler extenders of %s: %s", csi.GetName(), err.Error())
+			continue
+		}
+
+		if err = p.updateDegradedCondition(ctx, csi, errMsgs); err != nil {
+			p.Log.Errorf("failed to update status of %s: %s", csi.GetName(), err.Error())
+		}
+	}
+}
+
+// probeDeployment checks all running extender pods of csi Deployment
+// returns list of probe failures
+func (p *ExtenderProber) probeDeployment(ctx context.Context, csi *csibaremetalv1.Deployment) ([]string, error) {
+	pods := &corev1.PodList{}
+	if err := p.Client.List(ctx, pods, client.InNamespace(csi.GetNamespace()),
+		client.MatchingLabels(common.ConstructSelectorMap(extenderName))); err != nil {
+		return nil, err
+	}
+
+	port := csi.Spec.Scheduler.ExtenderPort
+	if port == "" {
+		port = defaultExtenderPort
+	}
+
+	var errMsgs []string
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+
+		// extender uses host network, so pod IP is the same one kube-scheduler uses on the node
+		host := net.JoinHostPort(pod.Status.PodIP, port)
+		if err := p.probe(host, pod.Spec.NodeName, csi.GetNamespace()); err != nil {
+			p.Log.Warnf("Scheduler extender %s on node %s is unhealthy: %s", pod.GetName(), pod.Spec.NodeName, err.Error())
+			errMsgs = append(errMsgs, fmt.Sprintf("%s: %s", pod.Spec.NodeName, err.Error()))
+		}
+	}
+
+	return errMsgs, nil
+}
+
+// probe sends synthetic filter and prioritize requests to the extender
+func (p *ExtenderProber) probe(host, nodeName, namespace string) error {
+	args := &extenderArgs{
+		Pod: &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: probePodName, Namespace: namespace},
+		},
+		Nodes: &corev1.NodeList{
+			Items: []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}},
+		},
+	}
+
+	filterResult := &extenderFilterResult{}
+	if err := p.send(host, nodeName, filterVerb, args, filterResult); err != nil {
+		return err
+	}
+	if filterResult.Error != "" {
+		probeErrors.WithLabelValues(nodeName, filterVerb).Inc()
+		return fmt.Errorf("%s returned error: %s", filterVerb, filterResult.Error)
+	}
+
+	priorities := []hostPriority{}
+	return p.send(host, nodeName, prioritizeVerb, args, &priorities)
+}
+
+// send posts args to the extender verb and decodes response to result, latency and errors are recorded to metrics
+func (p *ExtenderProber) send(host, nodeName, verb string, args *extenderArgs, result interface{}) error {
+	body, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	resp, err := p.HTTPClient.Post(fmt.Sprintf("http://%s/%s", host, verb), "application/json", bytes.NewReader(body))
+	probeDuration.WithLabelValues(nodeName, verb).Observe(time.Since(start).Seconds())
+	if err != nil {
+		probeErrors.WithLabelValues(nodeName, verb).Inc()
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		probeErrors.WithLabelValues(nodeName, verb).Inc()
+		return fmt.Errorf("%s returned status %d", verb, resp.StatusCode)
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		probeErrors.WithLabelValues(nodeName, verb).Inc()
+		return fmt.Errorf("unable to decode %s response: %s", verb, err.Error())
+	}
+
+	return nil
+}
+
+// updateDegradedCondition sets Degraded condition of csi Deployment by probe failures
+func (p *ExtenderProber) updateDegradedCondition(ctx context.Context, csi *csibaremetalv1.Deployment, errMsgs []string) error {
+	condition := metav1.Condition{
+		Type:               csibaremetalv1.DegradedCondition,
+		Status:             metav1.ConditionFalse,
+		Reason:             ExtenderProbeSucceededReason,
+		Message:            "All scheduler extenders respond",
+		ObservedGeneration: csi.GetGeneration(),
+	}
+	if len(errMsgs) != 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ExtenderProbeFailedReason
+		condition.Message = "Scheduler extenders don't respond: " + strings.Join(errMsgs, "; ")
+	}
+
+	if !meta.SetStatusCondition(&csi.Status.Conditions, condition) {
+		return nil
+	}
+
+	return p.Client.Status().Update(ctx, csi)
+}
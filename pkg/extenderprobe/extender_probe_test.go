@@ -0,0 +1,150 @@
+package extenderprobe
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+)
+
+const (
+	testNS = "ns"
+)
+
+var (
+	ctx = context.Background()
+)
+
+func Test_probeExtenders(t *testing.T) {
+	t.Run("Should set Degraded false if extender responds", func(t *testing.T) {
+		server := newExtenderServer(t, "")
+		defer server.Close()
+
+		csi, pod := prepareObjects(t, server.URL)
+		prober := setupExtenderProber(server.Client(), csi, pod)
+		prober.probeExtenders()
+
+		condition := getDegradedCondition(t, prober, csi)
+		assert.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, ExtenderProbeSucceededReason, condition.Reason)
+	})
+
+	t.Run("Should set Degraded true if extender returns error", func(t *testing.T) {
+		server := newExtenderServer(t, "unable to read volumes")
+		defer server.Close()
+
+		csi, pod := prepareObjects(t, server.URL)
+		prober := setupExtenderProber(server.Client(), csi, pod)
+		prober.probeExtenders()
+
+		condition := getDegradedCondition(t, prober, csi)
+		assert.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, ExtenderProbeFailedReason, condition.Reason)
+		assert.Contains(t, condition.Message, "unable to read volumes")
+	})
+
+	t.Run("Should set Degraded true if extender is not reachable", func(t *testing.T) {
+		server := newExtenderServer(t, "")
+		csi, pod := prepareObjects(t, server.URL)
+		server.Close()
+
+		prober := setupExtenderProber(server.Client(), csi, pod)
+		prober.probeExtenders()
+
+		condition := getDegradedCondition(t, prober, csi)
+		assert.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Contains(t, condition.Message, "node0")
+	})
+
+	t.Run("Should skip not running extender pods", func(t *testing.T) {
+		server := newExtenderServer(t, "")
+		csi, pod := prepareObjects(t, server.URL)
+		server.Close()
+		pod.Status.Phase = corev1.PodPending
+
+		prober := setupExtenderProber(server.Client(), csi, pod)
+		prober.probeExtenders()
+
+		condition := getDegradedCondition(t, prober, csi)
+		assert.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	})
+}
+
+func newExtenderServer(t *testing.T, filterError string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		args := &extenderArgs{}
+		assert.Nil(t, json.NewDecoder(req.Body).Decode(args))
+		assert.Equal(t, probePodName, args.Pod.Name)
+
+		switch req.URL.Path {
+		case "/" + filterVerb:
+			assert.Nil(t, json.NewEncoder(rw).Encode(&extenderFilterResult{Nodes: args.Nodes, Error: filterError}))
+		case "/" + prioritizeVerb:
+			assert.Nil(t, json.NewEncoder(rw).Encode([]hostPriority{{Host: args.Nodes.Items[0].Name}}))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func prepareObjects(t *testing.T, serverURL string) (*csibaremetalv1.Deployment, *corev1.Pod) {
+	u, err := url.Parse(serverURL)
+	assert.Nil(t, err)
+
+	csi := &csibaremetalv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "csi-baremetal", Namespace: testNS},
+		Spec: components.DeploymentSpec{
+			Scheduler: &components.Scheduler{ExtenderPort: u.Port()},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      extenderName + "-abcde",
+			Namespace: testNS,
+			Labels:    common.ConstructSelectorMap(extenderName),
+		},
+		Spec:   corev1.PodSpec{NodeName: "node0"},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: u.Hostname()},
+	}
+
+	return csi, pod
+}
+
+func getDegradedCondition(t *testing.T, prober *ExtenderProber, csi *csibaremetalv1.Deployment) *metav1.Condition {
+	updated := &csibaremetalv1.Deployment{}
+	err := prober.Client.Get(ctx, client.ObjectKey{Name: csi.Name, Namespace: csi.Namespace}, updated)
+	assert.Nil(t, err)
+
+	return meta.FindStatusCondition(updated.Status.Conditions, csibaremetalv1.DegradedCondition)
+}
+
+func setupExtenderProber(httpClient *http.Client, objects ...client.Object) *ExtenderProber {
+	scheme, _ := common.PrepareScheme()
+	builder := fake.ClientBuilder{}
+	builderWithScheme := builder.WithScheme(scheme)
+	client := builderWithScheme.WithObjects(objects...).WithStatusSubresource(&csibaremetalv1.Deployment{}).Build()
+
+	return &ExtenderProber{
+		Client:     client,
+		HTTPClient: httpClient,
+		Log:        logrus.New().WithField("component", "ExtenderProberTest"),
+	}
+}
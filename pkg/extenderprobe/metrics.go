@@ -0,0 +1,35 @@
+package extenderprobe
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	metricsSubsystem = "extender_probe"
+)
+
+var (
+	// probeDuration tracks latency of synthetic filter/prioritize requests to scheduler extenders
+	probeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: constant.MetricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "duration_seconds",
+		Help:      "Latency of synthetic requests to scheduler extender",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"node", "verb"})
+
+	// probeErrors counts failed synthetic filter/prioritize requests to scheduler extenders
+	probeErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: constant.MetricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "errors_total",
+		Help:      "Number of failed synthetic requests to scheduler extender",
+	}, []string{"node", "verb"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(probeDuration, probeErrors)
+}
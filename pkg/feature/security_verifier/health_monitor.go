@@ -0,0 +1,112 @@
+package securityverifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dell/csi-baremetal/pkg/eventing"
+	"github.com/dell/csi-baremetal/pkg/events"
+	"github.com/sirupsen/logrus"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+	verifierModels "github.com/dell/csi-baremetal-operator/pkg/feature/security_verifier/models"
+	"github.com/dell/csi-baremetal-operator/pkg/validator"
+	validatorModels "github.com/dell/csi-baremetal-operator/pkg/validator/models"
+	"github.com/dell/csi-baremetal-operator/pkg/validator/rbac"
+	rbacModels "github.com/dell/csi-baremetal-operator/pkg/validator/rbac/models"
+)
+
+const (
+	// InsufficientClusterRolesReason is set for health monitor conditions when verification fails
+	InsufficientClusterRolesReason = "InsufficientClusterRoles"
+)
+
+var (
+	// healthMonitorControllerPolicies are required by csi-external-health-monitor-controller
+	healthMonitorControllerPolicies = []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"persistentvolumes"}, Verbs: []string{"get", "list", "watch"}},
+		{APIGroups: []string{""}, Resources: []string{"persistentvolumeclaims"}, Verbs: []string{"get", "list", "watch"}},
+		{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get", "list", "watch"}},
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list", "watch"}},
+		{APIGroups: []string{""}, Resources: []string{"events"}, Verbs: []string{"create", "patch"}},
+	}
+	// healthMonitorAgentPolicies are required by csi-external-health-monitor-agent
+	healthMonitorAgentPolicies = []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"persistentvolumes"}, Verbs: []string{"get", "list", "watch"}},
+		{APIGroups: []string{""}, Resources: []string{"persistentvolumeclaims"}, Verbs: []string{"get", "list", "watch"}},
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list", "watch"}},
+		{APIGroups: []string{""}, Resources: []string{"events"}, Verbs: []string{"create", "patch"}},
+	}
+)
+
+type healthMonitorVerifier struct {
+	validator     validator.Validator
+	eventRecorder events.EventRecorder
+	log           *logrus.Entry
+}
+
+func (v *healthMonitorVerifier) Verify(ctx context.Context, csi *csibaremetalv1.Deployment, component verifierModels.Component) error {
+	var serviceAccount string
+	var policies []rbacv1.PolicyRule
+	switch component {
+	case verifierModels.Controller:
+		serviceAccount = constant.ControllerServiceAccountName
+		policies = healthMonitorControllerPolicies
+	case verifierModels.Node:
+		serviceAccount = csi.Spec.Driver.Node.ServiceAccount
+		policies = healthMonitorAgentPolicies
+	default:
+		return fmt.Errorf("unknown component was passed")
+	}
+
+	return v.validator.ValidateRBAC(ctx, &validatorModels.RBACRules{
+		Data: &rbacModels.ServiceAccountIsClusterRoleBoundData{
+			ServiceAccountName: serviceAccount,
+			Namespace:          csi.Namespace,
+			ClusterRole: &rbacv1.ClusterRole{
+				Rules: policies,
+			},
+		},
+		Type: validatorModels.ServiceAccountIsClusterRoleBound,
+	})
+}
+
+func (v *healthMonitorVerifier) HandleError(_ context.Context, csi *csibaremetalv1.Deployment, serviceAccount string, err error) error {
+	var rbacError rbac.Error
+	if errors.As(err, &rbacError) {
+		v.eventRecorder.Eventf(csi, eventing.WarningType, "HealthMonitorVerificationFailed",
+			"ServiceAccount %s has insufficient cluster roles for volume health monitoring: %s",
+			serviceAccount, rbacError.Error())
+		v.log.Warning(rbacError, "Service account has insufficient cluster roles for volume health monitoring")
+		return NewVerifierError("Service account has insufficient cluster roles for volume health monitoring")
+	}
+	v.log.Error(err, "Error occurred while validating service account cluster role bindings")
+	return err
+}
+
+// NewHealthMonitorCondition returns condition of failed health monitor verification
+func NewHealthMonitorCondition(conditionType string, err error) metav1.Condition {
+	return metav1.Condition{
+		Type:    conditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  InsufficientClusterRolesReason,
+		Message: err.Error() + ", volume health monitor sidecar isn't deployed",
+	}
+}
+
+// NewHealthMonitorVerifier is a constructor for volume health monitor sidecars verifier
+func NewHealthMonitorVerifier(
+	validator validator.Validator,
+	eventRecorder events.EventRecorder,
+	log *logrus.Entry,
+) SecurityVerifier {
+	return &healthMonitorVerifier{
+		validator:     validator,
+		eventRecorder: eventRecorder,
+		log:           log,
+	}
+}
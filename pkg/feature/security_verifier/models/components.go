@@ -8,4 +8,6 @@ var (
 	Node Component = "Node"
 	// Scheduler represents the scheduler extender component
 	Scheduler Component = "Scheduler"
+	// Controller represents the controller component
+	Controller Component = "Controller"
 )
@@ -0,0 +1,483 @@
+package imagedigest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/node"
+)
+
+const (
+	// ResolvedReason is set for ImageDigestsResolved condition when all image tags are resolved
+	ResolvedReason = "DigestsResolved"
+	// UnresolvedReason is set for ImageDigestsResolved condition when some image tags can't be resolved
+	UnresolvedReason = "DigestsUnresolved"
+
+	defaultRegistry   = "registry-1.docker.io"
+	defaultRepository = "library"
+	defaultTag        = "latest"
+	requestTimeout    = 10 * time.Second
+	digestHeader      = "Docker-Content-Digest"
+
+	// failed resolution of image is retried after backoff, which is doubled on each failure
+	initialBackoff = 30 * time.Second
+	maxBackoff     = 30 * time.Minute
+)
+
+// manifestTypes are accepted by registry, manifest lists are preferred to get the same digest on all architectures
+var manifestTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Resolver resolves tags of csi-baremetal images to digests and records them in status of csi Deployment,
+// so all nodes run identical images even if tags are moved in registry
+type Resolver struct {
+	Clientset kubernetes.Interface
+	Client    client.Client
+	// Offline disables requests to registries, only digests recorded in status are used
+	Offline bool
+	*logrus.Entry
+
+	// failures keeps errors of images, which are not requested from registries until backoff expires
+	failuresMu sync.Mutex
+	failures   map[string]*failure
+}
+
+// failure is a cached error of image resolution
+type failure struct {
+	message string
+	backoff time.Duration
+	retry   time.Time
+}
+
+// reference is a parsed image name <registry>/<repository>:<tag>
+type reference struct {
+	registry   string
+	repository string
+	tag        string
+}
+
+// Resolve resolves image tags, which are not resolved yet. Tags, which can't be resolved, are reported
+// in ImageDigestsResolved condition and images are used without digest. Failed tags and registry secret,
+// which can't be read, are retried with backoff, RequeueError is returned to resolve them after the earliest backoff expires
+func (r *Resolver) Resolve(ctx context.Context, csi *csibaremetalv1.Deployment) error {
+	if csi.Spec.DigestResolution == nil || !csi.Spec.DigestResolution.Enable {
+		if csi.Status.ImageDigests != nil {
+			csi.Status.ImageDigests = nil
+			if err := r.Client.Status().Update(ctx, csi); err != nil {
+				return err
+			}
+		}
+		return common.RemoveStatusCondition(ctx, r.Client, csi, csibaremetalv1.ImageDigestsResolvedCondition)
+	}
+	if r.Offline {
+		return nil
+	}
+
+	httpClient := &http.Client{Timeout: requestTimeout}
+
+	var (
+		digests     = map[string]string{}
+		credentials map[string]string
+		secretRead  bool
+		secretErr   *failure
+		errMsgs     []string
+		unresolved  int
+		retry       time.Duration
+	)
+	for _, ref := range getImageReferences(csi) {
+		if digest, ok := csi.Status.ImageDigests[ref]; ok {
+			digests[ref] = digest
+			continue
+		}
+
+		// registry secret is read only if there are tags to resolve, its error is retried like unresolved tag
+		if !secretRead {
+			secretRead = true
+			credentials, secretErr = r.getCredentials(ctx, csi)
+			if secretErr != nil {
+				errMsgs = append(errMsgs, fmt.Sprintf("registry secret %s: %s", csi.Spec.RegistrySecret, secretErr.message))
+				retry = time.Until(secretErr.retry)
+			}
+		}
+		if secretErr != nil {
+			unresolved++
+			continue
+		}
+
+		cached := r.getFailure(ref)
+		if cached == nil {
+			digest, err := resolveDigest(ctx, httpClient, parseReference(ref), credentials, csi.Spec.DigestResolution.Insecure)
+			if err == nil {
+				r.Infof("Image %s is resolved to %s", ref, digest)
+				r.removeFailure(ref)
+				digests[ref] = digest
+				continue
+			}
+			cached = r.addFailure(ref, err)
+			r.Warnf("Failed to resolve digest of image %s, retry in %s: %s", ref, cached.backoff, err.Error())
+		}
+
+		unresolved++
+		errMsgs = append(errMsgs, fmt.Sprintf("%s: %s", ref, cached.message))
+		if after := time.Until(cached.retry); retry == 0 || after < retry {
+			retry = after
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(csi.Status.ImageDigests, digests) {
+		csi.Status.ImageDigests = digests
+		if err := r.Client.Status().Update(ctx, csi); err != nil {
+			return err
+		}
+	}
+
+	if len(errMsgs) != 0 {
+		if err := common.UpdateStatusCondition(ctx, r.Client, csi, metav1.Condition{
+			Type:    csibaremetalv1.ImageDigestsResolvedCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  UnresolvedReason,
+			Message: strings.Join(errMsgs, "; "),
+		}); err != nil {
+			return err
+		}
+		return common.NewRequeueError(retry, fmt.Sprintf("%d image tags are not resolved", unresolved))
+	}
+	return common.UpdateStatusCondition(ctx, r.Client, csi, metav1.Condition{
+		Type:    csibaremetalv1.ImageDigestsResolvedCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  ResolvedReason,
+		Message: fmt.Sprintf("%d image tags are resolved", len(digests)),
+	})
+}
+
+// getFailure returns cached failure of the image, nil if resolution has to be retried
+func (r *Resolver) getFailure(ref string) *failure {
+	r.failuresMu.Lock()
+	defer r.failuresMu.Unlock()
+
+	if cached, ok := r.failures[ref]; ok && time.Now().Before(cached.retry) {
+		return cached
+	}
+	return nil
+}
+
+// addFailure caches error of the image with doubled backoff of the previous failure
+func (r *Resolver) addFailure(ref string, err error) *failure {
+	r.failuresMu.Lock()
+	defer r.failuresMu.Unlock()
+
+	if r.failures == nil {
+		r.failures = map[string]*failure{}
+	}
+	backoff := initialBackoff
+	if previous, ok := r.failures[ref]; ok {
+		backoff = previous.backoff * 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+	cached := &failure{message: err.Error(), backoff: backoff, retry: time.Now().Add(backoff)}
+	r.failures[ref] = cached
+	return cached
+}
+
+// removeFailure resets backoff of the image
+func (r *Resolver) removeFailure(ref string) {
+	r.failuresMu.Lock()
+	defer r.failuresMu.Unlock()
+	delete(r.failures, ref)
+}
+
+// getCredentials returns auth of registries from RegistrySecret, nil if the secret isn't set or found.
+// Failure to read the secret is cached with backoff as failure of image
+func (r *Resolver) getCredentials(ctx context.Context, csi *csibaremetalv1.Deployment) (map[string]string, *failure) {
+	if csi.Spec.RegistrySecret == "" {
+		return nil, nil
+	}
+
+	// key of the secret can't be confused with image reference, since it contains space
+	key := "secret " + csi.GetNamespace() + "/" + csi.Spec.RegistrySecret
+	if cached := r.getFailure(key); cached != nil {
+		return nil, cached
+	}
+
+	secret, err := r.Clientset.CoreV1().Secrets(csi.GetNamespace()).Get(ctx, csi.Spec.RegistrySecret, metav1.GetOptions{})
+	if k8sError.IsNotFound(err) {
+		r.Warnf("Registry secret %s is not found, images are resolved anonymously", csi.Spec.RegistrySecret)
+		r.removeFailure(key)
+		return nil, nil
+	}
+
+	var credentials map[string]string
+	if err == nil {
+		credentials, err = parseDockerConfig(secret.Data[corev1.DockerConfigJsonKey])
+	}
+	if err != nil {
+		cached := r.addFailure(key, err)
+		r.Warnf("Failed to read registry secret %s, retry in %s: %s", csi.Spec.RegistrySecret, cached.backoff, err.Error())
+		return nil, cached
+	}
+	r.removeFailure(key)
+	return credentials, nil
+}
+
+// parseDockerConfig returns base64 encoded <username>:<password> of registries from .dockerconfigjson
+func parseDockerConfig(data []byte) (map[string]string, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var config struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse registry secret: %s", err.Error())
+	}
+
+	credentials := map[string]string{}
+	for registry, auth := range config.Auths {
+		registry = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://"), "/")
+		if auth.Auth == "" {
+			auth.Auth = base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+		}
+		credentials[registry] = auth.Auth
+	}
+	return credentials, nil
+}
+
+// getImageReferences returns sorted references of images, which are not pinned by digest
+func getImageReferences(csi *csibaremetalv1.Deployment) []string {
+	refs := map[string]bool{}
+	for _, image := range getImages(&csi.Spec) {
+		if image != nil && image.Digest == "" {
+			refs[common.ConstructImageReference(image, &csi.Spec)] = true
+		}
+	}
+
+	result := make([]string, 0, len(refs))
+	for ref := range refs {
+		result = append(result, ref)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// getImages returns images of all csi-baremetal components
+func getImages(spec *components.DeploymentSpec) []*components.Image {
+	var images []*components.Image
+	if driver := spec.Driver; driver != nil {
+		if driver.Controller != nil {
+			images = append(images, driver.Controller.Image)
+			images = append(images, getSidecarImages(driver.Controller.Sidecars)...)
+		}
+		if driver.Node != nil {
+			if driver.Node.Image != nil {
+				images = append(images, node.NodeImages(driver.Node.Image)...)
+			}
+			images = append(images, getSidecarImages(driver.Node.Sidecars)...)
+			if driver.Node.DriveMgr != nil {
+				images = append(images, driver.Node.DriveMgr.Image)
+				for _, group := range driver.Node.DriveMgr.NodeGroups {
+					if group.Image != nil {
+						images = append(images, group.Image)
+					}
+				}
+			}
+		}
+		if driver.LogReceiver != nil {
+			images = append(images, driver.LogReceiver.Image)
+		}
+	}
+	if scheduler := spec.Scheduler; scheduler != nil {
+		images = append(images, scheduler.Image)
+		if scheduler.Patcher != nil {
+			images = append(images, scheduler.Patcher.Image)
+		}
+		if scheduler.OpenshiftSecondaryScheduler != nil {
+			images = append(images, scheduler.OpenshiftSecondaryScheduler.Image)
+		}
+	}
+	if spec.NodeController != nil {
+		images = append(images, spec.NodeController.Image)
+	}
+	return images
+}
+
+func getSidecarImages(sidecars map[string]*components.Sidecar) []*components.Image {
+	var images []*components.Image
+	for _, sidecar := range sidecars {
+		if sidecar != nil {
+			images = append(images, sidecar.Image)
+		}
+	}
+	return images
+}
+
+// parseReference splits image reference to registry, repository and tag,
+// Docker Hub is used if registry isn't set
+func parseReference(ref string) reference {
+	result := reference{registry: defaultRegistry, tag: defaultTag}
+
+	name := ref
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		result.tag = name[i+1:]
+		name = name[:i]
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		result.registry = parts[0]
+		name = parts[1]
+	}
+	if result.registry == defaultRegistry && !strings.Contains(name, "/") {
+		name = defaultRepository + "/" + name
+	}
+	result.repository = name
+	return result
+}
+
+// resolveDigest requests manifest of the image via Docker Registry HTTP API V2
+func resolveDigest(ctx context.Context, httpClient *http.Client, ref reference,
+	credentials map[string]string, insecure bool) (string, error) {
+	scheme := "https"
+	if insecure {
+		scheme = "http"
+	}
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, ref.registry, ref.repository, ref.tag)
+	auth := credentials[ref.registry]
+	if auth == "" && ref.registry == defaultRegistry {
+		auth = credentials["index.docker.io/v1"]
+	}
+
+	var authorization string
+	if auth != "" {
+		authorization = "Basic " + auth
+	}
+
+	resp, err := getManifest(ctx, httpClient, manifestURL, authorization)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// token authentication is used by most of registries
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := getToken(ctx, httpClient, resp.Header.Get("WWW-Authenticate"), auth)
+		if err != nil {
+			return "", err
+		}
+		if resp, err = getManifest(ctx, httpClient, manifestURL, "Bearer "+token); err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry responded with %s", resp.Status)
+	}
+	if digest := resp.Header.Get(digestHeader); digest != "" {
+		return digest, nil
+	}
+
+	// registry may not return digest header, digest is calculated from manifest content in this case
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(hash[:]), nil
+}
+
+func getManifest(ctx context.Context, httpClient *http.Client, manifestURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return httpClient.Do(req)
+}
+
+// getToken requests bearer token from the realm of WWW-Authenticate challenge
+func getToken(ctx context.Context, httpClient *http.Client, challenge, auth string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("registry requires unsupported authentication: %s", challenge)
+	}
+
+	params := map[string]string{}
+	for _, param := range strings.Split(strings.TrimPrefix(challenge, "Bearer "), ",") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok {
+			params[key] = strings.Trim(value, `"`)
+		}
+	}
+	if params["realm"] == "" {
+		return "", fmt.Errorf("realm is not set in authentication challenge: %s", challenge)
+	}
+
+	query := url.Values{}
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", "Basic "+auth)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token service responded with %s", resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to parse token: %s", err.Error())
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	return token.AccessToken, nil
+}
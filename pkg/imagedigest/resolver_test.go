@@ -0,0 +1,252 @@
+package imagedigest
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+)
+
+const (
+	testNamespace = "test-csi"
+	testSecret    = "registry-secret"
+	testAuth      = "user:password"
+	testToken     = "token"
+)
+
+// newRegistry starts registry, which requires token issued for testAuth credentials
+// and serves manifests of the passed repositories
+func newRegistry(t *testing.T, manifests map[string]string, requests *int) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.Header.Get("Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte(testAuth)) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token": "` + testToken + `"}`))
+			return
+		}
+
+		*requests++
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		digest, ok := manifests[strings.TrimPrefix(r.URL.Path, "/v2/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set(digestHeader, digest)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func prepareDeployment(registry string) *csibaremetalv1.Deployment {
+	return &csibaremetalv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "csi-baremetal.dell.com/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test-deployment", Namespace: testNamespace},
+		Spec: components.DeploymentSpec{
+			Driver: &components.Driver{
+				Controller: &components.Controller{Image: &components.Image{Name: "csi-baremetal-controller", Tag: "1.6.2"}},
+			},
+			Scheduler: &components.Scheduler{
+				Image: &components.Image{Name: "csi-baremetal-scheduler-extender", Tag: "1.6.2", Digest: "sha256:pinned"},
+			},
+			NodeController:  &components.NodeController{Image: &components.Image{Name: "csi-baremetal-node-controller", Tag: "1.6.2"}},
+			GlobalRegistry:  "source.example.com",
+			RegistryMirrors: map[string]string{"source.example.com": registry},
+			RegistrySecret:  testSecret,
+			DigestResolution: &components.DigestResolution{
+				Enable:   true,
+				Insecure: true,
+			},
+		},
+	}
+}
+
+func prepareResolver(csi *csibaremetalv1.Deployment) *Resolver {
+	clientSet := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: testSecret, Namespace: testNamespace},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths": {"` +
+			csi.Spec.RegistryMirrors["source.example.com"] + `": {"username": "user", "password": "password"}}}`)},
+	})
+
+	scheme, _ := common.PrepareScheme()
+	cl := fakeClient.NewClientBuilder().WithScheme(scheme).WithObjects(csi).
+		WithStatusSubresource(&csibaremetalv1.Deployment{}).Build()
+	return &Resolver{Clientset: clientSet, Client: cl, Entry: logrus.WithField("Test name", "ImageDigestTest")}
+}
+
+func Test_Resolve(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Digests are resolved once", func(t *testing.T) {
+		var requests int
+		server := newRegistry(t, map[string]string{
+			"csi-baremetal-controller/manifests/1.6.2":      "sha256:controller",
+			"csi-baremetal-node-controller/manifests/1.6.2": "sha256:nodecontroller",
+		}, &requests)
+		registry := strings.TrimPrefix(server.URL, "http://")
+		csi := prepareDeployment(registry)
+		resolver := prepareResolver(csi)
+
+		assert.Nil(t, resolver.Resolve(ctx, csi))
+		assert.Equal(t, map[string]string{
+			registry + "/csi-baremetal-controller:1.6.2":      "sha256:controller",
+			registry + "/csi-baremetal-node-controller:1.6.2": "sha256:nodecontroller",
+		}, csi.Status.ImageDigests)
+		assert.True(t, meta.IsStatusConditionTrue(csi.Status.Conditions, csibaremetalv1.ImageDigestsResolvedCondition))
+		assert.Equal(t, registry+"/csi-baremetal-controller:1.6.2@sha256:controller",
+			common.ConstructFullImageName(csi.Spec.Driver.Controller.Image, csi))
+
+		requests = 0
+		assert.Nil(t, resolver.Resolve(ctx, csi))
+		assert.Equal(t, 0, requests)
+	})
+
+	t.Run("Unresolved tag", func(t *testing.T) {
+		var requests int
+		server := newRegistry(t, map[string]string{
+			"csi-baremetal-controller/manifests/1.6.2": "sha256:controller",
+		}, &requests)
+		registry := strings.TrimPrefix(server.URL, "http://")
+		csi := prepareDeployment(registry)
+		resolver := prepareResolver(csi)
+
+		err := resolver.Resolve(ctx, csi)
+		requeueErr, ok := common.IsRequeueError(err)
+		assert.True(t, ok)
+		assert.InDelta(t, initialBackoff, requeueErr.After, float64(time.Second))
+		assert.Len(t, csi.Status.ImageDigests, 1)
+		condition := meta.FindStatusCondition(csi.Status.Conditions, csibaremetalv1.ImageDigestsResolvedCondition)
+		assert.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Contains(t, condition.Message, "csi-baremetal-node-controller:1.6.2")
+		assert.Equal(t, registry+"/csi-baremetal-node-controller:1.6.2",
+			common.ConstructFullImageName(csi.Spec.NodeController.Image, csi))
+
+		// failure is cached until backoff expires
+		requests = 0
+		_, ok = common.IsRequeueError(resolver.Resolve(ctx, csi))
+		assert.True(t, ok)
+		assert.Equal(t, 0, requests)
+		condition = meta.FindStatusCondition(csi.Status.Conditions, csibaremetalv1.ImageDigestsResolvedCondition)
+		assert.Contains(t, condition.Message, "csi-baremetal-node-controller:1.6.2")
+
+		// backoff is doubled on the next failure
+		ref := registry + "/csi-baremetal-node-controller:1.6.2"
+		resolver.failures[ref].retry = time.Now()
+		_, ok = common.IsRequeueError(resolver.Resolve(ctx, csi))
+		assert.True(t, ok)
+		assert.NotEqual(t, 0, requests)
+		assert.Equal(t, 2*initialBackoff, resolver.failures[ref].backoff)
+	})
+
+	t.Run("Registry secret can't be read", func(t *testing.T) {
+		var requests int
+		server := newRegistry(t, map[string]string{
+			"csi-baremetal-controller/manifests/1.6.2":      "sha256:controller",
+			"csi-baremetal-node-controller/manifests/1.6.2": "sha256:nodecontroller",
+		}, &requests)
+		registry := strings.TrimPrefix(server.URL, "http://")
+		csi := prepareDeployment(registry)
+		resolver := prepareResolver(csi)
+		clientSet := resolver.Clientset.(*fake.Clientset)
+		clientSet.PrependReactor("get", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, k8sError.NewForbidden(corev1.Resource("secrets"), testSecret, errors.New("access denied"))
+		})
+
+		err := resolver.Resolve(ctx, csi)
+		requeueErr, ok := common.IsRequeueError(err)
+		assert.True(t, ok)
+		assert.InDelta(t, initialBackoff, requeueErr.After, float64(time.Second))
+		assert.Equal(t, 0, requests)
+		assert.Empty(t, csi.Status.ImageDigests)
+		condition := meta.FindStatusCondition(csi.Status.Conditions, csibaremetalv1.ImageDigestsResolvedCondition)
+		assert.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Contains(t, condition.Message, "registry secret "+testSecret)
+
+		// secret isn't read until backoff expires
+		actions := len(clientSet.Actions())
+		_, ok = common.IsRequeueError(resolver.Resolve(ctx, csi))
+		assert.True(t, ok)
+		assert.Equal(t, actions, len(clientSet.Actions()))
+
+		// images are resolved when secret is readable
+		clientSet.ReactionChain = clientSet.ReactionChain[1:]
+		resolver.failures["secret "+testNamespace+"/"+testSecret].retry = time.Now()
+		assert.Nil(t, resolver.Resolve(ctx, csi))
+		assert.Len(t, csi.Status.ImageDigests, 2)
+		assert.True(t, meta.IsStatusConditionTrue(csi.Status.Conditions, csibaremetalv1.ImageDigestsResolvedCondition))
+	})
+
+	t.Run("Resolution is disabled", func(t *testing.T) {
+		csi := prepareDeployment("mirror.local")
+		csi.Spec.DigestResolution = nil
+		csi.Status.ImageDigests = map[string]string{"mirror.local/csi-baremetal-controller:1.6.2": "sha256:controller"}
+		csi.Status.Conditions = []metav1.Condition{{Type: csibaremetalv1.ImageDigestsResolvedCondition, Status: metav1.ConditionTrue}}
+		resolver := prepareResolver(csi)
+
+		assert.Nil(t, resolver.Resolve(ctx, csi))
+		assert.Nil(t, csi.Status.ImageDigests)
+		assert.Nil(t, meta.FindStatusCondition(csi.Status.Conditions, csibaremetalv1.ImageDigestsResolvedCondition))
+	})
+}
+
+func Test_parseReference(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected reference
+	}{
+		{"csi-baremetal-node", reference{registry: defaultRegistry, repository: "library/csi-baremetal-node", tag: defaultTag}},
+		{"dell/csi-baremetal-node:1.6.2", reference{registry: defaultRegistry, repository: "dell/csi-baremetal-node", tag: "1.6.2"}},
+		{"localhost/csi-baremetal-node:1.6.2", reference{registry: "localhost", repository: "csi-baremetal-node", tag: "1.6.2"}},
+		{"mirror.local:5000/csi/csi-baremetal-node:1.6.2",
+			reference{registry: "mirror.local:5000", repository: "csi/csi-baremetal-node", tag: "1.6.2"}},
+		{"mirror.local:5000/csi-baremetal-node", reference{registry: "mirror.local:5000", repository: "csi-baremetal-node", tag: defaultTag}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseReference(tt.ref))
+		})
+	}
+}
+
+func Test_parseDockerConfig(t *testing.T) {
+	credentials, err := parseDockerConfig([]byte(`{"auths": {
+		"https://mirror.local/": {"username": "user", "password": "password"},
+		"registry.example.com": {"auth": "dXNlcjpwYXNzd29yZA=="}}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"mirror.local":         base64.StdEncoding.EncodeToString([]byte(testAuth)),
+		"registry.example.com": "dXNlcjpwYXNzd29yZA==",
+	}, credentials)
+
+	_, err = parseDockerConfig([]byte("{"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,267 @@
+package logreceiver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	// ConfigMapName is the name of generated fluent-bit configuration
+	ConfigMapName = constant.CSIName + "-logs-config"
+	// ContainerName is the name of log receiver sidecar
+	ContainerName = "fluent-bit"
+
+	configVolume    = "logs-config"
+	configPath      = "/fluent-bit/etc"
+	configKey       = "fluent-bit.conf"
+	parsersKey      = "fluent-parsers.conf"
+	instanceEnv     = "INSTANCE"
+	esUserEnv       = "ES_USER"
+	esPasswordEnv   = "ES_PASSWORD"
+	defaultPort     = 24224
+	defaultSyslog   = 514
+	defaultES       = 9200
+	defaultProtocol = "udp"
+)
+
+// LogReceiver controls configuration of fluent-bit sidecars
+type LogReceiver struct {
+	Clientset kubernetes.Interface
+	*logrus.Entry
+}
+
+// Update creates or updates log receiver configmap, removes it if log receiver is disabled
+func (l *LogReceiver) Update(ctx context.Context, csi *csibaremetalv1.Deployment, scheme *runtime.Scheme) error {
+	if !IsEnabled(csi) {
+		return l.Uninstall(ctx, csi)
+	}
+
+	expected := createConfigMap(csi)
+	if err := controllerutil.SetControllerReference(csi, expected, scheme); err != nil {
+		return err
+	}
+
+	return common.UpdateConfigMap(ctx, l.Clientset, expected, l.Entry)
+}
+
+// Uninstall removes log receiver configmap
+func (l *LogReceiver) Uninstall(ctx context.Context, csi *csibaremetalv1.Deployment) error {
+	if plan := common.GetPlan(ctx); plan != nil {
+		_, err := l.Clientset.CoreV1().ConfigMaps(csi.GetNamespace()).Get(ctx, ConfigMapName, metav1.GetOptions{})
+		if err == nil {
+			plan.Add(csibaremetalv1.PlannedDelete, "ConfigMap", csi.GetNamespace(), ConfigMapName)
+		}
+		if apiErrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	err := l.Clientset.CoreV1().ConfigMaps(csi.GetNamespace()).Delete(ctx, ConfigMapName, metav1.DeleteOptions{})
+	if err != nil && !apiErrors.IsNotFound(err) {
+		l.Error(err, "Failed to delete configmap "+ConfigMapName)
+		return err
+	}
+
+	return nil
+}
+
+// IsEnabled checks if log receiver is enabled in csi Deployment
+func IsEnabled(csi *csibaremetalv1.Deployment) bool {
+	return csi.Spec.Driver != nil && csi.Spec.Driver.LogReceiver != nil && csi.Spec.Driver.LogReceiver.Enabled
+}
+
+// AddSidecar injects fluent-bit container, which tails logs volume, and its configuration volume into template
+func AddSidecar(template *corev1.PodTemplateSpec, csi *csibaremetalv1.Deployment) {
+	if !IsEnabled(csi) {
+		return
+	}
+
+	lr := csi.Spec.Driver.LogReceiver
+	env := []corev1.EnvVar{
+		{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "metadata.name"},
+		}},
+		{Name: "NODE_NAME", ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "spec.nodeName"},
+		}},
+		{Name: "NAMESPACE", ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "metadata.namespace"},
+		}},
+		{Name: instanceEnv, ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1",
+				FieldPath: fmt.Sprintf("metadata.labels['%s']", constant.RsysLabelKey)},
+		}},
+	}
+	if lr.Output != nil && lr.Output.CredentialsSecret != "" {
+		env = append(env,
+			secretEnv(esUserEnv, lr.Output.CredentialsSecret, "username"),
+			secretEnv(esPasswordEnv, lr.Output.CredentialsSecret, "password"))
+	}
+
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: configVolume,
+		VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: ConfigMapName},
+		}},
+	})
+	template.Spec.Containers = append(template.Spec.Containers, corev1.Container{
+		Name:            ContainerName,
+		Image:           common.ConstructFullImageName(lr.Image, csi),
+		ImagePullPolicy: corev1.PullPolicy(csi.Spec.PullPolicy),
+		Env:             env,
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: constant.LogsVolume, MountPath: "/var/log"},
+			{Name: configVolume, MountPath: configPath},
+		},
+		TerminationMessagePath:   constant.TerminationMessagePath,
+		TerminationMessagePolicy: constant.TerminationMessagePolicy,
+		Resources:                common.ConstructResourceRequirements(lr.Resources),
+	})
+}
+
+func secretEnv(name, secret, key string) corev1.EnvVar {
+	return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{
+		SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: secret},
+			Key:                  key,
+		},
+	}}
+}
+
+func createConfigMap(csi *csibaremetalv1.Deployment) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName,
+			Namespace: csi.GetNamespace(),
+			Labels:    common.ConstructLabelAppMap(),
+		},
+		Data: map[string]string{
+			configKey:  createConfig(csi.Spec.Driver.LogReceiver.Output),
+			parsersKey: createParsers(),
+		},
+	}
+}
+
+func createConfig(output *components.LogOutput) string {
+	var sb strings.Builder
+	sb.WriteString(`[SERVICE]
+    Flush         5
+    Log_Level     info
+    Daemon        off
+    Parsers_File  ` + parsersKey + `
+    HTTP_Server   On
+    HTTP_Listen   0.0.0.0
+    HTTP_Port     2020
+[INPUT]
+    Name             tail
+    Path             /var/log/*.log
+    DB               /var/log/flb.db
+    Path_Key         filename
+    Parser           csi-logs
+    Mem_Buf_Limit    5MB
+    Skip_Long_Lines  Off
+    Refresh_Interval 5
+    Tag              ` + constant.CSIName + `
+[FILTER]
+    Name             record_modifier
+    Match            *
+    Record pod_name ${POD_NAME}
+    Record node_name ${NODE_NAME}
+    Record namespace ${NAMESPACE}
+    Record instance ${` + instanceEnv + `}
+`)
+	sb.WriteString(createOutput(output))
+	return sb.String()
+}
+
+func createOutput(output *components.LogOutput) string {
+	if output == nil {
+		output = &components.LogOutput{Type: components.StdoutOutput}
+	}
+
+	switch output.Type {
+	case components.ForwardOutput:
+		return `[OUTPUT]
+    Name            forward
+    Match           *
+    Host            ` + output.Host + `
+    Port            ` + portOrDefault(output.Port, defaultPort) + `
+    Retry_Limit     False
+`
+	case components.SyslogOutput:
+		protocol := output.Protocol
+		if protocol == "" {
+			protocol = defaultProtocol
+		}
+		// instance record carries rsyslog label of the pod and is used as application name for directory layout
+		return `[OUTPUT]
+    Name               syslog
+    Match              *
+    Host               ` + output.Host + `
+    Port               ` + portOrDefault(output.Port, defaultSyslog) + `
+    Mode               ` + protocol + `
+    Syslog_Format      rfc5424
+    Syslog_Hostname_key node_name
+    Syslog_Appname_key instance
+    Syslog_Message_key msg
+    Retry_Limit        False
+`
+	case components.ElasticsearchOutput:
+		tls := "Off"
+		if output.Protocol == "https" {
+			tls = "On\n    tls.verify      Off"
+			if output.CredentialsSecret != "" {
+				tls += "\n    HTTP_User       ${" + esUserEnv + "}\n    HTTP_Passwd     ${" + esPasswordEnv + "}"
+			}
+		}
+		return `[OUTPUT]
+    Name            es
+    Match           *
+    tls             ` + tls + `
+    Type            logEvent
+    Host            ` + output.Host + `
+    Port            ` + portOrDefault(output.Port, defaultES) + `
+    Logstash_Format on
+    Retry_Limit     False
+    Logstash_Prefix kubernetes_cluster-` + constant.CSIName + `
+`
+	default:
+		return `[OUTPUT]
+    Name            stdout
+    Match           *
+    Format          json_lines
+`
+	}
+}
+
+func createParsers() string {
+	return `[PARSER]
+    Name        csi-logs
+    Format      json
+    Time_Key    time
+    Time_Format %Y-%m-%dT%H:%M:%S %z
+`
+}
+
+func portOrDefault(port, defaultValue int32) string {
+	if port == 0 {
+		port = defaultValue
+	}
+	return strconv.Itoa(int(port))
+}
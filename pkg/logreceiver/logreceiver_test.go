@@ -0,0 +1,122 @@
+package logreceiver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const ns = "test-csi"
+
+func prepareDeployment(receiver *components.LogReceiver) *csibaremetalv1.Deployment {
+	return &csibaremetalv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "csi-baremetal.dell.com/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test-deployment", Namespace: ns},
+		Spec: components.DeploymentSpec{
+			GlobalRegistry: "asdrepo.isus.emc.com:9042",
+			Driver:         &components.Driver{LogReceiver: receiver},
+		},
+	}
+}
+
+func prepareLogReceiver(objects ...*corev1.ConfigMap) *LogReceiver {
+	clientSet := fake.NewSimpleClientset()
+	for _, obj := range objects {
+		_, _ = clientSet.CoreV1().ConfigMaps(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+	}
+	return &LogReceiver{Clientset: clientSet, Entry: logrus.New().WithField("component", "logReceiver")}
+}
+
+func Test_Update(t *testing.T) {
+	scheme, _ := common.PrepareScheme()
+
+	t.Run("Create configmap", func(t *testing.T) {
+		lr := prepareLogReceiver()
+		csi := prepareDeployment(&components.LogReceiver{Enabled: true, Name: "fluent-bit", Output: &components.LogOutput{
+			Type: components.SyslogOutput, Host: "rsyslog.local", Protocol: "tcp"}})
+
+		assert.Nil(t, lr.Update(context.Background(), csi, scheme))
+
+		cm, err := lr.Clientset.CoreV1().ConfigMaps(ns).Get(context.Background(), ConfigMapName, metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Len(t, cm.OwnerReferences, 1)
+		assert.Contains(t, cm.Data[configKey], "Name               syslog")
+		assert.Contains(t, cm.Data[configKey], "Host               rsyslog.local")
+		assert.Contains(t, cm.Data[configKey], "Port               514")
+		assert.Contains(t, cm.Data[configKey], "Mode               tcp")
+		assert.Contains(t, cm.Data[configKey], "Syslog_Appname_key instance")
+		assert.Contains(t, cm.Data[parsersKey], "csi-logs")
+	})
+
+	t.Run("Remove configmap when log receiver is disabled", func(t *testing.T) {
+		lr := prepareLogReceiver(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: ns}})
+
+		assert.Nil(t, lr.Update(context.Background(), prepareDeployment(&components.LogReceiver{Name: "fluent-bit"}), scheme))
+
+		_, err := lr.Clientset.CoreV1().ConfigMaps(ns).Get(context.Background(), ConfigMapName, metav1.GetOptions{})
+		assert.NotNil(t, err)
+	})
+}
+
+func Test_createOutput(t *testing.T) {
+	assert.Contains(t, createOutput(nil), "Name            stdout")
+	assert.Contains(t, createOutput(&components.LogOutput{Type: components.StdoutOutput}), "Name            stdout")
+
+	forward := createOutput(&components.LogOutput{Type: components.ForwardOutput, Host: "fluentd", Port: 24000})
+	assert.Contains(t, forward, "Name            forward")
+	assert.Contains(t, forward, "Host            fluentd")
+	assert.Contains(t, forward, "Port            24000")
+
+	syslog := createOutput(&components.LogOutput{Type: components.SyslogOutput, Host: "rsyslog"})
+	assert.Contains(t, syslog, "Mode               udp")
+
+	es := createOutput(&components.LogOutput{Type: components.ElasticsearchOutput, Host: "es", Protocol: "http"})
+	assert.Contains(t, es, "Name            es")
+	assert.Contains(t, es, "tls             Off")
+	assert.Contains(t, es, "Port            9200")
+	assert.NotContains(t, es, "HTTP_User")
+
+	es = createOutput(&components.LogOutput{Type: components.ElasticsearchOutput, Host: "es", Protocol: "https",
+		CredentialsSecret: "logs-credentials"})
+	assert.Contains(t, es, "tls             On")
+	assert.Contains(t, es, "HTTP_User       ${ES_USER}")
+	assert.Contains(t, es, "HTTP_Passwd     ${ES_PASSWORD}")
+}
+
+func Test_AddSidecar(t *testing.T) {
+	template := &corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "controller"}}}}
+
+	AddSidecar(template, prepareDeployment(nil))
+	assert.Len(t, template.Spec.Containers, 1)
+	assert.Len(t, template.Spec.Volumes, 0)
+
+	AddSidecar(template, prepareDeployment(&components.LogReceiver{Name: "fluent-bit"}))
+	assert.Len(t, template.Spec.Containers, 1)
+	assert.Len(t, template.Spec.Volumes, 0)
+
+	AddSidecar(template, prepareDeployment(&components.LogReceiver{
+		Enabled: true,
+		Name:    "fluent-bit",
+		Image:   &components.Image{Name: "fluent-bit", Tag: "1.9"},
+	}))
+	assert.Len(t, template.Spec.Containers, 2)
+	assert.Len(t, template.Spec.Volumes, 1)
+
+	sidecar := template.Spec.Containers[1]
+	assert.Equal(t, ContainerName, sidecar.Name)
+	assert.Equal(t, "asdrepo.isus.emc.com:9042/fluent-bit:1.9", sidecar.Image)
+	assert.Equal(t, constant.LogsVolume, sidecar.VolumeMounts[0].Name)
+	assert.Equal(t, "/var/log", sidecar.VolumeMounts[0].MountPath)
+	assert.Equal(t, "metadata.labels['"+constant.RsysLabelKey+"']", sidecar.Env[3].ValueFrom.FieldRef.FieldPath)
+	assert.Equal(t, ConfigMapName, template.Spec.Volumes[0].ConfigMap.Name)
+}
@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	reconcileSubsystem = "reconcile"
+
+	// components of csi Deployment reconcile
+	nodeControllerComponent = "nodeController"
+	controllerComponent     = "controller"
+	extenderComponent       = "extender"
+	patcherComponent        = "patcher"
+)
+
+var (
+	// reconcileDuration tracks duration of csi Deployment components update
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: constant.MetricsNamespace,
+		Subsystem: reconcileSubsystem,
+		Name:      "duration_seconds",
+		Help:      "Duration of csi Deployment component reconcile",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"component"})
+
+	// reconcileErrors counts failed updates of csi Deployment components, postponed updates are not counted
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: constant.MetricsNamespace,
+		Subsystem: reconcileSubsystem,
+		Name:      "errors_total",
+		Help:      "Number of failed csi Deployment component reconciles",
+	}, []string{"component"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(reconcileDuration, reconcileErrors)
+}
+
+// observeReconcile runs update of the component and records its duration and result
+func observeReconcile(component string, update func() error) error {
+	start := time.Now()
+	err := update()
+	reconcileDuration.WithLabelValues(component).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		if _, ok := common.IsRequeueError(err); !ok {
+			reconcileErrors.WithLabelValues(component).Inc()
+		}
+	}
+	return err
+}
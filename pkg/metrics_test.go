@@ -0,0 +1,32 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+)
+
+func Test_observeReconcile(t *testing.T) {
+	reconcileErrors.Reset()
+	reconcileDuration.Reset()
+
+	err := observeReconcile(controllerComponent, func() error { return nil })
+	assert.Nil(t, err)
+	assert.Equal(t, float64(0), testutil.ToFloat64(reconcileErrors.WithLabelValues(controllerComponent)))
+
+	err = observeReconcile(controllerComponent, func() error { return errors.New("error") })
+	assert.NotNil(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(reconcileErrors.WithLabelValues(controllerComponent)))
+
+	// postponed update is not an error
+	err = observeReconcile(patcherComponent, func() error { return common.NewRequeueError(time.Second, "waiting") })
+	assert.NotNil(t, err)
+	assert.Equal(t, float64(0), testutil.ToFloat64(reconcileErrors.WithLabelValues(patcherComponent)))
+
+	assert.Equal(t, 2, testutil.CollectAndCount(reconcileDuration))
+}
@@ -0,0 +1,339 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	monitoringGroupVersion = "monitoring.coreos.com/v1"
+	serviceMonitorKind     = "ServiceMonitor"
+	serviceMonitorResource = "servicemonitors"
+	metricsServiceSuffix   = "-metrics"
+	metricsPortName        = "metrics"
+
+	// nodeComponent is a component label of csi-baremetal-node pods
+	nodeComponent = "node"
+)
+
+var serviceMonitorGVK = schema.FromAPIVersionAndKind(monitoringGroupVersion, serviceMonitorKind)
+
+// Monitoring controls Services and ServiceMonitors of csi-baremetal components
+type Monitoring struct {
+	Clientset kubernetes.Interface
+	Client    client.Client
+	*logrus.Entry
+}
+
+// metricsTarget describes metrics endpoint of csi-baremetal component
+type metricsTarget struct {
+	component string
+	port      int32
+	path      string
+	// enabled is false if the component isn't deployed, its Service and ServiceMonitor are deleted
+	enabled bool
+}
+
+// Update creates Services and ServiceMonitors for csi-baremetal components if monitoring.coreos.com CRDs are installed
+func (m *Monitoring) Update(ctx context.Context, csi *csibaremetalv1.Deployment, scheme *runtime.Scheme) error {
+	available, err := m.isServiceMonitorAvailable()
+	if err != nil {
+		return err
+	}
+	if !available {
+		m.Debug("ServiceMonitor CRD is not installed, skip monitoring configuration")
+		return nil
+	}
+
+	var errMsgs []string
+	for _, target := range getMetricsTargets(csi) {
+		if !target.enabled {
+			if err = m.deleteMetricsTarget(ctx, csi, target); err != nil {
+				errMsgs = append(errMsgs, err.Error())
+			}
+			continue
+		}
+
+		service := createMetricsService(csi, target)
+		if err = controllerutil.SetControllerReference(csi, service, scheme); err != nil {
+			return err
+		}
+		if err = m.updateService(ctx, service); err != nil {
+			errMsgs = append(errMsgs, err.Error())
+			continue
+		}
+
+		serviceMonitor := createServiceMonitor(csi, target)
+		if err = controllerutil.SetControllerReference(csi, serviceMonitor, scheme); err != nil {
+			return err
+		}
+		if err = m.updateServiceMonitor(ctx, serviceMonitor); err != nil {
+			errMsgs = append(errMsgs, err.Error())
+		}
+	}
+
+	if len(errMsgs) != 0 {
+		return fmt.Errorf(strings.Join(errMsgs, "\n"))
+	}
+	return nil
+}
+
+// isServiceMonitorAvailable checks that ServiceMonitor resource is served by API server
+func (m *Monitoring) isServiceMonitorAvailable() (bool, error) {
+	resources, err := m.Clientset.Discovery().ServerResourcesForGroupVersion(monitoringGroupVersion)
+	if err != nil {
+		if k8sError.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Name == serviceMonitorResource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// updateService updates found service with ports and selector from expected, creates one if not found
+func (m *Monitoring) updateService(ctx context.Context, expected *corev1.Service) error {
+	svcClient := m.Clientset.CoreV1().Services(expected.Namespace)
+
+	found, err := svcClient.Get(ctx, expected.Name, metav1.GetOptions{})
+	if err != nil {
+		if k8sError.IsNotFound(err) {
+			if plan := common.GetPlan(ctx); plan != nil {
+				plan.Add(csibaremetalv1.PlannedCreate, "Service", expected.Namespace, expected.Name)
+				return nil
+			}
+			if _, err = svcClient.Create(ctx, expected, metav1.CreateOptions{}); err != nil {
+				m.Error(err, "Failed to create service "+expected.Name)
+				return err
+			}
+			m.Info("Service created successfully: " + expected.Name)
+			return nil
+		}
+		m.Error(err, "Failed to get service "+expected.Name)
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(found.Spec.Ports, expected.Spec.Ports) &&
+		equality.Semantic.DeepEqual(found.Spec.Selector, expected.Spec.Selector) &&
+		equality.Semantic.DeepEqual(found.Labels, expected.Labels) {
+		return nil
+	}
+
+	if plan := common.GetPlan(ctx); plan != nil {
+		plan.Add(csibaremetalv1.PlannedUpdate, "Service", expected.Namespace, expected.Name)
+		return nil
+	}
+
+	found.Labels = expected.Labels
+	found.Spec.Ports = expected.Spec.Ports
+	found.Spec.Selector = expected.Spec.Selector
+	if _, err = svcClient.Update(ctx, found, metav1.UpdateOptions{}); err != nil {
+		m.Error(err, "Failed to update service "+expected.Name)
+		return err
+	}
+	m.Info("Service updated successfully: " + expected.Name)
+	return nil
+}
+
+// updateServiceMonitor updates found ServiceMonitor with spec from expected, creates one if not found
+func (m *Monitoring) updateServiceMonitor(ctx context.Context, expected *unstructured.Unstructured) error {
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(serviceMonitorGVK)
+	err := m.Client.Get(ctx, client.ObjectKeyFromObject(expected), found)
+	if err != nil {
+		if k8sError.IsNotFound(err) {
+			if plan := common.GetPlan(ctx); plan != nil {
+				plan.Add(csibaremetalv1.PlannedCreate, serviceMonitorKind, expected.GetNamespace(), expected.GetName())
+				return nil
+			}
+			if err = m.Client.Create(ctx, expected); err != nil {
+				m.Error(err, "Failed to create ServiceMonitor "+expected.GetName())
+				return err
+			}
+			m.Info("ServiceMonitor created successfully: " + expected.GetName())
+			return nil
+		}
+		m.Error(err, "Failed to get ServiceMonitor "+expected.GetName())
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(found.Object["spec"], expected.Object["spec"]) {
+		return nil
+	}
+
+	if plan := common.GetPlan(ctx); plan != nil {
+		plan.Add(csibaremetalv1.PlannedUpdate, serviceMonitorKind, expected.GetNamespace(), expected.GetName())
+		return nil
+	}
+
+	found.Object["spec"] = expected.Object["spec"]
+	if err = m.Client.Update(ctx, found); err != nil {
+		m.Error(err, "Failed to update ServiceMonitor "+expected.GetName())
+		return err
+	}
+	m.Info("ServiceMonitor updated successfully: " + expected.GetName())
+	return nil
+}
+
+// deleteMetricsTarget deletes ServiceMonitor and Service of the component, if they are owned by csi Deployment
+func (m *Monitoring) deleteMetricsTarget(ctx context.Context, csi *csibaremetalv1.Deployment, target metricsTarget) error {
+	name := getMetricsServiceName(target.component)
+
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetGroupVersionKind(serviceMonitorGVK)
+	if err := m.Client.Get(ctx, client.ObjectKey{Name: name, Namespace: csi.GetNamespace()}, serviceMonitor); err != nil {
+		if !k8sError.IsNotFound(err) {
+			m.Error(err, "Failed to get ServiceMonitor "+name)
+			return err
+		}
+	} else if metav1.IsControlledBy(serviceMonitor, csi) {
+		if plan := common.GetPlan(ctx); plan != nil {
+			plan.Add(csibaremetalv1.PlannedDelete, serviceMonitorKind, csi.GetNamespace(), name)
+		} else if err = m.Client.Delete(ctx, serviceMonitor); err != nil && !k8sError.IsNotFound(err) {
+			m.Error(err, "Failed to delete ServiceMonitor "+name)
+			return err
+		} else {
+			m.Info("ServiceMonitor deleted successfully: " + name)
+		}
+	}
+
+	svcClient := m.Clientset.CoreV1().Services(csi.GetNamespace())
+	service, err := svcClient.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if k8sError.IsNotFound(err) {
+			return nil
+		}
+		m.Error(err, "Failed to get service "+name)
+		return err
+	}
+	if !metav1.IsControlledBy(service, csi) {
+		return nil
+	}
+
+	if plan := common.GetPlan(ctx); plan != nil {
+		plan.Add(csibaremetalv1.PlannedDelete, "Service", csi.GetNamespace(), name)
+		return nil
+	}
+	if err = svcClient.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !k8sError.IsNotFound(err) {
+		m.Error(err, "Failed to delete service "+name)
+		return err
+	}
+	m.Info("Service deleted successfully: " + name)
+	return nil
+}
+
+// getMetricsTargets returns metrics endpoints of csi-baremetal components, extender and node-controller
+// targets are disabled with their components
+func getMetricsTargets(csi *csibaremetalv1.Deployment) []metricsTarget {
+	return []metricsTarget{
+		{
+			component: controller,
+			port:      common.MetricsPort(csi.Spec.Driver.Metrics),
+			path:      common.MetricsPath(csi.Spec.Driver.Metrics),
+			enabled:   true,
+		},
+		{
+			component: nodeComponent,
+			port:      common.MetricsPort(csi.Spec.Driver.Metrics),
+			path:      common.MetricsPath(csi.Spec.Driver.Metrics),
+			enabled:   true,
+		},
+		{
+			component: extender,
+			port:      common.MetricsPort(csi.Spec.Scheduler.Metrics),
+			path:      common.MetricsPath(csi.Spec.Scheduler.Metrics),
+			enabled:   csi.Spec.Scheduler.Enable,
+		},
+		{
+			component: nodeController,
+			port:      getNodeControllerMetricsPort(csi),
+			path:      constant.PrometheusPath,
+			enabled:   csi.Spec.NodeController != nil && csi.Spec.NodeController.Enable,
+		},
+	}
+}
+
+func getMetricsServiceName(component string) string {
+	return constant.CSIName + "-" + component + metricsServiceSuffix
+}
+
+func getMetricsLabels(component string) map[string]string {
+	labels := common.ConstructLabelAppMap()
+	labels[constant.ComponentLabelKey] = component
+	return labels
+}
+
+// createMetricsService creates headless service, which selects all pods of the component
+func createMetricsService(csi *csibaremetalv1.Deployment, target metricsTarget) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getMetricsServiceName(target.component),
+			Namespace: csi.GetNamespace(),
+			Labels:    getMetricsLabels(target.component),
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Selector:  getMetricsLabels(target.component),
+			Ports: []corev1.ServicePort{
+				{
+					Name:       metricsPortName,
+					Port:       target.port,
+					TargetPort: intstr.FromInt32(target.port),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
+
+func createServiceMonitor(csi *csibaremetalv1.Deployment, target metricsTarget) *unstructured.Unstructured {
+	labels := map[string]interface{}{}
+	for key, value := range getMetricsLabels(target.component) {
+		labels[key] = value
+	}
+
+	serviceMonitor := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": labels,
+			},
+			"namespaceSelector": map[string]interface{}{
+				"matchNames": []interface{}{csi.GetNamespace()},
+			},
+			"endpoints": []interface{}{
+				map[string]interface{}{
+					"port": metricsPortName,
+					"path": target.path,
+				},
+			},
+		},
+	}}
+	serviceMonitor.SetGroupVersionKind(serviceMonitorGVK)
+	serviceMonitor.SetName(getMetricsServiceName(target.component))
+	serviceMonitor.SetNamespace(csi.GetNamespace())
+	serviceMonitor.SetLabels(getMetricsLabels(target.component))
+	return serviceMonitor
+}
@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+var testMonitoringDeployment = &v1.Deployment{
+	TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "csi-baremetal.dell.com/v1"},
+	ObjectMeta: metav1.ObjectMeta{Name: "test-deployment", Namespace: "test-csi", UID: "test-uid"},
+	Spec: components.DeploymentSpec{
+		Driver:         &components.Driver{Metrics: &components.Metrics{Path: "/custom", Port: 9000}},
+		Scheduler:      &components.Scheduler{Enable: true},
+		NodeController: &components.NodeController{Enable: true},
+	},
+}
+
+func Test_MonitoringUpdate(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		deployment = testMonitoringDeployment.DeepCopy()
+	)
+
+	scheme, _ := common.PrepareScheme()
+	scheme.AddKnownTypeWithName(serviceMonitorGVK, &unstructured.Unstructured{})
+	clientSet := fake.NewSimpleClientset()
+	m := &Monitoring{
+		Clientset: clientSet,
+		Client:    prepareSnapshotClient(scheme, deployment),
+		Entry:     logrus.New().WithField(constant.CSIName, "monitoring"),
+	}
+
+	// monitoring CRDs are not installed
+	err := m.Update(ctx, deployment, scheme)
+	assert.Nil(t, err)
+	services, err := clientSet.CoreV1().Services(deployment.Namespace).List(ctx, metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, services.Items, 0)
+
+	fakeDiscovery, ok := clientSet.Discovery().(*fakediscovery.FakeDiscovery)
+	if !ok {
+		t.Fatalf("couldn't convert Discovery() to *FakeDiscovery")
+	}
+	fakeDiscovery.Resources = append(fakeDiscovery.Resources, &metav1.APIResourceList{
+		GroupVersion: monitoringGroupVersion,
+		APIResources: []metav1.APIResource{{Name: serviceMonitorResource}},
+	})
+
+	err = m.Update(ctx, deployment, scheme)
+	assert.Nil(t, err)
+	services, err = clientSet.CoreV1().Services(deployment.Namespace).List(ctx, metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, services.Items, 4)
+
+	service, err := clientSet.CoreV1().Services(deployment.Namespace).Get(ctx, getMetricsServiceName(controller), metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(9000), service.Spec.Ports[0].Port)
+	assert.Equal(t, controller, service.Spec.Selector[constant.ComponentLabelKey])
+
+	service, err = clientSet.CoreV1().Services(deployment.Namespace).Get(ctx, getMetricsServiceName(extender), metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(constant.PrometheusPort), service.Spec.Ports[0].Port)
+
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetGroupVersionKind(serviceMonitorGVK)
+	key := client.ObjectKey{Name: getMetricsServiceName(nodeComponent), Namespace: deployment.Namespace}
+	err = m.Client.Get(ctx, key, serviceMonitor)
+	assert.Nil(t, err)
+	endpoints, _, _ := unstructured.NestedSlice(serviceMonitor.Object, "spec", "endpoints")
+	assert.Equal(t, "/custom", endpoints[0].(map[string]interface{})["path"])
+
+	// metrics configuration is changed
+	deployment.Spec.Driver = &components.Driver{Metrics: &components.Metrics{Path: "/metrics", Port: 9100}}
+	err = m.Update(ctx, deployment, scheme)
+	assert.Nil(t, err)
+	service, err = clientSet.CoreV1().Services(deployment.Namespace).Get(ctx, getMetricsServiceName(nodeComponent), metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(9100), service.Spec.Ports[0].Port)
+	err = m.Client.Get(ctx, key, serviceMonitor)
+	assert.Nil(t, err)
+	endpoints, _, _ = unstructured.NestedSlice(serviceMonitor.Object, "spec", "endpoints")
+	assert.Equal(t, "/metrics", endpoints[0].(map[string]interface{})["path"])
+
+	// extender and node-controller are disabled
+	deployment.Spec.Scheduler.Enable = false
+	deployment.Spec.NodeController.Enable = false
+	err = m.Update(ctx, deployment, scheme)
+	assert.Nil(t, err)
+	services, err = clientSet.CoreV1().Services(deployment.Namespace).List(ctx, metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, services.Items, 2)
+	for _, component := range []string{extender, nodeController} {
+		_, err = clientSet.CoreV1().Services(deployment.Namespace).Get(ctx, getMetricsServiceName(component), metav1.GetOptions{})
+		assert.True(t, k8sError.IsNotFound(err))
+		err = m.Client.Get(ctx, client.ObjectKey{Name: getMetricsServiceName(component), Namespace: deployment.Namespace}, serviceMonitor)
+		assert.True(t, k8sError.IsNotFound(err))
+	}
+}
@@ -0,0 +1,184 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+)
+
+const (
+	// driveMgrGroupLabel is set on nodes to select node DaemonSet with drive manager of the group
+	driveMgrGroupLabel = "nodes.csi-baremetal.dell.com/drivemgr-group"
+	// defaultDriveMgrGroup is a value of drivemgr-group label for nodes, which don't match any group
+	defaultDriveMgrGroup = "default"
+)
+
+// getDriveMgrNodeGroups returns drive manager node groups of csi Deployment
+func getDriveMgrNodeGroups(csi *csibaremetalv1.Deployment) []components.DriveMgrNodeGroup {
+	if csi.Spec.Driver == nil || csi.Spec.Driver.Node == nil || csi.Spec.Driver.Node.DriveMgr == nil {
+		return nil
+	}
+	return csi.Spec.Driver.Node.DriveMgr.NodeGroups
+}
+
+// driveMgrGroupName returns value of drivemgr-group label for the group
+func driveMgrGroupName(group *components.DriveMgrNodeGroup) string {
+	if group == nil {
+		return defaultDriveMgrGroup
+	}
+	return group.Name
+}
+
+// driveMgrGroupDaemonsetName constructs name of node DaemonSet, name of the default group isn't added
+func driveMgrGroupDaemonsetName(platform *PlatformDescription, group *components.DriveMgrNodeGroup) string {
+	if group == nil {
+		return platform.DaemonsetName(nodeName)
+	}
+	return createNameWithTag(platform.DaemonsetName(nodeName), group.Name)
+}
+
+// validateDriveMgrNodeGroups checks that group names are unique and don't clash with the default group
+func validateDriveMgrNodeGroups(groups []components.DriveMgrNodeGroup) error {
+	var errMsgs []string
+
+	names := map[string]bool{}
+	for _, group := range groups {
+		switch {
+		case group.Name == defaultDriveMgrGroup:
+			errMsgs = append(errMsgs, fmt.Sprintf("drive manager node group name %s is reserved", group.Name))
+		case names[group.Name]:
+			errMsgs = append(errMsgs, fmt.Sprintf("drive manager node group %s is configured more than once", group.Name))
+		case len(group.NodeSelector) == 0:
+			errMsgs = append(errMsgs, fmt.Sprintf("drive manager node group %s has empty node selector", group.Name))
+		}
+		names[group.Name] = true
+	}
+
+	if len(errMsgs) != 0 {
+		return fmt.Errorf(strings.Join(errMsgs, "\n"))
+	}
+	return nil
+}
+
+// matchDriveMgrGroup returns name of the first group, which selects node labels
+func matchDriveMgrGroup(groups []components.DriveMgrNodeGroup, nodeLabels map[string]string) string {
+	for _, group := range groups {
+		if labels.SelectorFromSet(group.NodeSelector).Matches(labels.Set(nodeLabels)) {
+			return group.Name
+		}
+	}
+	return defaultDriveMgrGroup
+}
+
+// updateDriveMgrGroupLabels assigns each selected node to drive manager node group with drivemgr-group label,
+// label is removed if node groups are not configured. Returns a Set of groups, which will be deployed
+func (n *Node) updateDriveMgrGroupLabels(ctx context.Context, csi *csibaremetalv1.Deployment) (Set, error) {
+	var (
+		groups = getDriveMgrNodeGroups(csi)
+		// default daemonset is updated even without nodes to stop its pods on nodes moved to groups
+		needToDeploy = Set{defaultDriveMgrGroup: true}
+		resultErr    error
+	)
+
+	if err := validateDriveMgrNodeGroups(groups); err != nil {
+		return needToDeploy, err
+	}
+
+	nodes, err := common.GetSelectedNodes(ctx, n.clientset, csi.Spec.NodeSelector)
+	if err != nil {
+		return needToDeploy, err
+	}
+
+	for i, node := range nodes.Items {
+		value, labeled := node.Labels[driveMgrGroupLabel]
+
+		var expected string
+		if len(groups) != 0 {
+			expected = matchDriveMgrGroup(groups, node.Labels)
+			needToDeploy[expected] = true
+			if labeled && value == expected {
+				continue
+			}
+		} else if !labeled {
+			continue
+		}
+
+		if plan := common.GetPlan(ctx); plan != nil {
+			plan.Add(csibaremetalv1.PlannedUpdate, "Node", "", node.Name, fmt.Sprintf("label %s: %q -> %q",
+				driveMgrGroupLabel, value, expected))
+			continue
+		}
+
+		if expected == "" {
+			delete(node.Labels, driveMgrGroupLabel)
+		} else {
+			node.Labels[driveMgrGroupLabel] = expected
+		}
+		if _, err := n.clientset.CoreV1().Nodes().Update(ctx, &nodes.Items[i], metav1.UpdateOptions{}); err != nil {
+			n.log.Error(err, "Failed to update drive manager group label on "+node.Name)
+			resultErr = err
+		}
+	}
+
+	return needToDeploy, resultErr
+}
+
+// getDeployedDriveMgrGroups returns drive manager node groups from the Set, nil means nodes which don't match any group
+func getDeployedDriveMgrGroups(csi *csibaremetalv1.Deployment, needToDeploy Set) []*components.DriveMgrNodeGroup {
+	var result []*components.DriveMgrNodeGroup
+	if needToDeploy[defaultDriveMgrGroup] {
+		result = append(result, nil)
+	}
+	groups := getDriveMgrNodeGroups(csi)
+	for i := range groups {
+		if needToDeploy[groups[i].Name] {
+			result = append(result, &groups[i])
+		}
+	}
+	return result
+}
+
+// deleteObsoleteDriveMgrDaemonSets deletes node DaemonSets of csi Deployment, which select nodes of removed
+// or empty drive manager node groups
+func (n *Node) deleteObsoleteDriveMgrDaemonSets(ctx context.Context, csi *csibaremetalv1.Deployment, needToDeploy Set) error {
+	dsClient := n.clientset.AppsV1().DaemonSets(csi.GetNamespace())
+	daemonSets, err := dsClient.List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(common.ConstructLabelAppMap()).String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	var errMsgs []string
+	for _, ds := range daemonSets.Items {
+		group, ok := ds.Spec.Template.Spec.NodeSelector[driveMgrGroupLabel]
+		if !ok || group == defaultDriveMgrGroup || needToDeploy[group] || !metav1.IsControlledBy(&ds, csi) {
+			continue
+		}
+
+		if plan := common.GetPlan(ctx); plan != nil {
+			plan.Add(csibaremetalv1.PlannedDelete, "DaemonSet", ds.Namespace, ds.Name)
+			continue
+		}
+
+		if err = dsClient.Delete(ctx, ds.Name, metav1.DeleteOptions{}); err != nil && !apiErrors.IsNotFound(err) {
+			n.log.Error(err, "Failed to delete daemonset "+ds.Name)
+			errMsgs = append(errMsgs, err.Error())
+			continue
+		}
+		n.log.Info("Daemonset of drive manager node group " + group + " deleted: " + ds.Name)
+	}
+
+	if len(errMsgs) != 0 {
+		return fmt.Errorf(strings.Join(errMsgs, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+)
+
+var idracGroup = components.DriveMgrNodeGroup{
+	Name:         "idrac",
+	NodeSelector: map[string]string{"rack": "idrac"},
+	Type:         components.DriveMgrTypeIDRAC,
+	Image:        &components.Image{Name: "csi-baremetal-idracmgr"},
+}
+
+func prepareDriveMgrGroupsDeployment(groups ...components.DriveMgrNodeGroup) *v1.Deployment {
+	csi := prepareNodeConfigDeployment(nil)
+	csi.UID = "test-uid"
+	csi.Spec.Driver.Node.DriveMgr = &components.DriveMgr{
+		Image:      &components.Image{Name: "csi-baremetal-basemgr"},
+		Endpoint:   "tcp://localhost:8888",
+		NodeGroups: groups,
+	}
+	csi.Spec.Driver.Node.Image = &components.Image{Name: "csi-baremetal-node"}
+	csi.Spec.Driver.Node.Log = &components.Log{Level: "info"}
+	csi.Spec.Driver.Node.Sidecars = map[string]*components.Sidecar{
+		"livenessprobe":             {Image: &components.Image{Name: "livenessprobe"}},
+		"csi-node-driver-registrar": {Image: &components.Image{Name: "csi-node-driver-registrar"}},
+	}
+	return csi
+}
+
+func Test_updateDriveMgrGroupLabels(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		node1 = testNode1.DeepCopy()
+		node2 = testNode2.DeepCopy()
+	)
+	node1.Labels = map[string]string{"rack": "idrac"}
+
+	node := &Node{clientset: prepareNodeClientSet(node1, node2), log: logEntry}
+
+	t.Run("Nodes are assigned to groups", func(t *testing.T) {
+		needToDeploy, err := node.updateDriveMgrGroupLabels(ctx, prepareDriveMgrGroupsDeployment(idracGroup))
+		assert.Nil(t, err)
+		assert.Equal(t, Set{defaultDriveMgrGroup: true, "idrac": true}, needToDeploy)
+
+		updatedNode, err := node.clientset.CoreV1().Nodes().Get(ctx, node1.Name, metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, "idrac", updatedNode.Labels[driveMgrGroupLabel])
+
+		updatedNode, err = node.clientset.CoreV1().Nodes().Get(ctx, node2.Name, metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, defaultDriveMgrGroup, updatedNode.Labels[driveMgrGroupLabel])
+	})
+
+	t.Run("Labels are removed without groups", func(t *testing.T) {
+		needToDeploy, err := node.updateDriveMgrGroupLabels(ctx, prepareDriveMgrGroupsDeployment())
+		assert.Nil(t, err)
+		assert.Equal(t, Set{defaultDriveMgrGroup: true}, needToDeploy)
+
+		nodes, err := node.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		assert.Nil(t, err)
+		for _, n := range nodes.Items {
+			assert.NotContains(t, n.Labels, driveMgrGroupLabel)
+		}
+	})
+
+	t.Run("Invalid groups are rejected", func(t *testing.T) {
+		reserved := idracGroup
+		reserved.Name = defaultDriveMgrGroup
+
+		_, err := node.updateDriveMgrGroupLabels(ctx, prepareDriveMgrGroupsDeployment(idracGroup, idracGroup, reserved))
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "drive manager node group idrac is configured more than once")
+		assert.Contains(t, err.Error(), "drive manager node group name default is reserved")
+	})
+}
+
+func Test_createNodeDaemonSet_DriveMgrGroups(t *testing.T) {
+	csi := prepareDriveMgrGroupsDeployment(idracGroup)
+
+	t.Run("Nodes out of groups", func(t *testing.T) {
+		daemonSet := createNodeDaemonSet(csi, platforms["kernel-5.4"], nil)
+
+		assert.Equal(t, "csi-baremetal-node-kernel-5.4", daemonSet.Name)
+		assert.Equal(t, defaultDriveMgrGroup, daemonSet.Spec.Template.Spec.NodeSelector[driveMgrGroupLabel])
+		assert.Equal(t, "csi-baremetal-basemgr", findContainer(daemonSet, driveMgrContainerName).Image)
+	})
+
+	t.Run("Nodes of group", func(t *testing.T) {
+		daemonSet := createNodeDaemonSet(csi, platforms["kernel-5.4"], &csi.Spec.Driver.Node.DriveMgr.NodeGroups[0])
+
+		assert.Equal(t, "csi-baremetal-node-kernel-5.4-idrac", daemonSet.Name)
+		assert.Equal(t, "idrac", daemonSet.Spec.Template.Spec.NodeSelector[driveMgrGroupLabel])
+		assert.Equal(t, "csi-baremetal-idracmgr", findContainer(daemonSet, driveMgrContainerName).Image)
+		charDevice := coreV1.HostPathCharDev
+		assert.Contains(t, daemonSet.Spec.Template.Spec.Volumes, coreV1.Volume{Name: hostIPMIVolume, VolumeSource: coreV1.VolumeSource{
+			HostPath: &coreV1.HostPathVolumeSource{Path: "/dev/ipmi0", Type: &charDevice},
+		}})
+	})
+
+	t.Run("Node selector isn't changed without groups", func(t *testing.T) {
+		daemonSet := createNodeDaemonSet(prepareDriveMgrGroupsDeployment(), platforms["default"], nil)
+
+		assert.Equal(t, "csi-baremetal-node", daemonSet.Name)
+		assert.NotContains(t, daemonSet.Spec.Template.Spec.NodeSelector, driveMgrGroupLabel)
+	})
+}
+
+func Test_deleteObsoleteDriveMgrDaemonSets(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		csi       = prepareDriveMgrGroupsDeployment(idracGroup)
+		scheme, _ = common.PrepareScheme()
+	)
+
+	newDaemonSet := func(name, group string, owned bool) *appsv1.DaemonSet {
+		ds := &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNS, Labels: common.ConstructLabelAppMap()},
+			Spec: appsv1.DaemonSetSpec{Template: coreV1.PodTemplateSpec{Spec: coreV1.PodSpec{
+				NodeSelector: map[string]string{driveMgrGroupLabel: group},
+			}}},
+		}
+		if owned {
+			assert.Nil(t, controllerutil.SetControllerReference(csi, ds, scheme))
+		}
+		return ds
+	}
+
+	node := &Node{clientset: prepareNodeClientSet(
+		newDaemonSet("csi-baremetal-node", defaultDriveMgrGroup, true),
+		newDaemonSet("csi-baremetal-node-idrac", "idrac", true),
+		newDaemonSet("csi-baremetal-node-raid", "raid", true),
+		newDaemonSet("another-node-raid", "raid", false),
+	), log: logEntry}
+
+	assert.Nil(t, node.deleteObsoleteDriveMgrDaemonSets(ctx, csi, Set{defaultDriveMgrGroup: true, "idrac": true}))
+
+	daemonSets, err := node.clientset.AppsV1().DaemonSets(testNS).List(ctx, metav1.ListOptions{})
+	assert.Nil(t, err)
+	var names []string
+	for _, ds := range daemonSets.Items {
+		names = append(names, ds.Name)
+	}
+	assert.ElementsMatch(t, []string{"csi-baremetal-node", "csi-baremetal-node-idrac", "another-node-raid"}, names)
+}
+
+func findContainer(ds *appsv1.DaemonSet, name string) *coreV1.Container {
+	for i, container := range ds.Spec.Template.Spec.Containers {
+		if container.Name == name {
+			return &ds.Spec.Template.Spec.Containers[i]
+		}
+	}
+	return nil
+}
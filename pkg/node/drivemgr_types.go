@@ -0,0 +1,237 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+// driveMgrType describes what drive manager of the type needs from node pod in addition
+// to /dev, /host/home and crash mounts, loglevel and endpoint args, which are common for all types
+type driveMgrType struct {
+	// volumes are added to node pod
+	volumes func(csi *csibaremetalv1.Deployment) []corev1.Volume
+	// mounts are added to drivemgr container, they refer to volumes above or common node volumes
+	mounts []corev1.VolumeMount
+	// args are added to drivemgr container
+	args func(csi *csibaremetalv1.Deployment) []string
+	// env is added to drivemgr container
+	env []corev1.EnvVar
+	// securityContext of drivemgr container
+	securityContext *corev1.SecurityContext
+}
+
+// DriveMgrTypeMismatchReason is set for DriveMgrTypeMatched condition when image name contains another type
+const DriveMgrTypeMismatchReason = "DriveMgrTypeMismatch"
+
+var (
+	// imageDriveMgrTypes are searched in image names in this order to warn about drive managers,
+	// which type isn't set since csi Deployment was created before types were introduced
+	imageDriveMgrTypes = []components.DriveMgrType{
+		components.DriveMgrTypeLoopback,
+		components.DriveMgrTypeIDRAC,
+		components.DriveMgrTypeHAL,
+		components.DriveMgrTypeBase,
+	}
+
+	// driveMgrTypes is a registry of supported drive managers, new type has to be added to DriveMgrType enum as well
+	driveMgrTypes = map[components.DriveMgrType]*driveMgrType{
+		components.DriveMgrTypeBase: {
+			securityContext: &corev1.SecurityContext{Privileged: ptr.To(true)},
+		},
+		// idracmgr reads BMC address with ipmitool
+		components.DriveMgrTypeIDRAC: {
+			volumes: func(_ *csibaremetalv1.Deployment) []corev1.Volume {
+				charDevice := corev1.HostPathCharDev
+				return []corev1.Volume{{Name: hostIPMIVolume, VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{Path: "/dev/ipmi0", Type: &charDevice},
+				}}}
+			},
+			mounts:          []corev1.VolumeMount{{Name: hostIPMIVolume, MountPath: "/dev/ipmi0"}},
+			securityContext: &corev1.SecurityContext{Privileged: ptr.To(true)},
+		},
+		// halmgr discovers drives of enclosures via sysfs and udev database
+		components.DriveMgrTypeHAL: {
+			mounts: []corev1.VolumeMount{
+				{Name: hostSysVolume, MountPath: "/sys", ReadOnly: true},
+				{Name: hostRunUdevVolume, MountPath: "/run/udev", ReadOnly: true},
+			},
+			securityContext: &corev1.SecurityContext{Privileged: ptr.To(true)},
+		},
+		components.DriveMgrTypeLoopback: {
+			volumes: func(_ *csibaremetalv1.Deployment) []corev1.Volume {
+				configMapMode := corev1.ConfigMapVolumeSourceDefaultMode
+				return []corev1.Volume{{
+					Name: driveConfigVolume,
+					VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{
+							LocalObjectReference: corev1.LocalObjectReference{Name: loopbackManagerConfigName},
+							DefaultMode:          &configMapMode,
+							Optional:             ptr.To(true),
+						},
+					}}}
+			},
+			mounts: []corev1.VolumeMount{{Name: driveConfigVolume, MountPath: "/etc/config"}},
+			args: func(csi *csibaremetalv1.Deployment) []string {
+				return []string{"--usenodeannotation=" + strconv.FormatBool(csi.Spec.NodeIDAnnotation)}
+			},
+			securityContext: &corev1.SecurityContext{Privileged: ptr.To(true)},
+		},
+	}
+)
+
+// getDriveMgrType returns type of drive manager, basemgr is used if type isn't set
+func getDriveMgrType(driveMgr *components.DriveMgr) components.DriveMgrType {
+	if driveMgr == nil || driveMgr.Type == "" {
+		return components.DriveMgrTypeBase
+	}
+	return driveMgr.Type
+}
+
+// lookupDriveMgrType returns registered description of drive manager type, basemgr is used for unknown types
+func lookupDriveMgrType(driveMgr *components.DriveMgr) *driveMgrType {
+	if mgrType, ok := driveMgrTypes[getDriveMgrType(driveMgr)]; ok {
+		return mgrType
+	}
+	return driveMgrTypes[components.DriveMgrTypeBase]
+}
+
+// usesDriveMgrType checks if drive manager of csi Deployment or one of its node groups has the type.
+// Only Type fields are checked, image names never select drive manager type
+func usesDriveMgrType(csi *csibaremetalv1.Deployment, name components.DriveMgrType) bool {
+	driveMgr := csi.Spec.Driver.Node.DriveMgr
+	if driveMgr == nil {
+		return false
+	}
+	// node groups without type use type of the drive manager
+	if getDriveMgrType(driveMgr) == name {
+		return true
+	}
+	for i := range driveMgr.NodeGroups {
+		if driveMgr.NodeGroups[i].Type == name {
+			return true
+		}
+	}
+	return false
+}
+
+// getDriveMgrTypeMismatches returns descriptions of drive managers, which image names contain another type
+// than configured one. Type is never detected by image name, mismatches are only reported
+func getDriveMgrTypeMismatches(csi *csibaremetalv1.Deployment) []string {
+	driveMgr := csi.Spec.Driver.Node.DriveMgr
+	if driveMgr == nil {
+		return nil
+	}
+
+	var mismatches []string
+	check := func(name string, driveMgr *components.DriveMgr) {
+		if driveMgr.Image == nil {
+			return
+		}
+		for _, imageType := range imageDriveMgrTypes {
+			if !strings.Contains(driveMgr.Image.Name, string(imageType)) {
+				continue
+			}
+			if mgrType := getDriveMgrType(driveMgr); mgrType != imageType {
+				mismatches = append(mismatches, fmt.Sprintf("%s uses image %s with type %s, set type %s if it's expected",
+					name, driveMgr.Image.Name, mgrType, imageType))
+			}
+			return
+		}
+	}
+
+	check("drive manager", driveMgr)
+	for i := range driveMgr.NodeGroups {
+		group := &driveMgr.NodeGroups[i]
+		// group without overrides is the same as drive manager above
+		if group.Type == "" && group.Image == nil {
+			continue
+		}
+		check("drive manager of node group "+group.Name, groupDriveMgr(driveMgr, group))
+	}
+	return mismatches
+}
+
+// checkDriveMgrTypes reports drive managers, which image names contain another type, in DriveMgrTypeMatched condition
+func (n *Node) checkDriveMgrTypes(ctx context.Context, csi *csibaremetalv1.Deployment) error {
+	mismatches := getDriveMgrTypeMismatches(csi)
+	if len(mismatches) == 0 {
+		return common.RemoveStatusCondition(ctx, n.client, csi, csibaremetalv1.DriveMgrTypeMatchedCondition)
+	}
+
+	message := strings.Join(mismatches, "; ")
+	n.log.Warnf("Drive manager types don't match images: %s", message)
+	return common.UpdateStatusCondition(ctx, n.client, csi, metav1.Condition{
+		Type:    csibaremetalv1.DriveMgrTypeMatchedCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  DriveMgrTypeMismatchReason,
+		Message: message,
+	})
+}
+
+// groupDriveMgr returns drive manager of the node group, nil group means nodes which don't match any group
+func groupDriveMgr(driveMgr *components.DriveMgr, group *components.DriveMgrNodeGroup) *components.DriveMgr {
+	if group == nil {
+		return driveMgr
+	}
+	result := *driveMgr
+	result.NodeGroups = nil
+	if group.Type != "" {
+		result.Type = group.Type
+	}
+	if group.Image != nil {
+		result.Image = group.Image
+	}
+	return &result
+}
+
+// createDriveMgrContainer creates drivemgr container with volumes, args and privileges of its type
+func createDriveMgrContainer(csi *csibaremetalv1.Deployment, driveMgr *components.DriveMgr) corev1.Container {
+	var (
+		node    = csi.Spec.Driver.Node
+		mgrType = lookupDriveMgrType(driveMgr)
+	)
+	args := []string{
+		constant.LogLevelSlogan + common.MatchLogLevel(node.Log.Level),
+		"--drivemgrendpoint=" + driveMgr.Endpoint,
+	}
+	if mgrType.args != nil {
+		args = append(args, mgrType.args(csi)...)
+	}
+	mounts := []corev1.VolumeMount{
+		{Name: hostDevVolume, MountPath: "/dev"},
+		{Name: hostHomeVolume, MountPath: "/host/home"},
+		constant.CrashMountVolume,
+	}
+	mounts = append(mounts, mgrType.mounts...)
+	env := []corev1.EnvVar{
+		{Name: "LOG_FORMAT", Value: common.MatchLogFormat(node.Log.Format)},
+		{Name: "KUBE_NODE_NAME", ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "spec.nodeName"},
+		}},
+	}
+	env = append(env, mgrType.env...)
+
+	return corev1.Container{
+		Name:                     driveMgrContainerName,
+		Image:                    common.ConstructFullImageName(driveMgr.Image, csi),
+		ImagePullPolicy:          corev1.PullPolicy(csi.Spec.PullPolicy),
+		Args:                     args,
+		Env:                      env,
+		SecurityContext:          mgrType.securityContext.DeepCopy(),
+		VolumeMounts:             mounts,
+		TerminationMessagePath:   constant.TerminationMessagePath,
+		TerminationMessagePolicy: constant.TerminationMessagePolicy,
+		Resources:                common.ConstructResourceRequirements(driveMgr.Resources),
+	}
+}
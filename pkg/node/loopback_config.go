@@ -0,0 +1,210 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+)
+
+const (
+	loopbackConfigKey = "config.yaml"
+	// defaultLoopbackDriveCount is used by loopbackmgr if drive count isn't set
+	defaultLoopbackDriveCount = 3
+
+	// LoopbackConfigAppliedReason is set for LoopbackConfigValid condition when loopback-config is updated
+	LoopbackConfigAppliedReason = "LoopbackConfigApplied"
+	// InvalidLoopbackConfigReason is set for LoopbackConfigValid condition when loopback settings are rejected
+	InvalidLoopbackConfigReason = "InvalidLoopbackConfig"
+)
+
+// loopbackConfig is a copy of loopbackmgr Config
+type loopbackConfig struct {
+	DefaultDriveCount int                   `yaml:"defaultDrivePerNodeCount"`
+	DefaultDriveSize  string                `yaml:"defaultDriveSize,omitempty"`
+	Nodes             []*loopbackNodeConfig `yaml:"nodes,omitempty"`
+}
+
+// loopbackNodeConfig is a copy of loopbackmgr Node
+type loopbackNodeConfig struct {
+	NodeID     string            `yaml:"nodeID"`
+	DriveCount int               `yaml:"driveCount,omitempty"`
+	Drives     []*loopbackDevice `yaml:"drives,omitempty"`
+}
+
+// loopbackDevice is a copy of loopbackmgr LoopBackDevice, other fields are filled with defaults by loopbackmgr
+type loopbackDevice struct {
+	SerialNumber string `yaml:"serialNumber"`
+	Size         string `yaml:"size,omitempty"`
+	DriveType    string `yaml:"driveType,omitempty"`
+}
+
+// updateLoopbackConfig validates loopback settings of csi Deployment, creates or updates loopback-config ConfigMap
+// and reports LoopbackConfigValid condition. loopback-config isn't managed if loopback settings are not set
+// or neither drive manager nor its node groups have loopbackmgr type.
+// Returns error if settings are invalid and loopback-config wasn't updated
+func (n *Node) updateLoopbackConfig(ctx context.Context, csi *csibaremetalv1.Deployment, scheme *runtime.Scheme) error {
+	driveMgr := csi.Spec.Driver.Node.DriveMgr
+	if !usesDriveMgrType(csi, components.DriveMgrTypeLoopback) || driveMgr.Loopback == nil {
+		return common.RemoveStatusCondition(ctx, n.client, csi, csibaremetalv1.LoopbackConfigValidCondition)
+	}
+
+	warnings, err := n.validateLoopbackConfig(ctx, driveMgr.Loopback)
+	if err != nil {
+		n.log.Warnf("loopback-config is invalid: %s", err.Error())
+		if condErr := common.UpdateStatusCondition(ctx, n.client, csi, metav1.Condition{
+			Type:    csibaremetalv1.LoopbackConfigValidCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  InvalidLoopbackConfigReason,
+			Message: err.Error(),
+		}); condErr != nil {
+			return condErr
+		}
+		return fmt.Errorf("loopback-config is invalid: %s", err.Error())
+	}
+
+	message := "loopback-config is updated"
+	if len(warnings) != 0 {
+		n.log.Warnf("loopback-config is applied with warnings: %s", strings.Join(warnings, "; "))
+		message += ", warnings: " + strings.Join(warnings, "; ")
+	}
+	if err = common.UpdateStatusCondition(ctx, n.client, csi, metav1.Condition{
+		Type:    csibaremetalv1.LoopbackConfigValidCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  LoopbackConfigAppliedReason,
+		Message: message,
+	}); err != nil {
+		return err
+	}
+
+	expected := createLoopbackConfigMap(csi)
+	if err = controllerutil.SetControllerReference(csi, expected, scheme); err != nil {
+		return err
+	}
+
+	return common.UpdateConfigMap(ctx, n.clientset, expected, n.log)
+}
+
+// validateLoopbackConfig returns error if drive sizes can't be parsed or nodes are duplicated
+// and warnings for nodes, which are not found in cluster
+func (n *Node) validateLoopbackConfig(ctx context.Context, loopback *components.Loopback) ([]string, error) {
+	var (
+		errMsgs  []string
+		warnings []string
+	)
+
+	if err := validateLoopbackDriveSize(loopback.DriveSize); err != nil {
+		errMsgs = append(errMsgs, err.Error())
+	}
+
+	if len(loopback.Nodes) != 0 {
+		nodes, err := n.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		names := map[string]bool{}
+		for _, node := range nodes.Items {
+			names[node.Name] = true
+		}
+
+		configured := map[string]bool{}
+		for _, node := range loopback.Nodes {
+			if configured[node.Name] {
+				errMsgs = append(errMsgs, fmt.Sprintf("node %s is configured more than once", node.Name))
+				continue
+			}
+			configured[node.Name] = true
+
+			if err = validateLoopbackDriveSize(node.DriveSize); err != nil {
+				errMsgs = append(errMsgs, fmt.Sprintf("node %s: %s", node.Name, err.Error()))
+			}
+			if !names[node.Name] {
+				warnings = append(warnings, fmt.Sprintf("node %s is not found", node.Name))
+			}
+		}
+	}
+
+	if len(errMsgs) != 0 {
+		return warnings, fmt.Errorf(strings.Join(errMsgs, "\n"))
+	}
+	return warnings, nil
+}
+
+// validateLoopbackDriveSize checks that size is a positive quantity, empty size is replaced by loopbackmgr default
+func validateLoopbackDriveSize(size string) error {
+	if size == "" {
+		return nil
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return fmt.Errorf("drive size %q can't be parsed: %s", size, err.Error())
+	}
+	if quantity.Sign() <= 0 {
+		return fmt.Errorf("drive size %q must be positive", size)
+	}
+	return nil
+}
+
+func createLoopbackConfigMap(csi *csibaremetalv1.Deployment) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      loopbackManagerConfigName,
+			Namespace: csi.GetNamespace(),
+			Labels:    common.ConstructLabelAppMap(),
+		},
+		Data: createLoopbackConfigData(csi.Spec.Driver.Node.DriveMgr.Loopback),
+	}
+}
+
+// createLoopbackConfigData converts loopback settings to the format of loopbackmgr
+func createLoopbackConfigData(loopback *components.Loopback) map[string]string {
+	config := loopbackConfig{
+		DefaultDriveCount: loopback.DriveCount,
+		DefaultDriveSize:  loopback.DriveSize,
+	}
+	if config.DefaultDriveCount == 0 {
+		config.DefaultDriveCount = defaultLoopbackDriveCount
+	}
+
+	for _, node := range loopback.Nodes {
+		nodeConfig := &loopbackNodeConfig{NodeID: node.Name, DriveCount: node.DriveCount}
+		// drives are listed only to override their size or type, serial numbers are stable
+		// to keep loopbackmgr from recreating drives on restart
+		if node.DriveSize != "" || node.DriveType != "" {
+			count := node.DriveCount
+			if count == 0 {
+				count = config.DefaultDriveCount
+			}
+			for i := 0; i < count; i++ {
+				nodeConfig.Drives = append(nodeConfig.Drives, &loopbackDevice{
+					SerialNumber: loopbackSerialNumber(node.Name, i),
+					Size:         node.DriveSize,
+					DriveType:    node.DriveType,
+				})
+			}
+		}
+		config.Nodes = append(config.Nodes, nodeConfig)
+	}
+
+	data, _ := yaml.Marshal(config)
+	return map[string]string{loopbackConfigKey: string(data)}
+}
+
+// loopbackSerialNumber returns serial number of the drive in the format of loopbackmgr default drives.
+// loopbackmgr restores serial number from image file name after the last '-', so it must not contain '-'
+func loopbackSerialNumber(nodeName string, index int) string {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(fmt.Sprintf("%s/%d", nodeName, index)))
+	return fmt.Sprintf("LOOPBACK%d", hash.Sum32())
+}
@@ -0,0 +1,153 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+)
+
+func prepareLoopbackDeployment(loopback *components.Loopback) *v1.Deployment {
+	csi := prepareNodeConfigDeployment(nil)
+	csi.Spec.Driver.Node.DriveMgr = &components.DriveMgr{
+		Image:    &components.Image{Name: "csi-baremetal-drivemgr"},
+		Type:     components.DriveMgrTypeLoopback,
+		Loopback: loopback,
+	}
+	return csi
+}
+
+func Test_updateLoopbackConfig(t *testing.T) {
+	scheme, _ := common.PrepareScheme()
+
+	t.Run("loopback-config isn't managed without loopback settings", func(t *testing.T) {
+		csi := prepareLoopbackDeployment(nil)
+		node := prepareNodeConfigNode(csi)
+
+		err := node.updateLoopbackConfig(context.Background(), csi, scheme)
+		assert.Nil(t, err)
+		assert.Nil(t, meta.FindStatusCondition(csi.Status.Conditions, v1.LoopbackConfigValidCondition))
+
+		_, err = node.clientset.CoreV1().ConfigMaps(testNS).Get(context.Background(), loopbackManagerConfigName, metav1.GetOptions{})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Apply loopback-config with warnings", func(t *testing.T) {
+		csi := prepareLoopbackDeployment(&components.Loopback{
+			DriveSize: "302Mi",
+			Nodes: []components.LoopbackNode{
+				{Name: "node-1", DriveCount: 2, DriveType: "SSD"},
+				{Name: "node-2", DriveCount: 5},
+			},
+		})
+		node := prepareNodeConfigNode(csi)
+
+		err := node.updateLoopbackConfig(context.Background(), csi, scheme)
+		assert.Nil(t, err)
+
+		condition := meta.FindStatusCondition(csi.Status.Conditions, v1.LoopbackConfigValidCondition)
+		assert.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, LoopbackConfigAppliedReason, condition.Reason)
+		assert.Contains(t, condition.Message, "node node-2 is not found")
+
+		cm, err := node.clientset.CoreV1().ConfigMaps(testNS).Get(context.Background(), loopbackManagerConfigName, metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Len(t, cm.OwnerReferences, 1)
+
+		config := &loopbackConfig{}
+		assert.Nil(t, yaml.Unmarshal([]byte(cm.Data[loopbackConfigKey]), config))
+		assert.Equal(t, &loopbackConfig{
+			DefaultDriveCount: defaultLoopbackDriveCount,
+			DefaultDriveSize:  "302Mi",
+			Nodes: []*loopbackNodeConfig{
+				{NodeID: "node-1", DriveCount: 2, Drives: []*loopbackDevice{
+					{SerialNumber: loopbackSerialNumber("node-1", 0), DriveType: "SSD"},
+					{SerialNumber: loopbackSerialNumber("node-1", 1), DriveType: "SSD"},
+				}},
+				{NodeID: "node-2", DriveCount: 5},
+			},
+		}, config)
+	})
+
+	t.Run("Reject loopback-config with invalid size", func(t *testing.T) {
+		csi := prepareLoopbackDeployment(&components.Loopback{
+			Nodes: []components.LoopbackNode{
+				{Name: "node-1", DriveSize: "big"},
+				{Name: "node-1", DriveSize: "1Gi"},
+			},
+		})
+		node := prepareNodeConfigNode(csi)
+
+		err := node.updateLoopbackConfig(context.Background(), csi, scheme)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "loopback-config is invalid")
+
+		condition := meta.FindStatusCondition(csi.Status.Conditions, v1.LoopbackConfigValidCondition)
+		assert.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, InvalidLoopbackConfigReason, condition.Reason)
+		assert.Contains(t, condition.Message, "node node-1: drive size \"big\" can't be parsed")
+		assert.Contains(t, condition.Message, "node node-1 is configured more than once")
+
+		_, err = node.clientset.CoreV1().ConfigMaps(testNS).Get(context.Background(), loopbackManagerConfigName, metav1.GetOptions{})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Loopback settings are ignored for other drive managers", func(t *testing.T) {
+		csi := prepareLoopbackDeployment(&components.Loopback{DriveSize: "big"})
+		csi.Spec.Driver.Node.DriveMgr.Type = components.DriveMgrTypeBase
+		node := prepareNodeConfigNode(csi)
+
+		err := node.updateLoopbackConfig(context.Background(), csi, scheme)
+		assert.Nil(t, err)
+	})
+
+	t.Run("loopbackmgr isn't selected by image name", func(t *testing.T) {
+		csi := prepareLoopbackDeployment(&components.Loopback{DriveSize: "1Gi"})
+		csi.Spec.Driver.Node.DriveMgr.Type = ""
+		csi.Spec.Driver.Node.DriveMgr.Image = &components.Image{Name: "csi-baremetal-loopbackmgr"}
+		csi.Spec.Driver.Node.DriveMgr.NodeGroups = []components.DriveMgrNodeGroup{
+			{Name: "loopback", NodeSelector: map[string]string{"key": "value"}, Image: &components.Image{Name: "loopbackmgr"}},
+		}
+		node := prepareNodeConfigNode(csi)
+
+		err := node.updateLoopbackConfig(context.Background(), csi, scheme)
+		assert.Nil(t, err)
+		assert.Nil(t, meta.FindStatusCondition(csi.Status.Conditions, v1.LoopbackConfigValidCondition))
+
+		_, err = node.clientset.CoreV1().ConfigMaps(testNS).Get(context.Background(), loopbackManagerConfigName, metav1.GetOptions{})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("loopback-config is managed for node group with loopbackmgr type", func(t *testing.T) {
+		csi := prepareLoopbackDeployment(&components.Loopback{DriveSize: "1Gi"})
+		csi.Spec.Driver.Node.DriveMgr.Type = components.DriveMgrTypeBase
+		csi.Spec.Driver.Node.DriveMgr.NodeGroups = []components.DriveMgrNodeGroup{
+			{Name: "loopback", NodeSelector: map[string]string{"key": "value"}, Type: components.DriveMgrTypeLoopback},
+		}
+		node := prepareNodeConfigNode(csi)
+
+		err := node.updateLoopbackConfig(context.Background(), csi, scheme)
+		assert.Nil(t, err)
+		assert.True(t, meta.IsStatusConditionTrue(csi.Status.Conditions, v1.LoopbackConfigValidCondition))
+
+		_, err = node.clientset.CoreV1().ConfigMaps(testNS).Get(context.Background(), loopbackManagerConfigName, metav1.GetOptions{})
+		assert.Nil(t, err)
+	})
+}
+
+func Test_loopbackSerialNumber(t *testing.T) {
+	serial := loopbackSerialNumber("node-1", 0)
+	assert.Regexp(t, "^LOOPBACK[0-9]+$", serial)
+	assert.Equal(t, serial, loopbackSerialNumber("node-1", 0))
+	assert.NotEqual(t, serial, loopbackSerialNumber("node-1", 1))
+	assert.NotEqual(t, serial, loopbackSerialNumber("node-2", 0))
+}
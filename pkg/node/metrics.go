@@ -0,0 +1,34 @@
+package node
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	metricsSubsystem = "node"
+)
+
+var (
+	// platformNodes reports number of nodes labeled with each platform
+	platformNodes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: constant.MetricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "platform_nodes",
+		Help:      "Number of nodes per csi-baremetal-node platform",
+	}, []string{"platform"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(platformNodes)
+}
+
+// recordPlatforms exposes distribution of nodes per platform
+func recordPlatforms(nodesPerPlatform map[string]int) {
+	platformNodes.Reset()
+	for platform, count := range nodesPerPlatform {
+		platformNodes.WithLabelValues(platform).Set(float64(count))
+	}
+}
@@ -3,11 +3,13 @@ package node
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	nodeconst "github.com/dell/csi-baremetal/pkg/crcontrollers/node/common"
@@ -28,22 +30,28 @@ const (
 // Node controls csi-baremetal-node
 type Node struct {
 	clientset                          kubernetes.Interface
+	client                             client.Client
 	log                                *logrus.Entry
 	podSecurityPolicyVerifier          securityverifier.SecurityVerifier
 	securityContextConstraintsVerifier securityverifier.SecurityVerifier
+	healthMonitorVerifier              securityverifier.SecurityVerifier
 }
 
 // NewNode creates a Node object
 func NewNode(clientset kubernetes.Interface,
+	client client.Client,
 	podSecurityPolicyVerifier securityverifier.SecurityVerifier,
 	securityContextConstraintsVerifier securityverifier.SecurityVerifier,
+	healthMonitorVerifier securityverifier.SecurityVerifier,
 	logger *logrus.Entry,
 ) *Node {
 	return &Node{
 		clientset:                          clientset,
+		client:                             client,
 		log:                                logger,
 		podSecurityPolicyVerifier:          podSecurityPolicyVerifier,
 		securityContextConstraintsVerifier: securityContextConstraintsVerifier,
+		healthMonitorVerifier:              healthMonitorVerifier,
 	}
 }
 
@@ -79,19 +87,61 @@ func (n *Node) Update(ctx context.Context, csi *csibaremetalv1.Deployment, schem
 		}
 	}
 
+	withHealthMonitor, err := n.verifyHealthMonitor(ctx, csi)
+	if err != nil {
+		return err
+	}
+
 	needToDeploy, err := n.updateNodeLabels(ctx, csi.Spec.NodeSelector)
 	if err != nil {
 		return err
 	}
 
+	groupsToDeploy, err := n.updateDriveMgrGroupLabels(ctx, csi)
+	if err != nil {
+		return err
+	}
+
+	// node pods are not restarted with rejected node-config, NodeConfigValid condition describes the reason
+	nodeConfigHash, err := n.updateNodeConfig(ctx, csi, scheme)
+	if err != nil {
+		return err
+	}
+
+	// drive manager type is taken only from csi Deployment, images with another type are reported as warning
+	if err = n.checkDriveMgrTypes(ctx, csi); err != nil {
+		return err
+	}
+
+	// node pods are not updated with rejected loopback-config, LoopbackConfigValid condition describes the reason
+	if err = n.updateLoopbackConfig(ctx, csi, scheme); err != nil {
+		return err
+	}
+
 	for platformName, isDeploying := range needToDeploy {
-		if isDeploying {
-			expected := createNodeDaemonSet(csi, platforms[platformName])
+		if !isDeploying {
+			continue
+		}
+		// each drive manager node group has its own daemonset per platform
+		for _, group := range getDeployedDriveMgrGroups(csi, groupsToDeploy) {
+			expected := createNodeDaemonSet(csi, platforms[platformName], group)
+			if !withHealthMonitor {
+				common.RemoveContainer(&expected.Spec.Template, constant.HealthMonitorAgentName)
+			}
+			expected.Spec.Template.Annotations[nodeConfigHashAnnotation] = nodeConfigHash
 			if err := controllerutil.SetControllerReference(csi, expected, scheme); err != nil {
 				n.log.Error(err, "Failed to set controller reference "+expected.Name)
 				continue
 			}
 
+			// node-config is hashed in its own annotation
+			if err = common.AddConfigHashAnnotation(ctx, n.clientset, csi, &expected.Spec.Template,
+				nodeConfigMapName); err != nil {
+				n.log.Error(err, "Failed to compute config hash "+expected.Name)
+				resultErr = err
+				continue
+			}
+
 			if err = common.UpdateDaemonSet(ctx, n.clientset, expected, n.log); err != nil {
 				n.log.Error(err, "Failed to update daemonset "+expected.Name)
 				resultErr = err
@@ -99,9 +149,33 @@ func (n *Node) Update(ctx context.Context, csi *csibaremetalv1.Deployment, schem
 		}
 	}
 
+	if err = n.deleteObsoleteDriveMgrDaemonSets(ctx, csi, groupsToDeploy); err != nil {
+		resultErr = err
+	}
+
 	return resultErr
 }
 
+// verifyHealthMonitor validates cluster role bindings of node service account in case of health monitor
+// sidecar enabled and reports failure in NodeHealthMonitorVerified condition.
+// Returns false if the sidecar mustn't be deployed
+func (n *Node) verifyHealthMonitor(ctx context.Context, csi *csibaremetalv1.Deployment) (bool, error) {
+	conditionType := csibaremetalv1.NodeHealthMonitorVerifiedCondition
+	if csi.Spec.Driver.Node.Sidecars[constant.HealthMonitorAgentName] == nil {
+		return false, common.RemoveStatusCondition(ctx, n.client, csi, conditionType)
+	}
+
+	if err := n.healthMonitorVerifier.Verify(ctx, csi, models.Node); err != nil {
+		var verifierError securityverifier.Error
+		err = n.healthMonitorVerifier.HandleError(ctx, csi, csi.Spec.Driver.Node.ServiceAccount, err)
+		if !errors.As(err, &verifierError) {
+			return false, err
+		}
+		return false, common.UpdateStatusCondition(ctx, n.client, csi, securityverifier.NewHealthMonitorCondition(conditionType, err))
+	}
+	return true, common.RemoveStatusCondition(ctx, n.client, csi, conditionType)
+}
+
 // Uninstall deletes platform-label on each node in cluster
 func (n *Node) Uninstall(ctx context.Context, _ *csibaremetalv1.Deployment) error {
 	return n.cleanNodeLabels(ctx)
@@ -118,11 +192,13 @@ func (n *Node) updateNodeLabels(ctx context.Context, selector *components.NodeSe
 	)
 
 	needToDeploy := createPlatformsSet()
+	nodesPerPlatform := map[string]int{}
 
 	nodes, err := common.GetSelectedNodes(ctx, n.clientset, selector)
 	if err != nil {
 		return needToDeploy, err
 	}
+	defer recordPlatforms(nodesPerPlatform)
 
 	for i, node := range nodes.Items {
 		kernelVersion, err := GetNodeKernelVersion(&nodes.Items[i])
@@ -134,12 +210,19 @@ func (n *Node) updateNodeLabels(ctx context.Context, selector *components.NodeSe
 
 		platformName := findPlatform(kernelVersion)
 		needToDeploy[platformName] = true
+		nodesPerPlatform[platforms[platformName].labeltag]++
 
 		// skip updating label if exists
 		if value, ok := node.Labels[platformLabel]; ok && (value == platforms[platformName].labeltag) {
 			continue
 		}
 
+		if plan := common.GetPlan(ctx); plan != nil {
+			plan.Add(csibaremetalv1.PlannedUpdate, "Node", "", node.Name, fmt.Sprintf("label %s: %q -> %q",
+				platformLabel, node.Labels[platformLabel], platforms[platformName].labeltag))
+			continue
+		}
+
 		node.Labels[platformLabel] = platforms[platformName].labeltag
 		if _, err := n.clientset.CoreV1().Nodes().Update(ctx, &nodes.Items[i], metav1.UpdateOptions{}); err != nil {
 			n.log.Error(err, "Failed to update label on "+node.Name)
@@ -160,10 +243,12 @@ func (n *Node) cleanNodeLabels(ctx context.Context) error {
 		nodeIns := node
 		toUpdate := false
 
-		// delete platform label
-		if _, ok := node.Labels[platformLabel]; ok {
-			delete(node.Labels, platformLabel)
-			toUpdate = true
+		// delete platform and drive manager group labels
+		for _, label := range []string{platformLabel, driveMgrGroupLabel} {
+			if _, ok := node.Labels[label]; ok {
+				delete(node.Labels, label)
+				toUpdate = true
+			}
 		}
 
 		// delete label with NodeID
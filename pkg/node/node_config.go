@@ -0,0 +1,232 @@
+package node
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	wbtcommon "github.com/dell/csi-baremetal/pkg/node/wbt/common"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+)
+
+const (
+	wbtConfigKey  = "wbt-config.yaml"
+	wbtKernelsKey = "wbt-acceptable_kernels.yaml"
+
+	// nodeConfigHashAnnotation is set on node pods template to restart them when node-config is changed
+	nodeConfigHashAnnotation = "csi-baremetal.dell.com/node-config-hash"
+
+	// NodeConfigAppliedReason is set for NodeConfigValid condition when node-config is updated
+	NodeConfigAppliedReason = "NodeConfigApplied"
+	// InvalidNodeConfigReason is set for NodeConfigValid condition when node-config settings are rejected
+	InvalidNodeConfigReason = "InvalidNodeConfig"
+)
+
+// defaultWBT contains node-config settings applied if WBT isn't set in csi Deployment
+var defaultWBT = components.WBT{
+	Enable:         true,
+	Modes:          []components.WBTMode{components.WBTModeFS},
+	StorageClasses: []string{"csi-baremetal-sc-hdd", "csi-baremetal-sc-ssd", "csi-baremetal-sc-nvme"},
+	AcceptableKernels: []string{
+		// RHEL 8
+		"4.18.0-193.65.2.el8_2.x86_64",
+		"4.18.0-305.45.1.el8_4.x86_64",
+		"4.18.0-372.43.1.el8_6.x86_64",
+	},
+}
+
+// updateNodeConfig validates WBT settings of csi Deployment, creates or updates node-config ConfigMap
+// and reports NodeConfigValid condition. Kernels and StorageClasses, which are not found in cluster, are reported
+// as warnings. node-config isn't updated if settings can't be parsed.
+// Returns checksum of node-config data, which is applied to node pods
+func (n *Node) updateNodeConfig(ctx context.Context, csi *csibaremetalv1.Deployment, scheme *runtime.Scheme) (string, error) {
+	expected := createNodeConfigMap(csi)
+
+	// default settings are not validated to keep behavior of clusters without WBT in csi Deployment
+	if csi.Spec.Driver.Node.WBT != nil {
+		warnings, err := n.validateNodeConfig(ctx, csi.Spec.Driver.Node.WBT)
+		if err != nil {
+			n.log.Warnf("node-config is invalid: %s", err.Error())
+			if condErr := common.UpdateStatusCondition(ctx, n.client, csi, metav1.Condition{
+				Type:    csibaremetalv1.NodeConfigValidCondition,
+				Status:  metav1.ConditionFalse,
+				Reason:  InvalidNodeConfigReason,
+				Message: err.Error(),
+			}); condErr != nil {
+				return "", condErr
+			}
+			return n.getAppliedNodeConfigHash(ctx, csi)
+		}
+
+		message := "node-config is updated"
+		if len(warnings) != 0 {
+			n.log.Warnf("node-config is applied with warnings: %s", strings.Join(warnings, "; "))
+			message += ", warnings: " + strings.Join(warnings, "; ")
+		}
+		if err = common.UpdateStatusCondition(ctx, n.client, csi, metav1.Condition{
+			Type:    csibaremetalv1.NodeConfigValidCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  NodeConfigAppliedReason,
+			Message: message,
+		}); err != nil {
+			return "", err
+		}
+	} else if err := common.RemoveStatusCondition(ctx, n.client, csi, csibaremetalv1.NodeConfigValidCondition); err != nil {
+		return "", err
+	}
+
+	if err := controllerutil.SetControllerReference(csi, expected, scheme); err != nil {
+		return "", err
+	}
+
+	return getNodeConfigHash(expected.Data), common.UpdateConfigMap(ctx, n.clientset, expected, n.log)
+}
+
+// getAppliedNodeConfigHash returns checksum of existing node-config data to keep node pods running with it,
+// checksum of default settings is returned if node-config isn't found
+func (n *Node) getAppliedNodeConfigHash(ctx context.Context, csi *csibaremetalv1.Deployment) (string, error) {
+	cm, err := n.clientset.CoreV1().ConfigMaps(csi.GetNamespace()).Get(ctx, nodeConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			return getNodeConfigHash(createNodeConfigData(&defaultWBT)), nil
+		}
+		return "", err
+	}
+	return getNodeConfigHash(cm.Data), nil
+}
+
+// validateNodeConfig returns error if kernel versions can't be parsed
+// and warnings for kernels and StorageClasses, which are not found in cluster
+func (n *Node) validateNodeConfig(ctx context.Context, wbt *components.WBT) ([]string, error) {
+	var (
+		errMsgs  []string
+		warnings []string
+	)
+
+	nodes, err := n.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	nodeKernels := map[string]bool{}
+	nodeVersions := map[string]bool{}
+	for i := range nodes.Items {
+		nodeKernels[nodes.Items[i].Status.NodeInfo.KernelVersion] = true
+		if version, err := GetNodeKernelVersion(&nodes.Items[i]); err == nil {
+			nodeVersions[version.String()] = true
+		}
+	}
+
+	if !wbt.EnableForAllKernels {
+		for _, kernel := range wbt.AcceptableKernels {
+			version, err := GetKernelVersion(kernel)
+			if err != nil {
+				errMsgs = append(errMsgs, fmt.Sprintf("kernel version %q can't be parsed: %s", kernel, err.Error()))
+				continue
+			}
+			if nodeKernels[kernel] {
+				continue
+			}
+			// acceptable kernels may be listed before nodes are upgraded to them
+			if nodeVersions[version.String()] {
+				warnings = append(warnings, fmt.Sprintf("kernel %s is not found on nodes with kernel %d.%d",
+					kernel, version.Major(), version.Minor()))
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("kernel %s is not found on nodes", kernel))
+		}
+	}
+
+	// managed StorageClasses are created after node-config
+	if len(wbt.StorageClasses) != 0 {
+		classes, err := n.clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		names := map[string]bool{}
+		for _, class := range classes.Items {
+			names[class.Name] = true
+		}
+		for _, name := range wbt.StorageClasses {
+			if !names[name] {
+				warnings = append(warnings, fmt.Sprintf("storageclass %s is not found", name))
+			}
+		}
+	}
+
+	if len(errMsgs) != 0 {
+		return warnings, fmt.Errorf(strings.Join(errMsgs, "\n"))
+	}
+	return warnings, nil
+}
+
+func getWBT(csi *csibaremetalv1.Deployment) *components.WBT {
+	if csi.Spec.Driver.Node.WBT != nil {
+		return csi.Spec.Driver.Node.WBT
+	}
+	return &defaultWBT
+}
+
+func createNodeConfigMap(csi *csibaremetalv1.Deployment) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      nodeConfigMapName,
+			Namespace: csi.GetNamespace(),
+			Labels:    common.ConstructLabelAppMap(),
+		},
+		Data: createNodeConfigData(getWBT(csi)),
+	}
+}
+
+// createNodeConfigData converts WBT settings to the format of csi-baremetal-node WBT watcher
+func createNodeConfigData(wbt *components.WBT) map[string]string {
+	modes := make([]string, 0, len(wbt.Modes))
+	for _, mode := range wbt.Modes {
+		modes = append(modes, string(mode))
+	}
+
+	config, _ := yaml.Marshal(wbtcommon.WbtConfig{
+		Enable: wbt.Enable,
+		Value:  wbt.LatUsecValue,
+		VolumeOptions: wbtcommon.VolumeOptions{
+			Modes:          modes,
+			StorageClasses: wbt.StorageClasses,
+		},
+	})
+	kernels, _ := yaml.Marshal(wbtcommon.AcceptableKernelsConfig{
+		EnableForAll:   wbt.EnableForAllKernels,
+		KernelVersions: wbt.AcceptableKernels,
+	})
+
+	return map[string]string{
+		wbtConfigKey:  string(config),
+		wbtKernelsKey: string(kernels),
+	}
+}
+
+// getNodeConfigHash returns checksum of node-config data
+func getNodeConfigHash(data map[string]string) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte(data[key]))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,185 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+)
+
+const (
+	nodeKernel = "4.18.0-305.45.1.el8_4.x86_64"
+	testNS     = "test-csi"
+)
+
+func prepareNodeConfigDeployment(wbt *components.WBT) *v1.Deployment {
+	return &v1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "csi-baremetal.dell.com/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test-deployment", Namespace: testNS},
+		Spec: components.DeploymentSpec{
+			Driver: &components.Driver{Node: &components.Node{WBT: wbt}},
+		},
+	}
+}
+
+func prepareNodeConfigNode(csi *v1.Deployment) *Node {
+	nodeWithKernel := testNode1.DeepCopy()
+	nodeWithKernel.Status.NodeInfo = coreV1.NodeSystemInfo{KernelVersion: nodeKernel}
+	clientSet := prepareNodeClientSet(nodeWithKernel,
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "csi-baremetal-sc-hdd"}})
+
+	scheme, _ := common.PrepareScheme()
+	cl := fakeClient.NewClientBuilder().WithScheme(scheme).WithObjects(csi).
+		WithStatusSubresource(&v1.Deployment{}).Build()
+	return &Node{clientset: clientSet, client: cl, log: logEntry}
+}
+
+func Test_updateNodeConfig(t *testing.T) {
+	scheme, _ := common.PrepareScheme()
+
+	t.Run("Create default node-config", func(t *testing.T) {
+		csi := prepareNodeConfigDeployment(nil)
+		node := prepareNodeConfigNode(csi)
+
+		hash, err := node.updateNodeConfig(context.Background(), csi, scheme)
+		assert.Nil(t, err)
+		assert.Equal(t, getNodeConfigHash(createNodeConfigData(&defaultWBT)), hash)
+		assert.Nil(t, meta.FindStatusCondition(csi.Status.Conditions, v1.NodeConfigValidCondition))
+
+		cm, err := node.clientset.CoreV1().ConfigMaps(testNS).Get(context.Background(), nodeConfigMapName, metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Len(t, cm.OwnerReferences, 1)
+		assert.Contains(t, cm.Data[wbtConfigKey], "- csi-baremetal-sc-nvme")
+		assert.Contains(t, cm.Data[wbtKernelsKey], "- 4.18.0-372.43.1.el8_6.x86_64")
+	})
+
+	t.Run("Apply node-config", func(t *testing.T) {
+		csi := prepareNodeConfigDeployment(&components.WBT{
+			Enable:            true,
+			LatUsecValue:      100,
+			Modes:             []components.WBTMode{components.WBTModeFS, components.WBTModeRaw},
+			StorageClasses:    []string{"csi-baremetal-sc-hdd"},
+			AcceptableKernels: []string{nodeKernel},
+		})
+		node := prepareNodeConfigNode(csi)
+
+		_, err := node.updateNodeConfig(context.Background(), csi, scheme)
+		assert.Nil(t, err)
+
+		condition := meta.FindStatusCondition(csi.Status.Conditions, v1.NodeConfigValidCondition)
+		assert.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, NodeConfigAppliedReason, condition.Reason)
+
+		cm, err := node.clientset.CoreV1().ConfigMaps(testNS).Get(context.Background(), nodeConfigMapName, metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Contains(t, cm.Data[wbtConfigKey], "wbt_lat_usec_value: 100")
+		assert.Contains(t, cm.Data[wbtConfigKey], "- RAW")
+	})
+
+	t.Run("Reject node-config with invalid kernel", func(t *testing.T) {
+		csi := prepareNodeConfigDeployment(&components.WBT{Enable: true, AcceptableKernels: []string{"el8_4"}})
+		node := prepareNodeConfigNode(csi)
+
+		hash, err := node.updateNodeConfig(context.Background(), csi, scheme)
+		assert.Nil(t, err)
+		assert.Equal(t, getNodeConfigHash(createNodeConfigData(&defaultWBT)), hash)
+
+		condition := meta.FindStatusCondition(csi.Status.Conditions, v1.NodeConfigValidCondition)
+		assert.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, InvalidNodeConfigReason, condition.Reason)
+
+		_, err = node.clientset.CoreV1().ConfigMaps(testNS).Get(context.Background(), nodeConfigMapName, metav1.GetOptions{})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Node pods keep applied node-config if settings are rejected", func(t *testing.T) {
+		applied := &components.WBT{Enable: true, LatUsecValue: 100, AcceptableKernels: []string{nodeKernel}}
+		csi := prepareNodeConfigDeployment(applied)
+		node := prepareNodeConfigNode(csi)
+
+		appliedHash, err := node.updateNodeConfig(context.Background(), csi, scheme)
+		assert.Nil(t, err)
+
+		csi.Spec.Driver.Node.WBT = &components.WBT{Enable: true, LatUsecValue: 200, AcceptableKernels: []string{"el8_4"}}
+		hash, err := node.updateNodeConfig(context.Background(), csi, scheme)
+		assert.Nil(t, err)
+		assert.Equal(t, appliedHash, hash)
+
+		cm, err := node.clientset.CoreV1().ConfigMaps(testNS).Get(context.Background(), nodeConfigMapName, metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Contains(t, cm.Data[wbtConfigKey], "wbt_lat_usec_value: 100")
+	})
+
+	t.Run("Apply node-config with kernels and StorageClasses not found in cluster", func(t *testing.T) {
+		csi := prepareNodeConfigDeployment(&components.WBT{
+			Enable:            true,
+			StorageClasses:    []string{"csi-baremetal-sc-hdd", "csi-baremetal-sc-ssd"},
+			AcceptableKernels: []string{nodeKernel, "4.18.0-372.43.1.el8_6.x86_64", "5.14.0-70.el9.x86_64"},
+		})
+		node := prepareNodeConfigNode(csi)
+
+		_, err := node.updateNodeConfig(context.Background(), csi, scheme)
+		assert.Nil(t, err)
+
+		condition := meta.FindStatusCondition(csi.Status.Conditions, v1.NodeConfigValidCondition)
+		assert.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Contains(t, condition.Message, "kernel 4.18.0-372.43.1.el8_6.x86_64 is not found on nodes with kernel 4.18")
+		assert.Contains(t, condition.Message, "kernel 5.14.0-70.el9.x86_64 is not found on nodes")
+		assert.Contains(t, condition.Message, "storageclass csi-baremetal-sc-ssd is not found")
+		assert.NotContains(t, condition.Message, "csi-baremetal-sc-hdd")
+
+		_, err = node.clientset.CoreV1().ConfigMaps(testNS).Get(context.Background(), nodeConfigMapName, metav1.GetOptions{})
+		assert.Nil(t, err)
+	})
+
+	t.Run("Kernels are not validated if WBT is enabled for all", func(t *testing.T) {
+		csi := prepareNodeConfigDeployment(&components.WBT{Enable: true, EnableForAllKernels: true,
+			AcceptableKernels: []string{"el8_4"}})
+		node := prepareNodeConfigNode(csi)
+
+		_, err := node.updateNodeConfig(context.Background(), csi, scheme)
+		assert.Nil(t, err)
+	})
+}
+
+func Test_getNodeConfigHash(t *testing.T) {
+	defaultHash := getNodeConfigHash(createNodeConfigData(&defaultWBT))
+	assert.Equal(t, defaultHash, getNodeConfigHash(createNodeConfigData(&defaultWBT)))
+
+	changed := defaultWBT
+	changed.LatUsecValue = 100
+	assert.NotEqual(t, defaultHash, getNodeConfigHash(createNodeConfigData(&changed)))
+}
+
+func Test_Update_RejectedNodeConfig(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		scheme, _ = common.PrepareScheme()
+		csi       = prepareDriveMgrGroupsDeployment()
+	)
+	csi.Spec.Driver.Node.WBT = &components.WBT{Enable: true, AcceptableKernels: []string{"el8_4"}}
+	node := prepareNodeConfigNode(csi)
+
+	// rejected node-config doesn't stop node update
+	assert.Nil(t, node.Update(ctx, csi, scheme))
+
+	daemonSets, err := node.clientset.AppsV1().DaemonSets(testNS).List(ctx, metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, daemonSets.Items)
+	for _, ds := range daemonSets.Items {
+		assert.Equal(t, getNodeConfigHash(createNodeConfigData(&defaultWBT)),
+			ds.Spec.Template.Annotations[nodeConfigHashAnnotation])
+	}
+}
@@ -1,8 +1,8 @@
 package node
 
 import (
+	"fmt"
 	"strconv"
-	"strings"
 
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -12,14 +12,15 @@ import (
 	"k8s.io/utils/ptr"
 
 	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
 	"github.com/dell/csi-baremetal-operator/pkg/common"
 	"github.com/dell/csi-baremetal-operator/pkg/constant"
+	"github.com/dell/csi-baremetal-operator/pkg/logreceiver"
 )
 
 const (
 	node                      = "node"
 	nodeName                  = constant.CSIName + "-" + node
-	loopbackManagerImageName  = "loopbackmgr"
 	loopbackManagerConfigName = "loopback-config"
 
 	// volumes
@@ -28,29 +29,47 @@ const (
 	hostHomeVolume        = "host-home"
 	hostSysVolume         = "host-sys"
 	hostRootVolume        = "host-root"
+	hostEtcLVMVolume      = "host-etc-lvm"
 	hostRunUdevVolume     = "host-run-udev"
 	hostRunLVMVolume      = "host-run-lvm"
 	hostRunLock           = "host-run-lock"
+	hostIPMIVolume        = "host-ipmi"
 	mountPointDirVolume   = "mountpoint-dir"
 	csiPathVolume         = "csi-path"
 	driveConfigVolume     = "drive-config"
 	nodeConfigVolume      = "node-config"
 	nodeConfigMapName     = "node-config"
 	nodeConfigPath        = "/etc/node_config"
+	mountRootHostEnv      = "MOUNT_ROOT_HOST"
 )
 
+// ConfigMapNames returns names of ConfigMaps, which are mounted to node pods
+func ConfigMapNames() []string {
+	return []string{nodeConfigMapName, loopbackManagerConfigName}
+}
+
 // GetNodeDaemonsetPodsSelector returns a label-selector to use in the List method
 func GetNodeDaemonsetPodsSelector() labels.Selector {
 	return labels.SelectorFromSet(common.ConstructSelectorMap(nodeName))
 }
 
-func createNodeDaemonSet(csi *csibaremetalv1.Deployment, platform *PlatformDescription) *v1.DaemonSet {
-	var nodeSelectors = common.MakeNodeSelectorMap(csi.Spec.NodeSelector)
+// createNodeDaemonSet creates node DaemonSet of the platform and drive manager node group,
+// nil group means nodes which don't match any group
+func createNodeDaemonSet(csi *csibaremetalv1.Deployment, platform *PlatformDescription,
+	group *components.DriveMgrNodeGroup) *v1.DaemonSet {
+	var (
+		nodeSelectors = common.MakeNodeSelectorMap(csi.Spec.NodeSelector)
+		driveMgr      = groupDriveMgr(csi.Spec.Driver.Node.DriveMgr, group)
+	)
 	nodeSelectors[platformLabel] = platform.labeltag
+	// drivemgr-group label is set only if node groups are configured to keep node pods untouched otherwise
+	if len(getDriveMgrNodeGroups(csi)) != 0 {
+		nodeSelectors[driveMgrGroupLabel] = driveMgrGroupName(group)
+	}
 
-	return &v1.DaemonSet{
+	daemonSet := &v1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      platform.DaemonsetName(nodeName),
+			Name:      driveMgrGroupDaemonsetName(platform, group),
 			Namespace: csi.GetNamespace(),
 			Labels:    common.ConstructLabelAppMap(),
 		},
@@ -59,6 +78,8 @@ func createNodeDaemonSet(csi *csibaremetalv1.Deployment, platform *PlatformDescr
 			Selector: &metav1.LabelSelector{
 				MatchLabels: common.ConstructSelectorMap(nodeName),
 			},
+			// node pods are restarted by operator in case of orchestrated upgrade
+			UpdateStrategy: createNodeUpdateStrategy(csi),
 			// template
 			Template: corev1.PodTemplateSpec{
 				// labels and annotations
@@ -66,15 +87,12 @@ func createNodeDaemonSet(csi *csibaremetalv1.Deployment, platform *PlatformDescr
 					// labels
 					Labels: common.ConstructLabelMap(nodeName, node),
 					// integration with monitoring
-					Annotations: map[string]string{
-						"prometheus.io/scrape": "true",
-						"prometheus.io/port":   strconv.Itoa(constant.PrometheusPort),
-						"prometheus.io/path":   "/metrics",
-					},
+					Annotations: common.MetricsAnnotations(common.MetricsPort(csi.Spec.Driver.Metrics),
+						common.MetricsPath(csi.Spec.Driver.Metrics)),
 				},
 				Spec: corev1.PodSpec{
-					Volumes:                       createNodeVolumes(csi),
-					Containers:                    createNodeContainers(csi, platform),
+					Volumes:                       createNodeVolumes(csi, driveMgr),
+					Containers:                    createNodeContainers(csi, platform, driveMgr),
 					RestartPolicy:                 corev1.RestartPolicyAlways,
 					DNSPolicy:                     corev1.DNSClusterFirst,
 					TerminationGracePeriodSeconds: ptr.To(int64(constant.TerminationGracePeriodSeconds)),
@@ -89,9 +107,25 @@ func createNodeDaemonSet(csi *csibaremetalv1.Deployment, platform *PlatformDescr
 			},
 		},
 	}
+
+	// node pods are restarted to apply changed node-config
+	daemonSet.Spec.Template.Annotations[nodeConfigHashAnnotation] = getNodeConfigHash(createNodeConfigData(getWBT(csi)))
+
+	logreceiver.AddSidecar(&daemonSet.Spec.Template, csi)
+	common.ApplyPodOverrides(&daemonSet.Spec.Template, csi.Spec.Driver.Node.PodOverrides)
+
+	return daemonSet
+}
+
+func createNodeUpdateStrategy(csi *csibaremetalv1.Deployment) v1.DaemonSetUpdateStrategy {
+	if isUpgradeEnabled(csi) {
+		return v1.DaemonSetUpdateStrategy{Type: v1.OnDeleteDaemonSetStrategyType}
+	}
+	return v1.DaemonSetUpdateStrategy{Type: v1.RollingUpdateDaemonSetStrategyType}
 }
 
-func createNodeVolumes(csi *csibaremetalv1.Deployment) []corev1.Volume {
+// createNodeVolumes returns volumes of node pod including volumes required by drive manager
+func createNodeVolumes(csi *csibaremetalv1.Deployment, driveMgr *components.DriveMgr) []corev1.Volume {
 	directory := corev1.HostPathDirectory
 	directoryOrCreate := corev1.HostPathDirectoryOrCreate
 	configMapMode := corev1.ConfigMapVolumeSourceDefaultMode
@@ -110,9 +144,13 @@ func createNodeVolumes(csi *csibaremetalv1.Deployment) []corev1.Volume {
 		corev1.Volume{Name: hostSysVolume, VolumeSource: corev1.VolumeSource{
 			HostPath: &corev1.HostPathVolumeSource{Path: "/sys", Type: &directory},
 		}},
-		corev1.Volume{Name: hostRootVolume, VolumeSource: corev1.VolumeSource{
+	)
+	if isRootHostMounted(csi) {
+		volumes = append(volumes, corev1.Volume{Name: hostRootVolume, VolumeSource: corev1.VolumeSource{
 			HostPath: &corev1.HostPathVolumeSource{Path: "/", Type: &directory},
-		}},
+		}})
+	}
+	volumes = append(volumes,
 		corev1.Volume{Name: hostRunUdevVolume, VolumeSource: corev1.VolumeSource{
 			HostPath: &corev1.HostPathVolumeSource{Path: "/run/udev", Type: &directory},
 		}},
@@ -146,48 +184,35 @@ func createNodeVolumes(csi *csibaremetalv1.Deployment) []corev1.Volume {
 		constant.CrashVolume,
 	)
 
-	if isLoopbackMgr(csi.Spec.Driver.Node.DriveMgr.Image.Name) {
-		volumes = append(volumes, corev1.Volume{
-			Name: driveConfigVolume,
-			VolumeSource: corev1.VolumeSource{
-				ConfigMap: &corev1.ConfigMapVolumeSource{
-					LocalObjectReference: corev1.LocalObjectReference{Name: loopbackManagerConfigName},
-					DefaultMode:          &configMapMode,
-					Optional:             ptr.To(true),
-				},
-			}})
+	if !isRootHostMounted(csi) {
+		volumes = append(volumes, corev1.Volume{Name: hostEtcLVMVolume, VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{Path: "/etc/lvm", Type: &directoryOrCreate},
+		}})
+	}
+
+	if mgrType := lookupDriveMgrType(driveMgr); mgrType.volumes != nil {
+		volumes = append(volumes, mgrType.volumes(csi)...)
 	}
 
 	return volumes
 }
 
-func isLoopbackMgr(imageName string) bool {
-	return strings.Contains(imageName, loopbackManagerImageName)
+// isRootHostMounted checks if host root filesystem should be mounted to node container, enabled by default
+func isRootHostMounted(csi *csibaremetalv1.Deployment) bool {
+	mountRootHost := csi.Spec.Driver.MountRootHost
+	return mountRootHost == nil || *mountRootHost
 }
 
 // todo split long methods - https://github.com/dell/csi-baremetal/issues/329
-func createNodeContainers(csi *csibaremetalv1.Deployment, platform *PlatformDescription) []corev1.Container {
+func createNodeContainers(csi *csibaremetalv1.Deployment, platform *PlatformDescription,
+	driveMgr *components.DriveMgr) []corev1.Container {
 	var (
 		bidirectional = corev1.MountPropagationBidirectional
-		driveMgr      = csi.Spec.Driver.Node.DriveMgr
 		node          = csi.Spec.Driver.Node
 		lp            = node.Sidecars[constant.LivenessProbeName]
 		dr            = node.Sidecars[constant.DriverRegistrarName]
 		nodeImage     = platform.NodeImage(node.Image)
 	)
-	args := []string{
-		constant.LogLevelSlogan + common.MatchLogLevel(node.Log.Level),
-		"--drivemgrendpoint=" + driveMgr.Endpoint,
-	}
-	driveMgrMounts := []corev1.VolumeMount{
-		{Name: hostDevVolume, MountPath: "/dev"},
-		{Name: hostHomeVolume, MountPath: "/host/home"},
-		constant.CrashMountVolume,
-	}
-	if isLoopbackMgr(driveMgr.Image.Name) {
-		driveMgrMounts = append(driveMgrMounts, corev1.VolumeMount{Name: driveConfigVolume, MountPath: "/etc/config"})
-		args = append(args, "--usenodeannotation="+strconv.FormatBool(csi.Spec.NodeIDAnnotation))
-	}
 	nodeMounts := []corev1.VolumeMount{
 		{Name: constant.LogsVolume, MountPath: "/var/log"},
 		{Name: hostDevVolume, MountPath: "/dev"},
@@ -198,14 +223,39 @@ func createNodeContainers(csi *csibaremetalv1.Deployment, platform *PlatformDesc
 		{Name: constant.CSISocketDirVolume, MountPath: "/csi"},
 		{Name: mountPointDirVolume, MountPath: "/var/lib/kubelet/pods", MountPropagation: &bidirectional},
 		{Name: csiPathVolume, MountPath: "/var/lib/kubelet/plugins/kubernetes.io/csi", MountPropagation: &bidirectional},
-		{Name: hostRootVolume, MountPath: "/hostroot", MountPropagation: &bidirectional},
-		{Name: nodeConfigMapName, MountPath: nodeConfigPath},
+	}
+	if isRootHostMounted(csi) {
+		nodeMounts = append(nodeMounts, corev1.VolumeMount{Name: hostRootVolume, MountPath: "/hostroot", MountPropagation: &bidirectional})
+	}
+	nodeMounts = append(nodeMounts,
+		corev1.VolumeMount{Name: nodeConfigMapName, MountPath: nodeConfigPath},
 		constant.CrashMountVolume,
+	)
+	nodeEnv := []corev1.EnvVar{
+		{Name: "CSI_ENDPOINT", Value: "unix:///csi/csi.sock"},
+		{Name: "LOG_FORMAT", Value: common.MatchLogFormat(node.Log.Format)},
+		{Name: "KUBE_NODE_NAME", ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "spec.nodeName"},
+		}},
+		{Name: "MY_POD_IP", ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "status.podIP"},
+		}},
+		{Name: "NAMESPACE", ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "metadata.namespace"},
+		}},
+		{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "metadata.name"},
+		}},
 	}
-	return []corev1.Container{
+	if !isRootHostMounted(csi) {
+		nodeMounts = append(nodeMounts, corev1.VolumeMount{Name: hostEtcLVMVolume, MountPath: "/etc/lvm"})
+		// node driver skips /hostroot based system disk detection if host root is not mounted
+		nodeEnv = append(nodeEnv, corev1.EnvVar{Name: mountRootHostEnv, Value: "false"})
+	}
+	containers := []corev1.Container{
 		{
 			Name:            constant.LivenessProbeName,
-			Image:           common.ConstructFullImageName(lp.Image, csi.Spec.GlobalRegistry),
+			Image:           common.ConstructFullImageName(lp.Image, csi),
 			ImagePullPolicy: corev1.PullPolicy(csi.Spec.PullPolicy),
 			Args:            []string{"--csi-address=$(ADDRESS)"},
 			Env: []corev1.EnvVar{
@@ -221,7 +271,7 @@ func createNodeContainers(csi *csibaremetalv1.Deployment, platform *PlatformDesc
 		},
 		{
 			Name:            constant.DriverRegistrarName,
-			Image:           common.ConstructFullImageName(dr.Image, csi.Spec.GlobalRegistry),
+			Image:           common.ConstructFullImageName(dr.Image, csi),
 			ImagePullPolicy: corev1.PullPolicy(csi.Spec.PullPolicy),
 			Args: []string{"--v=5", "--csi-address=$(ADDRESS)",
 				"--kubelet-registration-path=$(DRIVER_REG_SOCK_PATH)"},
@@ -245,7 +295,7 @@ func createNodeContainers(csi *csibaremetalv1.Deployment, platform *PlatformDesc
 		},
 		{
 			Name:            "node",
-			Image:           common.ConstructFullImageName(nodeImage, csi.Spec.GlobalRegistry),
+			Image:           common.ConstructFullImageName(nodeImage, csi),
 			ImagePullPolicy: corev1.PullPolicy(csi.Spec.PullPolicy),
 			Args: []string{
 				"--csiendpoint=$(CSI_ENDPOINT)",
@@ -253,14 +303,14 @@ func createNodeContainers(csi *csibaremetalv1.Deployment, platform *PlatformDesc
 				"--namespace=$(NAMESPACE)",
 				"--extender=true",
 				constant.LogLevelSlogan + common.MatchLogLevel(node.Log.Level),
-				"--metrics-address=:" + strconv.Itoa(constant.PrometheusPort),
-				"--metrics-path=/metrics",
+				"--metrics-address=:" + strconv.Itoa(int(common.MetricsPort(csi.Spec.Driver.Metrics))),
+				"--metrics-path=" + common.MetricsPath(csi.Spec.Driver.Metrics),
 				"--drivemgrendpoint=" + driveMgr.Endpoint,
 				"--usenodeannotation=" + strconv.FormatBool(csi.Spec.NodeIDAnnotation),
 			},
 			Ports: []corev1.ContainerPort{
 				{Name: constant.LivenessPort, ContainerPort: 9808, Protocol: corev1.ProtocolTCP},
-				{Name: "metrics", ContainerPort: constant.PrometheusPort, Protocol: corev1.ProtocolTCP},
+				{Name: "metrics", ContainerPort: common.MetricsPort(csi.Spec.Driver.Metrics), Protocol: corev1.ProtocolTCP},
 			},
 			LivenessProbe: &corev1.Probe{
 				ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{
@@ -283,44 +333,46 @@ func createNodeContainers(csi *csibaremetalv1.Deployment, platform *PlatformDesc
 				SuccessThreshold:    3,
 				FailureThreshold:    100,
 			},
-			Env: []corev1.EnvVar{
-				{Name: "CSI_ENDPOINT", Value: "unix:///csi/csi.sock"},
-				{Name: "LOG_FORMAT", Value: common.MatchLogFormat(node.Log.Format)},
-				{Name: "KUBE_NODE_NAME", ValueFrom: &corev1.EnvVarSource{
-					FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "spec.nodeName"},
-				}},
-				{Name: "MY_POD_IP", ValueFrom: &corev1.EnvVarSource{
-					FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "status.podIP"},
-				}},
-				{Name: "NAMESPACE", ValueFrom: &corev1.EnvVarSource{
-					FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "metadata.namespace"},
-				}},
-				{Name: "POD_NAME", ValueFrom: &corev1.EnvVarSource{
-					FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "metadata.name"},
-				}},
-			},
+			Env:                      nodeEnv,
 			SecurityContext:          &corev1.SecurityContext{Privileged: ptr.To(true)},
 			VolumeMounts:             nodeMounts,
 			TerminationMessagePath:   constant.TerminationMessagePath,
 			TerminationMessagePolicy: constant.TerminationMessagePolicy,
 			Resources:                common.ConstructResourceRequirements(node.Resources),
 		},
-		{
-			Name:            "drivemgr",
-			Image:           common.ConstructFullImageName(driveMgr.Image, csi.Spec.GlobalRegistry),
-			ImagePullPolicy: corev1.PullPolicy(csi.Spec.PullPolicy),
-			Args:            args,
-			Env: []corev1.EnvVar{
-				{Name: "LOG_FORMAT", Value: common.MatchLogFormat(node.Log.Format)},
-				{Name: "KUBE_NODE_NAME", ValueFrom: &corev1.EnvVarSource{
-					FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "spec.nodeName"},
-				}},
-			},
-			SecurityContext:          &corev1.SecurityContext{Privileged: ptr.To(true)},
-			VolumeMounts:             driveMgrMounts,
-			TerminationMessagePath:   constant.TerminationMessagePath,
-			TerminationMessagePolicy: constant.TerminationMessagePolicy,
-			Resources:                common.ConstructResourceRequirements(driveMgr.Resources),
+		createDriveMgrContainer(csi, driveMgr),
+	}
+
+	if healthMonitor := node.Sidecars[constant.HealthMonitorAgentName]; healthMonitor != nil {
+		containers = append(containers, createHealthMonitorAgentContainer(csi, healthMonitor))
+	}
+
+	return containers
+}
+
+func createHealthMonitorAgentContainer(csi *csibaremetalv1.Deployment, healthMonitor *components.Sidecar) corev1.Container {
+	args := []string{"--v=5", "--csi-address=$(ADDRESS)"}
+	if healthMonitor.Args != nil && healthMonitor.Args.MonitorInterval != "" {
+		args = append(args, fmt.Sprintf("--monitor-interval=%v", healthMonitor.Args.MonitorInterval))
+	}
+
+	return corev1.Container{
+		Name:            constant.HealthMonitorAgentName,
+		Image:           common.ConstructFullImageName(healthMonitor.Image, csi),
+		ImagePullPolicy: corev1.PullPolicy(csi.Spec.PullPolicy),
+		Args:            args,
+		Env: []corev1.EnvVar{
+			{Name: "ADDRESS", Value: "/csi/csi.sock"},
+			{Name: "NODE_NAME", ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "spec.nodeName"},
+			}},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: constant.CSISocketDirVolume, MountPath: "/csi"},
+			constant.CrashMountVolume,
 		},
+		TerminationMessagePath:   constant.TerminationMessagePath,
+		TerminationMessagePolicy: constant.TerminationMessagePolicy,
+		Resources:                common.ConstructResourceRequirements(healthMonitor.Resources),
 	}
 }
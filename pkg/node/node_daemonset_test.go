@@ -178,18 +178,20 @@ var (
 			Selector: &metav1.LabelSelector{
 				MatchLabels: common.ConstructSelectorMap("csi-baremetal-node"),
 			},
+			UpdateStrategy: v1.DaemonSetUpdateStrategy{Type: v1.RollingUpdateDaemonSetStrategyType},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: common.ConstructLabelMap("csi-baremetal-node", "node"),
 					Annotations: map[string]string{
-						"prometheus.io/scrape": "true",
-						"prometheus.io/port":   strconv.Itoa(constant.PrometheusPort),
-						"prometheus.io/path":   "/metrics",
+						"prometheus.io/scrape":   "true",
+						"prometheus.io/port":     strconv.Itoa(constant.PrometheusPort),
+						"prometheus.io/path":     "/metrics",
+						nodeConfigHashAnnotation: getNodeConfigHash(createNodeConfigData(&defaultWBT)),
 					},
 				},
 				Spec: corev1.PodSpec{
 					Volumes:                       usedVolumes,
-					Containers:                    createNodeContainers(&csiDeployment, platform),
+					Containers:                    createNodeContainers(&csiDeployment, platform, csiDeployment.Spec.Driver.Node.DriveMgr),
 					RestartPolicy:                 corev1.RestartPolicyAlways,
 					DNSPolicy:                     corev1.DNSClusterFirst,
 					TerminationGracePeriodSeconds: ptr.To(int64(constant.TerminationGracePeriodSeconds)),
@@ -209,17 +211,104 @@ var (
 	}
 )
 
-func Test_IsLoppbackMgr_Containers(t *testing.T) {
-	t.Run("Check if is loopback-mgr", func(t *testing.T) {
+func Test_getDriveMgrType(t *testing.T) {
+	t.Run("basemgr is used if type isn't set", func(t *testing.T) {
+		assert.Equal(t, components.DriveMgrTypeBase,
+			getDriveMgrType(&components.DriveMgr{Image: &components.Image{Name: "loopbackmgr"}}))
+		assert.Equal(t, components.DriveMgrTypeBase,
+			getDriveMgrType(&components.DriveMgr{Image: &components.Image{Name: "csi-baremetal-idracmgr"}}))
+		assert.Equal(t, components.DriveMgrTypeBase, getDriveMgrType(nil))
+	})
+
+	t.Run("Type overrides image name", func(t *testing.T) {
+		assert.Equal(t, components.DriveMgrTypeLoopback, getDriveMgrType(&components.DriveMgr{
+			Image: &components.Image{Name: "drivemgr"}, Type: components.DriveMgrTypeLoopback}))
+		assert.Equal(t, components.DriveMgrTypeBase, getDriveMgrType(&components.DriveMgr{
+			Image: &components.Image{Name: "loopbackmgr"}, Type: components.DriveMgrTypeBase}))
+	})
 
-		assert.True(t, isLoopbackMgr("loopbackmgr"))
-		assert.False(t, isLoopbackMgr("anothermgr"))
+	t.Run("Node group inherits type", func(t *testing.T) {
+		driveMgr := &components.DriveMgr{Image: &components.Image{Name: "drivemgr"}, Type: components.DriveMgrTypeHAL}
+		assert.Equal(t, components.DriveMgrTypeHAL, getDriveMgrType(groupDriveMgr(driveMgr,
+			&components.DriveMgrNodeGroup{Name: "group", Image: &components.Image{Name: "another"}})))
+		assert.Equal(t, components.DriveMgrTypeIDRAC, getDriveMgrType(groupDriveMgr(driveMgr,
+			&components.DriveMgrNodeGroup{Name: "group", Type: components.DriveMgrTypeIDRAC})))
+	})
+
+	t.Run("All types of enum are registered", func(t *testing.T) {
+		for _, name := range []components.DriveMgrType{components.DriveMgrTypeBase, components.DriveMgrTypeLoopback,
+			components.DriveMgrTypeIDRAC, components.DriveMgrTypeHAL} {
+			assert.Contains(t, driveMgrTypes, name)
+		}
+	})
+}
+
+func Test_getDriveMgrTypeMismatches(t *testing.T) {
+	csi := &v1csi.Deployment{Spec: components.DeploymentSpec{Driver: &components.Driver{Node: &components.Node{
+		DriveMgr: &components.DriveMgr{
+			Image: &components.Image{Name: "csi-baremetal-loopbackmgr"},
+			NodeGroups: []components.DriveMgrNodeGroup{
+				{Name: "inherited", NodeSelector: map[string]string{"key": "inherited"}},
+				{Name: "idrac", NodeSelector: map[string]string{"key": "idrac"}, Type: components.DriveMgrTypeIDRAC,
+					Image: &components.Image{Name: "csi-baremetal-idracmgr"}},
+				{Name: "hal", NodeSelector: map[string]string{"key": "hal"},
+					Image: &components.Image{Name: "csi-baremetal-halmgr"}},
+			},
+		},
+	}}}}
+
+	assert.Equal(t, []string{
+		"drive manager uses image csi-baremetal-loopbackmgr with type basemgr, set type loopbackmgr if it's expected",
+		"drive manager of node group hal uses image csi-baremetal-halmgr with type basemgr, set type halmgr if it's expected",
+	}, getDriveMgrTypeMismatches(csi))
+
+	csi.Spec.Driver.Node.DriveMgr.Type = components.DriveMgrTypeLoopback
+	csi.Spec.Driver.Node.DriveMgr.NodeGroups[2].Type = components.DriveMgrTypeHAL
+	assert.Empty(t, getDriveMgrTypeMismatches(csi))
+}
+
+func Test_createDriveMgrContainer(t *testing.T) {
+	t.Run("Loopback manager gets config and node annotation flag", func(t *testing.T) {
+		container := createDriveMgrContainer(&csiDeployment, &components.DriveMgr{
+			Image: &components.Image{Name: "drivemgr"}, Endpoint: "endpoint", Type: components.DriveMgrTypeLoopback})
+
+		assert.Equal(t, driveMgrContainerName, container.Name)
+		assert.Contains(t, container.Args, "--drivemgrendpoint=endpoint")
+		assert.Contains(t, container.Args, "--usenodeannotation=false")
+		assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: driveConfigVolume, MountPath: "/etc/config"})
+		assert.True(t, *container.SecurityContext.Privileged)
+	})
+
+	t.Run("iDRAC manager gets IPMI device", func(t *testing.T) {
+		container := createDriveMgrContainer(&csiDeployment, &components.DriveMgr{
+			Image: &components.Image{Name: "drivemgr"}, Endpoint: "endpoint", Type: components.DriveMgrTypeIDRAC})
+
+		assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: hostIPMIVolume, MountPath: "/dev/ipmi0"})
+		assert.True(t, *container.SecurityContext.Privileged)
+	})
+
+	t.Run("HAL manager gets sysfs and udev", func(t *testing.T) {
+		container := createDriveMgrContainer(&csiDeployment, &components.DriveMgr{
+			Image: &components.Image{Name: "drivemgr"}, Endpoint: "endpoint", Type: components.DriveMgrTypeHAL})
+
+		assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: hostSysVolume, MountPath: "/sys", ReadOnly: true})
+		assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: hostRunUdevVolume, MountPath: "/run/udev", ReadOnly: true})
+		assert.NotContains(t, container.VolumeMounts, corev1.VolumeMount{Name: hostIPMIVolume, MountPath: "/dev/ipmi0"})
+		assert.True(t, *container.SecurityContext.Privileged)
+	})
+
+	t.Run("Base manager gets common mounts only", func(t *testing.T) {
+		container := createDriveMgrContainer(&csiDeployment, csiDeployment.Spec.Driver.Node.DriveMgr)
+
+		assert.Equal(t, []string{"--loglevel=debug", "--drivemgrendpoint=endpoint"}, container.Args)
+		assert.Len(t, container.VolumeMounts, 3)
+		assert.True(t, *container.SecurityContext.Privileged)
 	})
 }
 
 func Test_Create_NodeDaemonSet(t *testing.T) {
 	t.Run("Check if daemonset is created", func(t *testing.T) {
-		daemonSet := createNodeDaemonSet(&csiDeployment, platform)
+		daemonSet := createNodeDaemonSet(&csiDeployment, platform, nil)
 		assert.NotNil(t, daemonSet)
 		if !reflect.DeepEqual(daemonSet, expectedDaemonSet) {
 			t.Errorf("Expected daemonset: %v, but got: %v", expectedDaemonSet, daemonSet)
@@ -244,7 +333,7 @@ func Test_Create_NodeVolumes(t *testing.T) {
 	}
 	t.Run("Check volumes for non loopback mgr", func(t *testing.T) {
 		expectedVolumes := usedVolumes
-		volumes := createNodeVolumes(&csiDeployment)
+		volumes := createNodeVolumes(&csiDeployment, csiDeployment.Spec.Driver.Node.DriveMgr)
 
 		assert.NotNil(t, volumes)
 		if !reflect.DeepEqual(volumes, expectedVolumes) {
@@ -253,8 +342,8 @@ func Test_Create_NodeVolumes(t *testing.T) {
 	})
 
 	t.Run("Check volumes for loopback mgr", func(t *testing.T) {
-		inDeployment := *csiDeployment.DeepCopy()
-		inDeployment.Spec.Driver.Node.DriveMgr.Image.Name = "loopbackmgr"
+		driveMgr := &components.DriveMgr{Image: &components.Image{Name: "loopbackmgr"}, Endpoint: "endpoint",
+			Type: components.DriveMgrTypeLoopback}
 
 		expectedVolumes := append(usedVolumes, corev1.Volume{
 			Name: driveConfigVolume,
@@ -265,7 +354,7 @@ func Test_Create_NodeVolumes(t *testing.T) {
 					Optional:             ptr.To(true),
 				},
 			}})
-		volumes := createNodeVolumes(&csiDeployment)
+		volumes := createNodeVolumes(&csiDeployment, driveMgr)
 
 		assert.NotNil(t, volumes)
 		if !reflect.DeepEqual(volumes, expectedVolumes) {
@@ -273,3 +362,61 @@ func Test_Create_NodeVolumes(t *testing.T) {
 		}
 	})
 }
+
+func Test_Create_NodeDaemonSet_WithoutRootHost(t *testing.T) {
+	deployment := csiDeployment.DeepCopy()
+	driver := *deployment.Spec.Driver
+	driver.MountRootHost = ptr.To(false)
+	deployment.Spec.Driver = &driver
+
+	daemonSet := createNodeDaemonSet(deployment, platform, nil)
+
+	for _, volume := range daemonSet.Spec.Template.Spec.Volumes {
+		assert.NotEqual(t, hostRootVolume, volume.Name)
+	}
+	assert.Contains(t, daemonSet.Spec.Template.Spec.Volumes, corev1.Volume{Name: hostEtcLVMVolume, VolumeSource: corev1.VolumeSource{
+		HostPath: &corev1.HostPathVolumeSource{Path: "/etc/lvm", Type: &directoryOrCreate},
+	}})
+
+	for _, container := range daemonSet.Spec.Template.Spec.Containers {
+		if container.Name != "node" {
+			continue
+		}
+		for _, mount := range container.VolumeMounts {
+			assert.NotEqual(t, "/hostroot", mount.MountPath)
+		}
+		assert.Contains(t, container.Env, corev1.EnvVar{Name: mountRootHostEnv, Value: "false"})
+	}
+}
+
+func Test_Create_NodeDaemonSet_WithRootHost(t *testing.T) {
+	daemonSet := createNodeDaemonSet(csiDeployment.DeepCopy(), platform, nil)
+	assert.True(t, isRootHostMounted(csiDeployment.DeepCopy()))
+
+	deployment := csiDeployment.DeepCopy()
+	driver := *deployment.Spec.Driver
+	driver.MountRootHost = ptr.To(true)
+	deployment.Spec.Driver = &driver
+	assert.True(t, isRootHostMounted(deployment))
+
+	for _, volume := range daemonSet.Spec.Template.Spec.Volumes {
+		assert.NotEqual(t, hostEtcLVMVolume, volume.Name)
+	}
+	for _, container := range daemonSet.Spec.Template.Spec.Containers {
+		for _, env := range container.Env {
+			assert.NotEqual(t, mountRootHostEnv, env.Name)
+		}
+	}
+}
+
+func Test_createHealthMonitorAgentContainer(t *testing.T) {
+	deployment := v1csi.Deployment{}
+	container := createHealthMonitorAgentContainer(&deployment, &components.Sidecar{
+		Image: &components.Image{Name: "health-monitor"},
+		Args:  &components.Args{MonitorInterval: "1m"},
+	})
+
+	assert.Equal(t, constant.HealthMonitorAgentName, container.Name)
+	assert.Contains(t, container.Args, "--monitor-interval=1m")
+	assert.Equal(t, "NODE_NAME", container.Env[1].Name)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	coreV1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
@@ -137,6 +138,7 @@ func TestNewNode(t *testing.T) {
 		cl := builderWithScheme.WithObjects().Build()
 
 		node := NewNode(clientSet,
+			cl,
 			securityverifier.NewPodSecurityPolicyVerifier(
 				validator.NewValidator(rbac.NewValidator(cl, logEntry, rbac.NewMatcher())),
 				new(mocks.EventRecorder),
@@ -149,12 +151,19 @@ func TestNewNode(t *testing.T) {
 				matchSecurityContextConstraintsPolicies,
 				logEntry,
 			),
+			securityverifier.NewHealthMonitorVerifier(
+				validator.NewValidator(rbac.NewValidator(cl, logEntry, rbac.NewMatcher())),
+				new(mocks.EventRecorder),
+				logEntry,
+			),
 			logEntry,
 		)
 		assert.NotNil(t, node.clientset)
+		assert.NotNil(t, node.client)
 		assert.NotNil(t, node.log)
 		assert.NotNil(t, node.podSecurityPolicyVerifier)
 		assert.NotNil(t, node.securityContextConstraintsVerifier)
+		assert.NotNil(t, node.healthMonitorVerifier)
 	})
 }
 
@@ -251,6 +260,103 @@ func Test_ValidateRBACPodSecurityPolicy(t *testing.T) {
 	})
 }
 
+func Test_ValidateRBACHealthMonitor(t *testing.T) {
+	var (
+		healthMonitorDeployment = v1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-deployment", Namespace: "test-csi"},
+			Spec: components.DeploymentSpec{
+				Driver: &components.Driver{
+					Node: &components.Node{
+						ServiceAccount: "csi-node-sa",
+						Sidecars: map[string]*components.Sidecar{
+							constant.HealthMonitorAgentName: {Image: &components.Image{Name: "health-monitor"}},
+						},
+					},
+				},
+				Platform: constant.PlatformVanilla,
+			},
+		}
+		clusterRoleBinding = &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-clusterrolebinding"},
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.ServiceAccountKind, Name: "csi-node-sa", Namespace: "test-csi"},
+			},
+			RoleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "test-clusterrole"},
+		}
+		clusterRole = &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-clusterrole"},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"persistentvolumes", "persistentvolumeclaims", "pods"},
+					Verbs: []string{"get", "list", "watch"}},
+				{APIGroups: []string{""}, Resources: []string{"events"}, Verbs: []string{"get", "create", "patch"}},
+			},
+		}
+	)
+
+	t.Run("Not Existing ClusterRole and ClusterRoleBinding for node ServiceAccount", func(t *testing.T) {
+		ctx := context.Background()
+
+		eventRecorder := new(mocks.EventRecorder)
+		eventRecorder.On("Eventf", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+		scheme, _ := common.PrepareScheme()
+		csi := prepareDriveMgrGroupsDeployment()
+		csi.Namespace = healthMonitorDeployment.Namespace
+		csi.Spec.Driver.Node.ServiceAccount = "csi-node-sa"
+		csi.Spec.Driver.Node.Sidecars[constant.HealthMonitorAgentName] = &components.Sidecar{
+			Image: &components.Image{Name: "health-monitor"}}
+		node := prepareNode(eventRecorder, prepareNodeClientSet(testNode1.DeepCopy()), prepareStatusClient(scheme, csi))
+		err := node.Update(ctx, csi, scheme)
+		assert.Nil(t, err)
+		eventRecorder.AssertCalled(t, "Eventf", mock.Anything, mock.Anything, "HealthMonitorVerificationFailed",
+			mock.Anything, mock.Anything, mock.Anything)
+
+		condition := meta.FindStatusCondition(csi.Status.Conditions, v1.NodeHealthMonitorVerifiedCondition)
+		assert.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, securityverifier.InsufficientClusterRolesReason, condition.Reason)
+
+		// node is deployed without the sidecar
+		daemonSets, err := node.clientset.AppsV1().DaemonSets(csi.Namespace).List(ctx, metav1.ListOptions{})
+		assert.Nil(t, err)
+		assert.NotEmpty(t, daemonSets.Items)
+		for i := range daemonSets.Items {
+			assert.Nil(t, findContainer(&daemonSets.Items[i], constant.HealthMonitorAgentName))
+		}
+	})
+
+	t.Run("ClusterRoleBinding for User with name of node ServiceAccount", func(t *testing.T) {
+		ctx := context.Background()
+
+		userBinding := clusterRoleBinding.DeepCopy()
+		userBinding.Subjects[0] = rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName,
+			Name: "csi-node-sa", Namespace: "test-csi"}
+
+		eventRecorder := new(mocks.EventRecorder)
+		eventRecorder.On("Eventf", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+		scheme, _ := common.PrepareScheme()
+		csi := healthMonitorDeployment.DeepCopy()
+		node := prepareNode(eventRecorder, prepareNodeClientSet(),
+			prepareStatusClient(scheme, csi, userBinding, clusterRole.DeepCopy()))
+		err := node.Update(ctx, csi, scheme)
+		assert.Nil(t, err)
+		eventRecorder.AssertCalled(t, "Eventf", mock.Anything, mock.Anything, "HealthMonitorVerificationFailed",
+			mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Existing ClusterRole and ClusterRoleBinding for node ServiceAccount", func(t *testing.T) {
+		ctx := context.Background()
+
+		eventRecorder := new(mocks.EventRecorder)
+		scheme, _ := common.PrepareScheme()
+		node := prepareNode(eventRecorder, prepareNodeClientSet(),
+			prepareValidatorClient(scheme, clusterRoleBinding.DeepCopy(), clusterRole.DeepCopy()))
+		err := node.Update(ctx, healthMonitorDeployment.DeepCopy(), scheme)
+		assert.Nil(t, err)
+		eventRecorder.AssertNotCalled(t, "Eventf", mock.Anything, mock.Anything, mock.Anything,
+			mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
 func Test_updateNodeLabels(t *testing.T) {
 	t.Run("Should deploy default platform and label nodes", func(t *testing.T) {
 		var (
@@ -430,8 +536,14 @@ func prepareValidatorClient(scheme *runtime.Scheme, objects ...client.Object) cl
 	return builderWithScheme.WithObjects(objects...).Build()
 }
 
+func prepareStatusClient(scheme *runtime.Scheme, objects ...client.Object) client.Client {
+	return fakeClient.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).
+		WithStatusSubresource(&v1.Deployment{}).Build()
+}
+
 func prepareNode(eventRecorder events.EventRecorder, clientSet kubernetes.Interface, client client.Client) *Node {
 	return NewNode(clientSet,
+		client,
 		securityverifier.NewPodSecurityPolicyVerifier(
 			validator.NewValidator(rbac.NewValidator(client, logEntry, rbac.NewMatcher())),
 			eventRecorder,
@@ -444,6 +556,11 @@ func prepareNode(eventRecorder events.EventRecorder, clientSet kubernetes.Interf
 			matchSecurityContextConstraintsPolicies,
 			logEntry,
 		),
+		securityverifier.NewHealthMonitorVerifier(
+			validator.NewValidator(rbac.NewValidator(client, logEntry, rbac.NewMatcher())),
+			eventRecorder,
+			logEntry,
+		),
 		logEntry,
 	)
 }
@@ -51,10 +51,24 @@ func (pd *PlatformDescription) NodeImage(baseImage *components.Image) *component
 
 	taggedImage.Tag = baseImage.Tag
 	taggedImage.Name = createNameWithTag(baseImage.Name, pd.tag)
+	taggedImage.Registry = baseImage.Registry
+	// digest pins content of the base image, it doesn't match images of other platforms
+	if pd.tag == "" {
+		taggedImage.Digest = baseImage.Digest
+	}
 
 	return &taggedImage
 }
 
+// NodeImages returns images of node for all supported platforms
+func NodeImages(baseImage *components.Image) []*components.Image {
+	images := make([]*components.Image, 0, len(platforms))
+	for _, platform := range platforms {
+		images = append(images, platform.NodeImage(baseImage))
+	}
+	return images
+}
+
 // findPlatform calls checkVersion for all platforms in list,
 // returns first found platform-name or "default" if no one passed
 func findPlatform(kernelVersion *semver.Version) string {
@@ -0,0 +1,366 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dell/csi-baremetal/api/v1/nodecrd"
+	nodeconst "github.com/dell/csi-baremetal/pkg/crcontrollers/node/common"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+)
+
+const (
+	defaultWaveTimeout    = 10 * time.Minute
+	defaultMaxUnavailable = 1
+	upgradeCheckInterval  = 30 * time.Second
+
+	driveMgrContainerName = "drivemgr"
+)
+
+// Upgrade restarts csi-baremetal-node pods of outdated DaemonSet revision in waves.
+// Canary nodes are upgraded first, next wave is started when pods of the previous one pass health gates
+type Upgrade struct {
+	Clientset kubernetes.Interface
+	Client    client.Client
+	*logrus.Entry
+}
+
+// nodePod is csi-baremetal-node pod and the result of comparison with the current revision of its DaemonSet
+type nodePod struct {
+	pod     *corev1.Pod
+	updated bool
+}
+
+// Update checks health gates of the current wave and starts the next one, progress is reported in status of csi Deployment.
+// Returns RequeueError while upgrade is in progress
+func (u *Upgrade) Update(ctx context.Context, csi *csibaremetalv1.Deployment) error {
+	if !isUpgradeEnabled(csi) {
+		if csi.Status.NodeUpgrade == nil {
+			return nil
+		}
+		csi.Status.NodeUpgrade = nil
+		return u.Client.Status().Update(ctx, csi)
+	}
+
+	pods, err := u.getNodePods(ctx, csi.GetNamespace())
+	if err != nil {
+		return err
+	}
+
+	status := csi.Status.NodeUpgrade.DeepCopy()
+	if status == nil {
+		status = &csibaremetalv1.NodeUpgradeStatus{}
+	}
+	status.TotalNodes = int32(len(pods))
+	status.UpdatedNodes = 0
+	for _, p := range pods {
+		if p.updated {
+			status.UpdatedNodes++
+		}
+	}
+
+	upgradeErr := u.upgrade(ctx, csi, status, pods)
+	if _, ok := common.IsRequeueError(upgradeErr); upgradeErr != nil && !ok {
+		return upgradeErr
+	}
+
+	if !equality.Semantic.DeepEqual(csi.Status.NodeUpgrade, status) {
+		csi.Status.NodeUpgrade = status
+		if err = u.Client.Status().Update(ctx, csi); err != nil {
+			return err
+		}
+	}
+	return upgradeErr
+}
+
+// upgrade changes status according to the state of node pods
+func (u *Upgrade) upgrade(ctx context.Context, csi *csibaremetalv1.Deployment,
+	status *csibaremetalv1.NodeUpgradeStatus, pods map[string]nodePod) error {
+	config := csi.Spec.Driver.Node.Upgrade
+
+	if status.Phase == csibaremetalv1.NodeUpgradePaused {
+		if csi.GetAnnotations()[csibaremetalv1.ResumeNodeUpgradeAnnotation] != "true" {
+			u.Debugf("Upgrade of node pods is paused on wave %d", status.Wave)
+			return nil
+		}
+		if err := u.removeResumeAnnotation(ctx, csi); err != nil {
+			return err
+		}
+		u.Infof("Upgrade of node pods is resumed on wave %d", status.Wave)
+		status.Phase = csibaremetalv1.NodeUpgradeInProgress
+		status.WaveStartTime = &metav1.Time{Time: time.Now()}
+		status.Message = ""
+	}
+
+	if len(status.WaveNodes) != 0 {
+		failures, err := u.checkHealthGates(ctx, status.WaveNodes, pods)
+		if err != nil {
+			return err
+		}
+		if len(failures) != 0 {
+			status.Message = strings.Join(failures, "; ")
+			if status.WaveStartTime != nil && time.Since(status.WaveStartTime.Time) > getWaveTimeout(config) {
+				u.Warnf("Upgrade of node pods is paused on wave %d: %s", status.Wave, status.Message)
+				status.Phase = csibaremetalv1.NodeUpgradePaused
+				return nil
+			}
+			return common.NewRequeueError(upgradeCheckInterval,
+				fmt.Sprintf("wave %d of node upgrade is in progress", status.Wave))
+		}
+		u.Infof("Wave %d of node upgrade passed health gates", status.Wave)
+		status.WaveNodes = nil
+		status.WaveStartTime = nil
+		status.Message = ""
+	}
+
+	if status.UpdatedNodes == status.TotalNodes {
+		status.Phase = csibaremetalv1.NodeUpgradeCompleted
+		return nil
+	}
+
+	wave, err := u.selectWave(ctx, config, pods)
+	if err != nil {
+		return err
+	}
+	if len(wave) == 0 {
+		status.Phase = csibaremetalv1.NodeUpgradeInProgress
+		status.Message = "maxUnavailable node pods are not ready"
+		return common.NewRequeueError(upgradeCheckInterval, "node upgrade is waiting for not ready node pods")
+	}
+
+	for _, name := range wave {
+		pod := pods[name].pod
+		err = u.Clientset.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+		if err != nil {
+			u.Error(err, "Failed to delete pod "+pod.Name)
+			return err
+		}
+	}
+
+	// waves are counted from the beginning for the new revision
+	if status.Phase != csibaremetalv1.NodeUpgradeInProgress {
+		status.Wave = 0
+	}
+	status.Phase = csibaremetalv1.NodeUpgradeInProgress
+	status.Wave++
+	status.WaveNodes = wave
+	status.WaveStartTime = &metav1.Time{Time: time.Now()}
+	status.Message = ""
+	u.Infof("Wave %d of node upgrade is started: %s", status.Wave, strings.Join(wave, ", "))
+
+	return common.NewRequeueError(upgradeCheckInterval, fmt.Sprintf("wave %d of node upgrade is started", status.Wave))
+}
+
+// getNodePods returns csi-baremetal-node pods by node names
+func (u *Upgrade) getNodePods(ctx context.Context, namespace string) (map[string]nodePod, error) {
+	selector := GetNodeDaemonsetPodsSelector().String()
+
+	revisions, err := u.Clientset.AppsV1().ControllerRevisions(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	// the current revision of DaemonSet has the highest number
+	current := map[types.UID]*appsv1.ControllerRevision{}
+	for i := range revisions.Items {
+		revision := &revisions.Items[i]
+		owner := metav1.GetControllerOf(revision)
+		if owner == nil {
+			continue
+		}
+		if found, ok := current[owner.UID]; !ok || found.Revision < revision.Revision {
+			current[owner.UID] = revision
+		}
+	}
+
+	pods, err := u.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]nodePod{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		owner := metav1.GetControllerOf(pod)
+		if owner == nil || pod.Spec.NodeName == "" {
+			continue
+		}
+		revision, ok := current[owner.UID]
+		if !ok {
+			continue
+		}
+		// terminating pod is replaced with the new one on the same node
+		if found, ok := result[pod.Spec.NodeName]; ok && found.pod.DeletionTimestamp == nil {
+			continue
+		}
+		result[pod.Spec.NodeName] = nodePod{
+			pod:     pod,
+			updated: pod.Labels[appsv1.DefaultDaemonSetUniqueLabelKey] == revision.Labels[appsv1.DefaultDaemonSetUniqueLabelKey],
+		}
+	}
+	return result, nil
+}
+
+// checkHealthGates returns failed health gates of the nodes: node pod is updated and ready,
+// drivemgr container is ready and csibmnode with UUID of the node exists
+func (u *Upgrade) checkHealthGates(ctx context.Context, nodes []string, pods map[string]nodePod) ([]string, error) {
+	csibmnodes := &nodecrd.NodeList{}
+	if err := u.Client.List(ctx, csibmnodes); err != nil {
+		return nil, err
+	}
+	reporting := map[string]bool{}
+	for _, csibmnode := range csibmnodes.Items {
+		reporting[csibmnode.Spec.UUID] = true
+	}
+
+	k8sNodes, err := u.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	nodeIDs := map[string]string{}
+	for i := range k8sNodes.Items {
+		nodeIDs[k8sNodes.Items[i].Name] = getNodeID(&k8sNodes.Items[i])
+	}
+
+	var failures []string
+	for _, name := range nodes {
+		p, ok := pods[name]
+		switch {
+		case !ok:
+			failures = append(failures, fmt.Sprintf("node pod is not found on %s", name))
+		case !p.updated || p.pod.DeletionTimestamp != nil:
+			failures = append(failures, fmt.Sprintf("node pod on %s is not updated", name))
+		case !isPodReady(p.pod):
+			failures = append(failures, fmt.Sprintf("node pod on %s is not ready", name))
+		case !isContainerReady(p.pod, driveMgrContainerName):
+			failures = append(failures, fmt.Sprintf("drivemgr on %s is not ready", name))
+		case nodeIDs[name] == "" || !reporting[nodeIDs[name]]:
+			failures = append(failures, fmt.Sprintf("csibmnode of %s is not found", name))
+		}
+	}
+	return failures, nil
+}
+
+// selectWave returns outdated canary nodes if any, otherwise outdated nodes of one failure domain.
+// Not ready node pods reduce size of the wave
+func (u *Upgrade) selectWave(ctx context.Context, config *components.NodeUpgrade, pods map[string]nodePod) ([]string, error) {
+	var (
+		budget   = getMaxUnavailable(config)
+		outdated []string
+	)
+	for name, p := range pods {
+		if !isPodReady(p.pod) {
+			budget--
+		}
+		if !p.updated {
+			outdated = append(outdated, name)
+		}
+	}
+	if budget <= 0 {
+		return nil, nil
+	}
+	sort.Strings(outdated)
+
+	var wave []string
+	for _, name := range outdated {
+		if len(wave) == budget {
+			break
+		}
+		for _, canary := range config.CanaryNodes {
+			if name == canary {
+				wave = append(wave, name)
+				break
+			}
+		}
+	}
+	if len(wave) != 0 {
+		return wave, nil
+	}
+
+	domains := map[string]string{}
+	if config.FailureDomainLabel != "" {
+		nodes, err := u.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range nodes.Items {
+			domains[node.Name] = node.Labels[config.FailureDomainLabel]
+		}
+	}
+	sort.SliceStable(outdated, func(i, j int) bool {
+		return domains[outdated[i]] < domains[outdated[j]]
+	})
+
+	for _, name := range outdated {
+		if len(wave) == budget || domains[name] != domains[outdated[0]] {
+			break
+		}
+		wave = append(wave, name)
+	}
+	return wave, nil
+}
+
+// removeResumeAnnotation removes resume annotation to allow pausing of the upgrade again
+func (u *Upgrade) removeResumeAnnotation(ctx context.Context, csi *csibaremetalv1.Deployment) error {
+	patch := client.MergeFrom(csi.DeepCopy())
+	delete(csi.Annotations, csibaremetalv1.ResumeNodeUpgradeAnnotation)
+	return u.Client.Patch(ctx, csi, patch)
+}
+
+// getNodeID returns UUID of csibmnode, which is set by node-controller in annotation or label of the node
+func getNodeID(node *corev1.Node) string {
+	if id := node.GetAnnotations()[nodeconst.DeafultNodeIDAnnotationKey]; id != "" {
+		return id
+	}
+	return node.GetLabels()[nodeconst.NodeIDTopologyLabelKey]
+}
+
+func isUpgradeEnabled(csi *csibaremetalv1.Deployment) bool {
+	return csi.Spec.Driver.Node.Upgrade != nil && csi.Spec.Driver.Node.Upgrade.Enable
+}
+
+func getWaveTimeout(config *components.NodeUpgrade) time.Duration {
+	if config.WaveTimeout != "" {
+		if timeout, err := time.ParseDuration(config.WaveTimeout); err == nil {
+			return timeout
+		}
+	}
+	return defaultWaveTimeout
+}
+
+func getMaxUnavailable(config *components.NodeUpgrade) int {
+	if config.MaxUnavailable > 0 {
+		return int(config.MaxUnavailable)
+	}
+	return defaultMaxUnavailable
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func isContainerReady(pod *corev1.Pod, name string) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == name {
+			return status.Ready
+		}
+	}
+	return false
+}
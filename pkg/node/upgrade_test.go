@@ -0,0 +1,284 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dell/csi-baremetal/api/v1/nodecrd"
+	nodeconst "github.com/dell/csi-baremetal/pkg/crcontrollers/node/common"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+)
+
+const (
+	oldRevision = "old"
+	newRevision = "new"
+	zoneLabel   = "topology.kubernetes.io/zone"
+)
+
+var nodeDaemonSet = &appsv1.DaemonSet{
+	ObjectMeta: metav1.ObjectMeta{Name: "csi-baremetal-node", Namespace: testNS, UID: types.UID("node-ds")},
+}
+
+func prepareUpgradeDeployment(upgrade *components.NodeUpgrade, status *v1.NodeUpgradeStatus) *v1.Deployment {
+	csi := prepareNodeConfigDeployment(nil)
+	csi.Spec.Driver.Node.Upgrade = upgrade
+	csi.Status.NodeUpgrade = status
+	return csi
+}
+
+func prepareRevision(hash string, revision int64) *appsv1.ControllerRevision {
+	labels := common.ConstructLabelMap(nodeName, node)
+	labels[appsv1.DefaultDaemonSetUniqueLabelKey] = hash
+	return &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "csi-baremetal-node-" + hash,
+			Namespace:       testNS,
+			Labels:          labels,
+			OwnerReferences: []metav1.OwnerReference{ownerReference()},
+		},
+		Revision: revision,
+	}
+}
+
+func prepareNodePod(nodeName, hash string, ready bool) *coreV1.Pod {
+	labels := common.ConstructLabelMap("csi-baremetal-node", node)
+	labels[appsv1.DefaultDaemonSetUniqueLabelKey] = hash
+	status := coreV1.ConditionFalse
+	if ready {
+		status = coreV1.ConditionTrue
+	}
+	return &coreV1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "csi-baremetal-node-" + nodeName,
+			Namespace:       testNS,
+			Labels:          labels,
+			OwnerReferences: []metav1.OwnerReference{ownerReference()},
+		},
+		Spec: coreV1.PodSpec{NodeName: nodeName},
+		Status: coreV1.PodStatus{
+			Conditions:        []coreV1.PodCondition{{Type: coreV1.PodReady, Status: status}},
+			ContainerStatuses: []coreV1.ContainerStatus{{Name: driveMgrContainerName, Ready: ready}},
+		},
+	}
+}
+
+func prepareK8sNode(name, zone string) *coreV1.Node {
+	return &coreV1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Labels:      map[string]string{zoneLabel: zone},
+		Annotations: map[string]string{nodeconst.DeafultNodeIDAnnotationKey: "uuid-" + name},
+	}}
+}
+
+func prepareCSIBMNode(name string) *nodecrd.Node {
+	csibmnode := &nodecrd.Node{ObjectMeta: metav1.ObjectMeta{Name: "csibmnode-" + name}}
+	csibmnode.Spec.UUID = "uuid-" + name
+	// hostname address may differ from node name, it isn't used to match csibmnode
+	csibmnode.Spec.Addresses = map[string]string{string(coreV1.NodeHostName): name + ".local"}
+	return csibmnode
+}
+
+func ownerReference() metav1.OwnerReference {
+	return metav1.OwnerReference{APIVersion: "apps/v1", Kind: "DaemonSet", Name: nodeDaemonSet.Name,
+		UID: nodeDaemonSet.UID, Controller: &[]bool{true}[0]}
+}
+
+func prepareUpgrade(csi *v1.Deployment, objects []runtime.Object, csibmnodes ...client.Object) *Upgrade {
+	scheme, _ := common.PrepareScheme()
+	objects = append(objects, prepareRevision(oldRevision, 1), prepareRevision(newRevision, 2))
+	cl := fakeClient.NewClientBuilder().WithScheme(scheme).WithObjects(append(csibmnodes, csi)...).
+		WithStatusSubresource(&v1.Deployment{}).Build()
+	return &Upgrade{Clientset: prepareNodeClientSet(objects...), Client: cl, Entry: logEntry}
+}
+
+func getPodNames(t *testing.T, upgrade *Upgrade) []string {
+	pods, err := upgrade.Clientset.CoreV1().Pods(testNS).List(context.Background(), metav1.ListOptions{})
+	assert.Nil(t, err)
+	var names []string
+	for _, pod := range pods.Items {
+		names = append(names, pod.Spec.NodeName)
+	}
+	return names
+}
+
+func Test_Upgrade_Update(t *testing.T) {
+	t.Run("Canary nodes are upgraded first", func(t *testing.T) {
+		csi := prepareUpgradeDeployment(&components.NodeUpgrade{Enable: true, CanaryNodes: []string{"node-3"}}, nil)
+		upgrade := prepareUpgrade(csi, []runtime.Object{
+			prepareNodePod("node-1", oldRevision, true),
+			prepareNodePod("node-2", oldRevision, true),
+			prepareNodePod("node-3", oldRevision, true),
+		})
+
+		err := upgrade.Update(context.Background(), csi)
+		_, ok := common.IsRequeueError(err)
+		assert.True(t, ok)
+
+		assert.ElementsMatch(t, []string{"node-1", "node-2"}, getPodNames(t, upgrade))
+		assert.Equal(t, v1.NodeUpgradeInProgress, csi.Status.NodeUpgrade.Phase)
+		assert.Equal(t, int32(1), csi.Status.NodeUpgrade.Wave)
+		assert.Equal(t, []string{"node-3"}, csi.Status.NodeUpgrade.WaveNodes)
+		assert.Equal(t, int32(3), csi.Status.NodeUpgrade.TotalNodes)
+		assert.Equal(t, int32(0), csi.Status.NodeUpgrade.UpdatedNodes)
+	})
+
+	t.Run("Canary wave respects maxUnavailable", func(t *testing.T) {
+		csi := prepareUpgradeDeployment(&components.NodeUpgrade{Enable: true, MaxUnavailable: 2,
+			CanaryNodes: []string{"node-1", "node-2", "node-3"}}, nil)
+		upgrade := prepareUpgrade(csi, []runtime.Object{
+			prepareNodePod("node-1", oldRevision, true),
+			prepareNodePod("node-2", oldRevision, true),
+			prepareNodePod("node-3", oldRevision, true),
+		})
+
+		err := upgrade.Update(context.Background(), csi)
+		_, ok := common.IsRequeueError(err)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"node-1", "node-2"}, csi.Status.NodeUpgrade.WaveNodes)
+		assert.Equal(t, []string{"node-3"}, getPodNames(t, upgrade))
+	})
+
+	t.Run("Wave contains nodes of one failure domain", func(t *testing.T) {
+		csi := prepareUpgradeDeployment(&components.NodeUpgrade{Enable: true, MaxUnavailable: 2,
+			FailureDomainLabel: zoneLabel}, nil)
+		upgrade := prepareUpgrade(csi, []runtime.Object{
+			prepareK8sNode("node-1", "zone-b"),
+			prepareK8sNode("node-2", "zone-a"),
+			prepareK8sNode("node-3", "zone-a"),
+			prepareK8sNode("node-4", "zone-a"),
+			prepareNodePod("node-1", oldRevision, true),
+			prepareNodePod("node-2", oldRevision, true),
+			prepareNodePod("node-3", oldRevision, true),
+			prepareNodePod("node-4", oldRevision, true),
+		})
+
+		err := upgrade.Update(context.Background(), csi)
+		_, ok := common.IsRequeueError(err)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"node-2", "node-3"}, csi.Status.NodeUpgrade.WaveNodes)
+		assert.ElementsMatch(t, []string{"node-1", "node-4"}, getPodNames(t, upgrade))
+	})
+
+	t.Run("Not ready pods reduce wave", func(t *testing.T) {
+		csi := prepareUpgradeDeployment(&components.NodeUpgrade{Enable: true}, nil)
+		upgrade := prepareUpgrade(csi, []runtime.Object{
+			prepareNodePod("node-1", oldRevision, false),
+			prepareNodePod("node-2", oldRevision, true),
+		})
+
+		err := upgrade.Update(context.Background(), csi)
+		_, ok := common.IsRequeueError(err)
+		assert.True(t, ok)
+		assert.Len(t, getPodNames(t, upgrade), 2)
+		assert.Empty(t, csi.Status.NodeUpgrade.WaveNodes)
+		assert.Equal(t, int32(0), csi.Status.NodeUpgrade.Wave)
+	})
+
+	t.Run("Next wave is started when health gates are passed", func(t *testing.T) {
+		csi := prepareUpgradeDeployment(&components.NodeUpgrade{Enable: true}, &v1.NodeUpgradeStatus{
+			Phase:         v1.NodeUpgradeInProgress,
+			Wave:          1,
+			WaveNodes:     []string{"node-1"},
+			WaveStartTime: &metav1.Time{Time: time.Now()},
+		})
+		upgrade := prepareUpgrade(csi, []runtime.Object{
+			prepareK8sNode("node-1", ""),
+			prepareNodePod("node-1", newRevision, true),
+			prepareNodePod("node-2", oldRevision, true),
+		}, prepareCSIBMNode("node-1"))
+
+		err := upgrade.Update(context.Background(), csi)
+		_, ok := common.IsRequeueError(err)
+		assert.True(t, ok)
+		assert.Equal(t, int32(2), csi.Status.NodeUpgrade.Wave)
+		assert.Equal(t, []string{"node-2"}, csi.Status.NodeUpgrade.WaveNodes)
+		assert.Equal(t, int32(1), csi.Status.NodeUpgrade.UpdatedNodes)
+		assert.Equal(t, []string{"node-1"}, getPodNames(t, upgrade))
+	})
+
+	t.Run("Upgrade is paused if health gates are failed", func(t *testing.T) {
+		csi := prepareUpgradeDeployment(&components.NodeUpgrade{Enable: true, WaveTimeout: "1m"}, &v1.NodeUpgradeStatus{
+			Phase:         v1.NodeUpgradeInProgress,
+			Wave:          1,
+			WaveNodes:     []string{"node-1"},
+			WaveStartTime: &metav1.Time{Time: time.Now().Add(-2 * time.Minute)},
+		})
+		upgrade := prepareUpgrade(csi, []runtime.Object{
+			prepareNodePod("node-1", newRevision, true),
+			prepareNodePod("node-2", oldRevision, true),
+		})
+
+		assert.Nil(t, upgrade.Update(context.Background(), csi))
+		assert.Equal(t, v1.NodeUpgradePaused, csi.Status.NodeUpgrade.Phase)
+		assert.Equal(t, "csibmnode of node-1 is not found", csi.Status.NodeUpgrade.Message)
+		assert.Len(t, getPodNames(t, upgrade), 2)
+
+		// paused upgrade isn't continued without resume annotation
+		assert.Nil(t, upgrade.Update(context.Background(), csi))
+		assert.Equal(t, v1.NodeUpgradePaused, csi.Status.NodeUpgrade.Phase)
+	})
+
+	t.Run("Paused upgrade is resumed", func(t *testing.T) {
+		csi := prepareUpgradeDeployment(&components.NodeUpgrade{Enable: true}, &v1.NodeUpgradeStatus{
+			Phase:         v1.NodeUpgradePaused,
+			Wave:          1,
+			WaveNodes:     []string{"node-1"},
+			WaveStartTime: &metav1.Time{Time: time.Now().Add(-time.Hour)},
+		})
+		csi.Annotations = map[string]string{v1.ResumeNodeUpgradeAnnotation: "true"}
+		upgrade := prepareUpgrade(csi, []runtime.Object{
+			prepareNodePod("node-1", newRevision, false),
+			prepareNodePod("node-2", oldRevision, true),
+		})
+
+		err := upgrade.Update(context.Background(), csi)
+		_, ok := common.IsRequeueError(err)
+		assert.True(t, ok)
+		assert.Equal(t, v1.NodeUpgradeInProgress, csi.Status.NodeUpgrade.Phase)
+		assert.Equal(t, "node pod on node-1 is not ready", csi.Status.NodeUpgrade.Message)
+
+		found := &v1.Deployment{}
+		assert.Nil(t, upgrade.Client.Get(context.Background(), client.ObjectKeyFromObject(csi), found))
+		assert.NotContains(t, found.Annotations, v1.ResumeNodeUpgradeAnnotation)
+	})
+
+	t.Run("Upgrade is completed", func(t *testing.T) {
+		csi := prepareUpgradeDeployment(&components.NodeUpgrade{Enable: true}, nil)
+		upgrade := prepareUpgrade(csi, []runtime.Object{
+			prepareNodePod("node-1", newRevision, true),
+			prepareNodePod("node-2", newRevision, true),
+		})
+
+		assert.Nil(t, upgrade.Update(context.Background(), csi))
+		assert.Equal(t, v1.NodeUpgradeCompleted, csi.Status.NodeUpgrade.Phase)
+		assert.Equal(t, int32(2), csi.Status.NodeUpgrade.UpdatedNodes)
+	})
+
+	t.Run("Status is removed if upgrade is disabled", func(t *testing.T) {
+		csi := prepareUpgradeDeployment(nil, &v1.NodeUpgradeStatus{Phase: v1.NodeUpgradeCompleted})
+		upgrade := prepareUpgrade(csi, nil)
+
+		assert.Nil(t, upgrade.Update(context.Background(), csi))
+		assert.Nil(t, csi.Status.NodeUpgrade)
+	})
+}
+
+func Test_createNodeUpdateStrategy(t *testing.T) {
+	csi := prepareUpgradeDeployment(nil, nil)
+	assert.Equal(t, appsv1.RollingUpdateDaemonSetStrategyType, createNodeUpdateStrategy(csi).Type)
+
+	csi.Spec.Driver.Node.Upgrade = &components.NodeUpgrade{Enable: true}
+	assert.Equal(t, appsv1.OnDeleteDaemonSetStrategyType, createNodeUpdateStrategy(csi).Type)
+}
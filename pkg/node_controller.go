@@ -2,6 +2,7 @@ package pkg
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/apps/v1"
@@ -39,6 +40,10 @@ func (nc *NodeController) Update(ctx context.Context, csi *csibaremetalv1.Deploy
 		return err
 	}
 
+	if err := common.AddConfigHashAnnotation(ctx, nc.Clientset, csi, &expected.Spec.Template); err != nil {
+		return err
+	}
+
 	if err := common.UpdateDeployment(ctx, nc.Clientset, expected, nc.Entry); err != nil {
 		return err
 	}
@@ -47,7 +52,7 @@ func (nc *NodeController) Update(ctx context.Context, csi *csibaremetalv1.Deploy
 }
 
 func createNodeControllerDeployment(csi *csibaremetalv1.Deployment) *v1.Deployment {
-	return &v1.Deployment{
+	deployment := &v1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      nodeControllerName,
 			Namespace: csi.GetNamespace(),
@@ -64,6 +69,8 @@ func createNodeControllerDeployment(csi *csibaremetalv1.Deployment) *v1.Deployme
 				ObjectMeta: metav1.ObjectMeta{
 					// labels
 					Labels: common.ConstructLabelMap(nodeControllerName, nodeController),
+					// integration with monitoring
+					Annotations: common.MetricsAnnotations(getNodeControllerMetricsPort(csi), constant.PrometheusPath),
 				},
 				Spec: corev1.PodSpec{
 					Containers:                    createNodeControllerContainers(csi),
@@ -80,6 +87,10 @@ func createNodeControllerDeployment(csi *csibaremetalv1.Deployment) *v1.Deployme
 			},
 		},
 	}
+
+	common.ApplyPodOverrides(&deployment.Spec.Template, csi.Spec.NodeController.PodOverrides)
+
+	return deployment
 }
 
 func createNodeControllerContainers(csi *csibaremetalv1.Deployment) []corev1.Container {
@@ -98,10 +109,14 @@ func createNodeControllerContainers(csi *csibaremetalv1.Deployment) []corev1.Con
 	if ns != nil {
 		args = append(args, "--nodeselector="+ns.Key+":"+ns.Value)
 	}
+	// arg is set only with configured port, node-controller images without metrics-addr flag listen on default one
+	if metrics := csi.Spec.NodeController.Metrics; metrics != nil && metrics.Port != 0 {
+		args = append(args, "--metrics-addr=:"+strconv.Itoa(int(metrics.Port)))
+	}
 	return []corev1.Container{
 		{
 			Name:            nodeController,
-			Image:           common.ConstructFullImageName(image, csi.Spec.GlobalRegistry),
+			Image:           common.ConstructFullImageName(image, csi),
 			ImagePullPolicy: corev1.PullPolicy(csi.Spec.PullPolicy),
 			Args:            args,
 			Env: []corev1.EnvVar{
@@ -112,7 +127,18 @@ func createNodeControllerContainers(csi *csibaremetalv1.Deployment) []corev1.Con
 			TerminationMessagePath:   constant.TerminationMessagePath,
 			TerminationMessagePolicy: constant.TerminationMessagePolicy,
 			VolumeMounts:             []corev1.VolumeMount{constant.CrashMountVolume},
-			Resources:                common.ConstructResourceRequirements(resources),
+			Ports: []corev1.ContainerPort{
+				{Name: "metrics", ContainerPort: getNodeControllerMetricsPort(csi), Protocol: corev1.ProtocolTCP},
+			},
+			Resources: common.ConstructResourceRequirements(resources),
 		},
 	}
 }
+
+// getNodeControllerMetricsPort returns metrics port of node-controller from csi Deployment or the default one
+func getNodeControllerMetricsPort(csi *csibaremetalv1.Deployment) int32 {
+	if csi.Spec.NodeController != nil && csi.Spec.NodeController.Metrics != nil && csi.Spec.NodeController.Metrics.Port != 0 {
+		return int32(csi.Spec.NodeController.Metrics.Port)
+	}
+	return constant.NodeControllerMetricsPort
+}
@@ -73,3 +73,22 @@ func prepareNode(clientSet kubernetes.Interface) *NodeController {
 func prepareNodeClientSet(objects ...runtime.Object) kubernetes.Interface {
 	return fake.NewSimpleClientset(objects...)
 }
+
+func Test_createNodeControllerContainers_Metrics(t *testing.T) {
+	deployment := testDeployment.DeepCopy()
+	deployment.Spec.NodeController = &components.NodeController{
+		Log:   &components.Log{Level: "debug"},
+		Image: &components.Image{Name: "test"},
+	}
+
+	container := createNodeControllerContainers(deployment)[0]
+	assert.Equal(t, int32(constant.NodeControllerMetricsPort), container.Ports[0].ContainerPort)
+	for _, arg := range container.Args {
+		assert.NotContains(t, arg, "--metrics-addr")
+	}
+
+	deployment.Spec.NodeController.Metrics = &components.Metrics{Port: 8787}
+	container = createNodeControllerContainers(deployment)[0]
+	assert.Equal(t, int32(8787), container.Ports[0].ContainerPort)
+	assert.Contains(t, container.Args, "--metrics-addr=:8787")
+}
@@ -113,6 +113,7 @@ func (c *Controller) handleNodeRemoval(ctx context.Context, csibmnodes []nodecrd
 	var (
 		errors        []string
 		removingNodes []nodecrd.Node
+		removalCount  int
 	)
 
 	isNodesTainted := getMapIsNodesTainted(nodes, rTaint)
@@ -128,6 +129,9 @@ func (c *Controller) handleNodeRemoval(ctx context.Context, csibmnodes []nodecrd
 		}
 
 		hasTaint, hasNode = isNodesTainted[getNodeName(&csibmnodes[i])]
+		if hasLabel || hasTaint {
+			removalCount++
+		}
 
 		// perform node removal
 		if hasLabel && !hasNode {
@@ -155,6 +159,8 @@ func (c *Controller) handleNodeRemoval(ctx context.Context, csibmnodes []nodecrd
 		}
 	}
 
+	nodesInRemoval.Set(float64(removalCount))
+
 	if len(errors) != 0 {
 		return fmt.Errorf(strings.Join(errors, "\n"))
 	}
@@ -260,9 +266,11 @@ func (c *Controller) handleNodeMaintenance(ctx context.Context, nodes []corev1.N
 	)
 
 	logStart := true
+	maintenanceCount := 0
 
 	for i, node := range nodes {
 		if hasTaint(&nodes[i], mTaint) {
+			maintenanceCount++
 			if logStart {
 				c.log.Debug("Starting Node Maintenance")
 				logStart = false
@@ -272,6 +280,7 @@ func (c *Controller) handleNodeMaintenance(ctx context.Context, nodes []corev1.N
 			}
 		}
 	}
+	nodesInMaintenance.Set(float64(maintenanceCount))
 
 	if len(errors) != 0 {
 		return fmt.Errorf(strings.Join(errors, "\n"))
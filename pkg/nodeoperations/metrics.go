@@ -0,0 +1,34 @@
+package nodeoperations
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	metricsSubsystem = "node_operations"
+)
+
+var (
+	// nodesInMaintenance reports number of nodes with maintenance taint
+	nodesInMaintenance = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: constant.MetricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "maintenance_nodes",
+		Help:      "Number of nodes under maintenance",
+	})
+
+	// nodesInRemoval reports number of csibmnodes with removal taint or label
+	nodesInRemoval = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: constant.MetricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "removal_nodes",
+		Help:      "Number of nodes under removal",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(nodesInMaintenance, nodesInRemoval)
+}
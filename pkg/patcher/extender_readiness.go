@@ -7,6 +7,7 @@ import (
 
 	"gopkg.in/yaml.v3"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -14,6 +15,7 @@ import (
 	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
 	"github.com/dell/csi-baremetal-operator/pkg/common"
 	"github.com/dell/csi-baremetal-operator/pkg/constant"
+	"github.com/dell/csi-baremetal-operator/pkg/controlplane"
 )
 
 // TODO import from csi-baremetal - https://github.com/dell/csi-baremetal/issues/475
@@ -25,10 +27,18 @@ const (
 	// ExtenderConfigMapFile - ExtenderConfigMap data key
 	ExtenderConfigMapFile = "nodes.yaml"
 
-	K8sMasterNodeLabelKey = "node-role.kubernetes.io/master"
-
 	readinessCheckIntervalForOpenshiftSecondaryScheduler = 10 * time.Second
-	readinessMaxRetiresForOpenshiftSecondaryScheduler    = 12
+	readinessMaxWaitForOpenshiftSecondaryScheduler       = 2 * time.Minute
+
+	// SecondarySchedulerWaitingReason is set for SecondarySchedulerReady condition
+	// while operator waits for Openshift Secondary Scheduler restart
+	SecondarySchedulerWaitingReason = "WaitingForRestart"
+	// SecondarySchedulerRestartedReason is set for SecondarySchedulerReady condition
+	// when Openshift Secondary Scheduler is restarted with csi-baremetal extender
+	SecondarySchedulerRestartedReason = "Restarted"
+	// SecondarySchedulerTimedOutReason is set for SecondarySchedulerReady condition
+	// if Openshift Secondary Scheduler isn't restarted in time
+	SecondarySchedulerTimedOutReason = "RestartTimedOut"
 )
 
 // ExtenderReadinessOptions contains options to deploy ExtenderConfigMap
@@ -50,11 +60,26 @@ type ReadinessStatus struct {
 	NodeName      string `yaml:"node_name"`
 	KubeScheduler string `yaml:"kube_scheduler"`
 	Restarted     bool   `yaml:"restarted"`
+
+	// Patched is true if kube-scheduler on the master uses csi-baremetal configuration
+	Patched bool `yaml:"patched"`
+	// ExtenderReachable is true if scheduler extender on the master responds to filter requests
+	ExtenderReachable bool `yaml:"extender_reachable"`
+	// Healthy is true if kube-scheduler is patched, restarted, ready and can reach the extender
+	Healthy bool `yaml:"healthy"`
+	// Retries is the number of patching retries performed for the master
+	Retries int `yaml:"retries,omitempty"`
+	// LastRetryTime is the time of the last patching retry for the master
+	LastRetryTime time.Time `yaml:"last_retry_time,omitempty"`
 }
 
 // ReadinessStatusList contains statuses of all kube-schedulers in cluster
 type ReadinessStatusList struct {
 	Items []ReadinessStatus `yaml:"nodes"`
+	// RolledBack is true if the original scheduler configuration was restored after patching retries were exhausted
+	RolledBack bool `yaml:"rolled_back,omitempty"`
+	// ObservedGeneration is the csi Deployment generation the retry counters belong to
+	ObservedGeneration int64 `yaml:"observed_generation,omitempty"`
 }
 
 // NewExtenderReadinessOptions creates ExtenderReadinessOptions
@@ -143,16 +168,27 @@ func (p *SchedulerPatcher) UpdateReadinessConfigMap(ctx context.Context, csi *cs
 	if err != nil {
 		return err
 	}
+	// csi-baremetal extender could be merged into 3rd-party secondary scheduler config
+	if useOpenshiftSecondaryScheduler {
+		if options.watchedConfigMapName, err = p.getSecondarySchedulerConfigName(ctx); err != nil {
+			return err
+		}
+	}
 
 	cmCreationTime, err := p.getConfigMapCreationTime(ctx, options)
 	if err != nil {
 		return err
 	}
 
+	previousStatuses, err := p.getReadinessStatusList(ctx, options)
+	if err != nil {
+		return err
+	}
+
 	var readinessStatuses *ReadinessStatusList
 	if useOpenshiftSecondaryScheduler {
-		readinessStatuses, err = p.updateReadinessStatusesForOpenshiftSecondaryScheduler(ctx, options.kubeSchedulerLabel,
-			cmCreationTime, readinessCheckIntervalForOpenshiftSecondaryScheduler, readinessMaxRetiresForOpenshiftSecondaryScheduler)
+		readinessStatuses, err = p.updateReadinessStatusesForOpenshiftSecondaryScheduler(ctx, csi, options.kubeSchedulerLabel,
+			cmCreationTime, readinessCheckIntervalForOpenshiftSecondaryScheduler, readinessMaxWaitForOpenshiftSecondaryScheduler)
 	} else {
 		readinessStatuses, err = p.updateReadinessStatuses(ctx, options.kubeSchedulerLabel, cmCreationTime)
 	}
@@ -160,6 +196,15 @@ func (p *SchedulerPatcher) UpdateReadinessConfigMap(ctx context.Context, csi *cs
 		return err
 	}
 
+	if err = p.updateMastersState(ctx, csi, options, readinessStatuses, previousStatuses, useOpenshiftSecondaryScheduler); err != nil {
+		return err
+	}
+
+	// Retry patching procedure on masters, which are not healthy after readiness-timeout,
+	// 	and restore original configuration if retries are exhausted
+	retryErr := p.handleUnhealthyMasters(ctx, csi, scheme, readinessStatuses, cmCreationTime, useOpenshiftSecondaryScheduler)
+	recordReadinessStatuses(readinessStatuses)
+
 	expected, err := createReadinessConfigMap(options, readinessStatuses)
 	if err != nil {
 		return err
@@ -173,24 +218,7 @@ func (p *SchedulerPatcher) UpdateReadinessConfigMap(ctx context.Context, csi *cs
 		return err
 	}
 
-	// Retry patching procedure if extenders is not ready and
-	// 	passed readiness-timeout after configmap creation
-	isTimeoutPassed := cmCreationTime.Time.Before(time.Now().Add(time.Minute * time.Duration(-csi.Spec.Scheduler.Patcher.ReadinessTimeout)))
-	if !isAllReady(readinessStatuses) && isTimeoutPassed {
-		p.Log.Info("Retry patching")
-		switch csi.Spec.Platform {
-		case constant.PlatformOpenShift:
-			err = p.retryPatchOpenshift(ctx, csi, useOpenshiftSecondaryScheduler, scheme)
-			return err
-		case constant.PlatformVanilla, constant.PlatformRKE:
-			err = p.retryPatchVanilla(ctx, csi, scheme)
-			return err
-		default:
-			return fmt.Errorf("%s platform is not supported platform for the patcher", csi.Spec.Platform)
-		}
-	}
-
-	return nil
+	return retryErr
 }
 
 func (p *SchedulerPatcher) getConfigMapCreationTime(ctx context.Context, options *ExtenderReadinessOptions) (metav1.Time, error) {
@@ -199,26 +227,49 @@ func (p *SchedulerPatcher) getConfigMapCreationTime(ctx context.Context, options
 		return metav1.Time{}, err
 	}
 
-	return config.GetCreationTimestamp(), nil
+	return getMergedAt(config), nil
 }
 
-func (p *SchedulerPatcher) updateReadinessStatusesForOpenshiftSecondaryScheduler(ctx context.Context, kubeSchedulerLabel string,
-	cmCreationTime metav1.Time, checkInterval time.Duration, numRetries int) (*ReadinessStatusList, error) {
-	readinessStatuses := &ReadinessStatusList{}
-	var err error
-	// keep checking until ready
-	for i := 0; i < numRetries; i++ {
-		readinessStatuses, err = p.updateReadinessStatuses(ctx, kubeSchedulerLabel, cmCreationTime)
-		if err != nil {
+// updateReadinessStatusesForOpenshiftSecondaryScheduler checks Openshift Secondary Scheduler restart without blocking.
+// The wait is recorded in SecondarySchedulerReady condition of csi Deployment, RequeueError is returned
+// until the scheduler is restarted or maxWait is passed. Timed out wait is started again only for the new generation
+// of csi Deployment. Reconcile is resumed by Pod events or after checkInterval
+func (p *SchedulerPatcher) updateReadinessStatusesForOpenshiftSecondaryScheduler(ctx context.Context, csi *csibaremetalv1.Deployment,
+	kubeSchedulerLabel string, cmCreationTime metav1.Time, checkInterval time.Duration, maxWait time.Duration) (*ReadinessStatusList, error) {
+	readinessStatuses, err := p.updateReadinessStatuses(ctx, kubeSchedulerLabel, cmCreationTime)
+	if err != nil {
+		return nil, err
+	}
+	readiness := len(readinessStatuses.Items) == 1 && readinessStatuses.Items[0].Restarted
+	p.Log.Infof("Number of Openshift Secondary Scheduler Pods: %d", len(readinessStatuses.Items))
+	p.Log.Infof("Readiness of Openshift Secondary Scheduler Extender: %t", readiness)
+
+	condition := meta.FindStatusCondition(csi.Status.Conditions, csibaremetalv1.SecondarySchedulerReadyCondition)
+	switch {
+	case readiness:
+		err = p.setSecondarySchedulerCondition(ctx, csi, metav1.ConditionTrue, SecondarySchedulerRestartedReason,
+			"Openshift Secondary Scheduler is restarted with csi-baremetal extender")
+	case condition != nil && condition.Reason == SecondarySchedulerTimedOutReason &&
+		condition.ObservedGeneration == csi.GetGeneration():
+		// timed out wait isn't started again until spec of csi Deployment is changed
+		p.Log.Debugf("Openshift Secondary Scheduler is not restarted in %s, the wait isn't repeated", maxWait)
+	case condition == nil || condition.Reason != SecondarySchedulerWaitingReason:
+		// start waiting, transition time of the condition is the beginning of the wait
+		meta.RemoveStatusCondition(&csi.Status.Conditions, csibaremetalv1.SecondarySchedulerReadyCondition)
+		if err = p.setSecondarySchedulerCondition(ctx, csi, metav1.ConditionFalse, SecondarySchedulerWaitingReason,
+			"Waiting for Openshift Secondary Scheduler restart"); err != nil {
 			return nil, err
 		}
-		readiness := len(readinessStatuses.Items) == 1 && readinessStatuses.Items[0].Restarted
-		if readiness {
-			break
-		}
-		p.Log.Infof("Number of Openshift Secondary Scheduler Pods: %d", len(readinessStatuses.Items))
-		p.Log.Infof("Readiness of Openshift Secondary Scheduler Extender: %t", readiness)
-		<-time.After(checkInterval)
+		return nil, common.NewRequeueError(checkInterval, "waiting for Openshift Secondary Scheduler restart")
+	case time.Since(condition.LastTransitionTime.Time) < maxWait:
+		return nil, common.NewRequeueError(checkInterval, "waiting for Openshift Secondary Scheduler restart")
+	default:
+		p.Log.Warnf("Openshift Secondary Scheduler is not restarted in %s", maxWait)
+		err = p.setSecondarySchedulerCondition(ctx, csi, metav1.ConditionFalse, SecondarySchedulerTimedOutReason,
+			fmt.Sprintf("Openshift Secondary Scheduler is not restarted in %s", maxWait))
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	var readinessScheduler string
@@ -227,11 +278,11 @@ func (p *SchedulerPatcher) updateReadinessStatusesForOpenshiftSecondaryScheduler
 	}
 
 	readinessStatuses = &ReadinessStatusList{}
-	masterNodes, err := p.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: K8sMasterNodeLabelKey})
+	masterNodes, err := controlplane.ListNodes(ctx, p.Clientset, csi.Spec.Scheduler.ControlPlaneSelector)
 	if err != nil {
 		return nil, err
 	}
-	for _, node := range masterNodes.Items {
+	for _, node := range masterNodes {
 		readinessStatuses.Items = append(readinessStatuses.Items, ReadinessStatus{
 			KubeScheduler: readinessScheduler,
 			NodeName:      node.Name,
@@ -241,6 +292,23 @@ func (p *SchedulerPatcher) updateReadinessStatusesForOpenshiftSecondaryScheduler
 	return readinessStatuses, err
 }
 
+// setSecondarySchedulerCondition updates SecondarySchedulerReady condition of csi Deployment if it was changed
+func (p *SchedulerPatcher) setSecondarySchedulerCondition(ctx context.Context, csi *csibaremetalv1.Deployment,
+	status metav1.ConditionStatus, reason, message string) error {
+	changed := meta.SetStatusCondition(&csi.Status.Conditions, metav1.Condition{
+		Type:               csibaremetalv1.SecondarySchedulerReadyCondition,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: csi.GetGeneration(),
+	})
+	if !changed {
+		return nil
+	}
+
+	return p.Client.Status().Update(ctx, csi)
+}
+
 func (p *SchedulerPatcher) updateReadinessStatuses(ctx context.Context, kubeSchedulerLabel string, cmCreationTime metav1.Time) (*ReadinessStatusList, error) {
 	readinessStatuses := &ReadinessStatusList{}
 
@@ -280,16 +348,6 @@ func (p *SchedulerPatcher) updateReadinessStatuses(ctx context.Context, kubeSche
 	return readinessStatuses, nil
 }
 
-func isAllReady(statuses *ReadinessStatusList) bool {
-	for _, status := range statuses.Items {
-		if !status.Restarted {
-			return false
-		}
-	}
-
-	return true
-}
-
 func createReadinessConfigMap(options *ExtenderReadinessOptions, statuses *ReadinessStatusList) (*corev1.ConfigMap, error) {
 	data, err := yaml.Marshal(statuses)
 	if err != nil {
@@ -16,6 +16,7 @@ import (
 	"gopkg.in/yaml.v3"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
@@ -27,6 +28,7 @@ import (
 	"github.com/dell/csi-baremetal-operator/api/v1/components"
 	"github.com/dell/csi-baremetal-operator/pkg/common"
 	"github.com/dell/csi-baremetal-operator/pkg/constant"
+	"github.com/dell/csi-baremetal-operator/pkg/controlplane"
 	"github.com/dell/csi-baremetal-operator/pkg/feature/security_verifier"
 	"github.com/dell/csi-baremetal-operator/pkg/validator"
 	"github.com/dell/csi-baremetal-operator/pkg/validator/rbac"
@@ -254,12 +256,33 @@ func Test_updateReadinessStatusesForOpenshiftSecondaryScheduler(t *testing.T) {
 		node0.Name = node0.Name + "0"
 		node1 := nodeTemplate.DeepCopy()
 		node1.Name = node1.Name + "1"
+		// kubeadm sets only control-plane role label since Kubernetes 1.24
+		node1.Labels = map[string]string{controlplane.ControlPlaneLabelKey: ""}
+		csi := csiDeploy.DeepCopy()
+		csi.Name = "test-deployment"
 
 		sp := prepareSchedulerPatcher(eventRecorder, prepareNodeClientSet(node0, node1),
-			prepareValidatorClient(scheme, node0, node1))
-
-		statuses, err := sp.updateReadinessStatusesForOpenshiftSecondaryScheduler(ctx,
-			"app=secondary-scheduler", metav1.Time{Time: curTime}, time.Second, 6)
+			prepareValidatorClientWithStatus(scheme, node0, node1, csi))
+
+		// scheduler is not restarted, the wait is started and recorded in status
+		statuses, err := sp.updateReadinessStatusesForOpenshiftSecondaryScheduler(ctx, csi,
+			"app=secondary-scheduler", metav1.Time{Time: curTime}, time.Second, time.Minute)
+		assert.Nil(t, statuses)
+		requeueErr, ok := common.IsRequeueError(err)
+		assert.True(t, ok)
+		assert.Equal(t, time.Second, requeueErr.After)
+		assertSecondarySchedulerCondition(t, sp, csi, metav1.ConditionFalse, SecondarySchedulerWaitingReason)
+
+		// the wait is continued
+		statuses, err = sp.updateReadinessStatusesForOpenshiftSecondaryScheduler(ctx, csi,
+			"app=secondary-scheduler", metav1.Time{Time: curTime}, time.Second, time.Minute)
+		assert.Nil(t, statuses)
+		_, ok = common.IsRequeueError(err)
+		assert.True(t, ok)
+
+		// the wait is timed out
+		statuses, err = sp.updateReadinessStatusesForOpenshiftSecondaryScheduler(ctx, csi,
+			"app=secondary-scheduler", metav1.Time{Time: curTime}, time.Second, 0)
 		assert.Nil(t, err)
 		assert.Equal(t, 2, len(statuses.Items))
 		for _, status := range statuses.Items {
@@ -267,6 +290,22 @@ func Test_updateReadinessStatusesForOpenshiftSecondaryScheduler(t *testing.T) {
 			assert.True(t, status.Restarted)
 			assert.True(t, status.NodeName == "master0" || status.NodeName == "master1")
 		}
+		assertSecondarySchedulerCondition(t, sp, csi, metav1.ConditionFalse, SecondarySchedulerTimedOutReason)
+
+		// the wait isn't started again for the same generation
+		statuses, err = sp.updateReadinessStatusesForOpenshiftSecondaryScheduler(ctx, csi,
+			"app=secondary-scheduler", metav1.Time{Time: curTime}, time.Second, time.Minute)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(statuses.Items))
+		assertSecondarySchedulerCondition(t, sp, csi, metav1.ConditionFalse, SecondarySchedulerTimedOutReason)
+
+		// the wait is started again when spec is changed
+		timedOut := csi.DeepCopy()
+		timedOut.Generation++
+		_, err = sp.updateReadinessStatusesForOpenshiftSecondaryScheduler(ctx, timedOut,
+			"app=secondary-scheduler", metav1.Time{Time: curTime}, time.Second, time.Minute)
+		_, ok = common.IsRequeueError(err)
+		assert.True(t, ok)
 
 		pod := &corev1.Pod{
 			ObjectMeta: metav1.ObjectMeta{
@@ -290,10 +329,11 @@ func Test_updateReadinessStatusesForOpenshiftSecondaryScheduler(t *testing.T) {
 			},
 		}
 		sp = prepareSchedulerPatcher(eventRecorder, prepareNodeClientSet(pod, node0, node1),
-			prepareValidatorClient(scheme, pod, node0, node1))
+			prepareValidatorClientWithStatus(scheme, pod, node0, node1, csi))
 
-		statuses, err = sp.updateReadinessStatusesForOpenshiftSecondaryScheduler(ctx,
-			"app=secondary-scheduler", metav1.Time{Time: curTime}, time.Second, 6)
+		// scheduler is restarted
+		statuses, err = sp.updateReadinessStatusesForOpenshiftSecondaryScheduler(ctx, csi,
+			"app=secondary-scheduler", metav1.Time{Time: curTime}, time.Second, time.Minute)
 		assert.Nil(t, err)
 		assert.Equal(t, 2, len(statuses.Items))
 		for _, status := range statuses.Items {
@@ -301,9 +341,21 @@ func Test_updateReadinessStatusesForOpenshiftSecondaryScheduler(t *testing.T) {
 			assert.True(t, status.Restarted)
 			assert.True(t, status.NodeName == "master0" || status.NodeName == "master1")
 		}
+		assertSecondarySchedulerCondition(t, sp, csi, metav1.ConditionTrue, SecondarySchedulerRestartedReason)
 	})
 }
 
+func assertSecondarySchedulerCondition(t *testing.T, sp *SchedulerPatcher, csi *csibaremetalv1.Deployment,
+	status metav1.ConditionStatus, reason string) {
+	updated := &csibaremetalv1.Deployment{}
+	assert.Nil(t, sp.Client.Get(context.Background(), client.ObjectKeyFromObject(csi), updated))
+
+	condition := meta.FindStatusCondition(updated.Status.Conditions, csibaremetalv1.SecondarySchedulerReadyCondition)
+	assert.NotNil(t, condition)
+	assert.Equal(t, status, condition.Status)
+	assert.Equal(t, reason, condition.Reason)
+}
+
 func Test_updateReadinessStatuses(t *testing.T) {
 	var (
 		ctx         = context.Background()
@@ -542,6 +594,12 @@ func prepareValidatorClient(scheme *runtime.Scheme, objects ...client.Object) cl
 	return builderWithScheme.WithObjects(objects...).Build()
 }
 
+func prepareValidatorClientWithStatus(scheme *runtime.Scheme, objects ...client.Object) client.Client {
+	builder := fakeClient.ClientBuilder{}
+	builderWithScheme := builder.WithScheme(scheme)
+	return builderWithScheme.WithObjects(objects...).WithStatusSubresource(&csibaremetalv1.Deployment{}).Build()
+}
+
 func prepareSchedulerPatcher(eventRecorder events.EventRecorder, clientSet kubernetes.Interface, client client.Client) *SchedulerPatcher {
 	sp := &SchedulerPatcher{
 		Clientset: clientSet,
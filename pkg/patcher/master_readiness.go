@@ -0,0 +1,281 @@
+package patcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	// defaultPatchingMaxRetries is used if Patcher.MaxRetries is not set
+	defaultPatchingMaxRetries = 3
+	// maxReadinessBackoff limits the delay between patching retries on one master
+	maxReadinessBackoff = 4 * time.Hour
+)
+
+// getReadinessStatusList reads statuses saved in ExtenderConfigMap on the previous reconcile
+// returns empty list if ExtenderConfigMap doesn't exist
+func (p *SchedulerPatcher) getReadinessStatusList(ctx context.Context, options *ExtenderReadinessOptions) (*ReadinessStatusList, error) {
+	statuses := &ReadinessStatusList{}
+
+	config, err := p.Clientset.CoreV1().ConfigMaps(options.readinessConfigMapNamespace).Get(ctx,
+		options.readinessConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if k8sError.IsNotFound(err) {
+			return statuses, nil
+		}
+		return nil, err
+	}
+
+	if err = yaml.Unmarshal([]byte(config.Data[options.readinessConfigMapFile]), statuses); err != nil {
+		p.Log.Warnf("Failed to parse %s configmap, previous readiness statuses are dropped: %s",
+			options.readinessConfigMapName, err.Error())
+		return &ReadinessStatusList{}, nil
+	}
+
+	return statuses, nil
+}
+
+// isPatchingRolledBack returns true if the original scheduler configuration was restored
+// for the current generation of csi Deployment
+func (p *SchedulerPatcher) isPatchingRolledBack(ctx context.Context, csi *csibaremetalv1.Deployment) (bool, error) {
+	statuses, err := p.getReadinessStatusList(ctx, &ExtenderReadinessOptions{
+		readinessConfigMapName:      ExtenderConfigMapName,
+		readinessConfigMapNamespace: csi.GetNamespace(),
+		readinessConfigMapFile:      ExtenderConfigMapFile,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return statuses.RolledBack && statuses.ObservedGeneration == csi.GetGeneration(), nil
+}
+
+// updateMastersState fills per-master patching state in statuses
+// retry counters are kept from previous statuses if csi Deployment generation wasn't changed
+func (p *SchedulerPatcher) updateMastersState(ctx context.Context, csi *csibaremetalv1.Deployment, options *ExtenderReadinessOptions,
+	statuses *ReadinessStatusList, previous *ReadinessStatusList, useOpenshiftSecondaryScheduler bool) error {
+	statuses.ObservedGeneration = csi.GetGeneration()
+
+	previousByNode := map[string]ReadinessStatus{}
+	if previous.ObservedGeneration == csi.GetGeneration() {
+		statuses.RolledBack = previous.RolledBack
+		for _, status := range previous.Items {
+			previousByNode[status.NodeName] = status
+		}
+	}
+
+	// Openshift Secondary Scheduler is not bound to masters, restart status is the only one available
+	if useOpenshiftSecondaryScheduler {
+		for i := range statuses.Items {
+			status := &statuses.Items[i]
+			status.Patched = status.Restarted
+			status.ExtenderReachable = status.Restarted
+			status.Healthy = status.Restarted
+			status.Retries = previousByNode[status.NodeName].Retries
+			status.LastRetryTime = previousByNode[status.NodeName].LastRetryTime
+		}
+		return nil
+	}
+
+	schedulerPods, err := p.Clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{LabelSelector: options.kubeSchedulerLabel})
+	if err != nil {
+		p.Log.Error(err, "Unable to get pods with kube scheduler label")
+		return err
+	}
+	schedulerPodsByName := map[string]*corev1.Pod{}
+	for i := range schedulerPods.Items {
+		schedulerPodsByName[schedulerPods.Items[i].Name] = &schedulerPods.Items[i]
+	}
+
+	extenderPods, err := p.Clientset.CoreV1().Pods(csi.GetNamespace()).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(common.ConstructSelectorMap(csiExtenderName)).String(),
+	})
+	if err != nil {
+		p.Log.Error(err, "Unable to get scheduler extender pods")
+		return err
+	}
+	extenderPodsByNode := map[string]*corev1.Pod{}
+	for i := range extenderPods.Items {
+		extenderPodsByNode[extenderPods.Items[i].Spec.NodeName] = &extenderPods.Items[i]
+	}
+
+	for i := range statuses.Items {
+		status := &statuses.Items[i]
+		schedulerPod := schedulerPodsByName[status.KubeScheduler]
+
+		status.Patched = schedulerPod != nil && isSchedulerPodPatched(csi, schedulerPod)
+		status.ExtenderReachable = p.isExtenderReachable(csi, extenderPodsByNode[status.NodeName])
+		status.Healthy = status.Patched && status.Restarted && status.ExtenderReachable &&
+			schedulerPod != nil && isPodReady(schedulerPod)
+		status.Retries = previousByNode[status.NodeName].Retries
+		status.LastRetryTime = previousByNode[status.NodeName].LastRetryTime
+
+		p.Log.Debugf("Master %s: patched - %t, restarted - %t, extender reachable - %t, healthy - %t, retries - %d",
+			status.NodeName, status.Patched, status.Restarted, status.ExtenderReachable, status.Healthy, status.Retries)
+	}
+
+	return nil
+}
+
+// handleUnhealthyMasters retries patching on masters, which are not healthy after readiness-timeout,
+// backoff between retries is doubled every time.
+// Restores original scheduler configuration when one of masters exhausts Patcher.MaxRetries
+func (p *SchedulerPatcher) handleUnhealthyMasters(ctx context.Context, csi *csibaremetalv1.Deployment, scheme *runtime.Scheme,
+	statuses *ReadinessStatusList, cmCreationTime metav1.Time, useOpenshiftSecondaryScheduler bool) error {
+	if statuses.RolledBack {
+		return nil
+	}
+
+	var (
+		errMsgs          []string
+		openshiftRetried bool
+		maxRetries       = getPatchingMaxRetries(csi)
+		now              = time.Now()
+	)
+
+	for i := range statuses.Items {
+		status := &statuses.Items[i]
+		if status.Healthy {
+			continue
+		}
+
+		lastAttempt := cmCreationTime.Time
+		if status.LastRetryTime.After(lastAttempt) {
+			lastAttempt = status.LastRetryTime
+		}
+		if now.Before(lastAttempt.Add(readinessBackoff(csi.Spec.Scheduler.Patcher.ReadinessTimeout, status.Retries))) {
+			continue
+		}
+
+		if status.Retries >= maxRetries {
+			p.Log.Errorf("Scheduler on master %s is not healthy after %d retries, restore original configuration",
+				status.NodeName, status.Retries)
+			if err := p.rollbackPatching(ctx, csi); err != nil {
+				return err
+			}
+			statuses.RolledBack = true
+			return nil
+		}
+
+		p.Log.Infof("Retry patching on master %s, attempt %d of %d", status.NodeName, status.Retries+1, maxRetries)
+		var err error
+		switch csi.Spec.Platform {
+		case constant.PlatformOpenShift:
+			// Openshift scheduler configuration is cluster-wide, retry it once for all masters
+			if !openshiftRetried {
+				err = p.retryPatchOpenshift(ctx, csi, useOpenshiftSecondaryScheduler, scheme)
+				openshiftRetried = true
+			}
+		case constant.PlatformVanilla, constant.PlatformRKE:
+			err = p.retryPatchVanillaOnNode(ctx, csi, status.NodeName)
+		default:
+			return fmt.Errorf("%s platform is not supported platform for the patcher", csi.Spec.Platform)
+		}
+		if err != nil {
+			errMsgs = append(errMsgs, err.Error())
+		}
+
+		status.Retries++
+		status.LastRetryTime = now
+	}
+
+	if len(errMsgs) != 0 {
+		return fmt.Errorf(strings.Join(errMsgs, "\n"))
+	}
+
+	return nil
+}
+
+// rollbackPatching restores original scheduler configuration
+func (p *SchedulerPatcher) rollbackPatching(ctx context.Context, csi *csibaremetalv1.Deployment) error {
+	switch csi.Spec.Platform {
+	case constant.PlatformOpenShift:
+		return p.unPatchOpenShift(ctx)
+	case constant.PlatformVanilla, constant.PlatformRKE:
+		return p.rollbackVanilla(ctx, csi)
+	default:
+		return fmt.Errorf("%s platform is not supported platform for the patcher", csi.Spec.Platform)
+	}
+}
+
+// isExtenderReachable checks scheduler extender on the master the same way as kube-scheduler does
+// extender uses host network, so pod IP is reachable from kube-scheduler on the same node
+func (p *SchedulerPatcher) isExtenderReachable(csi *csibaremetalv1.Deployment, pod *corev1.Pod) bool {
+	if pod == nil || pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+		return false
+	}
+
+	if err := p.checkSchedulerExtenderPattern(pod.Status.PodIP, csi.Spec.Scheduler.ExtenderPort, extenderFilterPattern); err != nil {
+		p.Log.Debugf("Scheduler extender %s on node %s is not reachable: %s", pod.Name, pod.Spec.NodeName, err.Error())
+		return false
+	}
+
+	return true
+}
+
+// isSchedulerPodPatched checks that kube-scheduler uses one of the configs created by patcher
+// Openshift scheduler is patched via cluster-wide resources, so it is always considered as patched here
+func isSchedulerPodPatched(csi *csibaremetalv1.Deployment, pod *corev1.Pod) bool {
+	if csi.Spec.Platform == constant.PlatformOpenShift {
+		return true
+	}
+
+	cfg, err := newPatcherConfiguration(csi)
+	if err != nil {
+		return false
+	}
+
+	targets := []string{cfg.targetConfig, cfg.targetPolicy, cfg.targetConfig19, cfg.targetConfig23, cfg.targetConfig29}
+	for _, container := range pod.Spec.Containers {
+		for _, arg := range append(container.Command, container.Args...) {
+			for _, target := range targets {
+				if strings.Contains(arg, target) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func getPatchingMaxRetries(csi *csibaremetalv1.Deployment) int {
+	if csi.Spec.Scheduler.Patcher.MaxRetries > 0 {
+		return csi.Spec.Scheduler.Patcher.MaxRetries
+	}
+	return defaultPatchingMaxRetries
+}
+
+// readinessBackoff returns readiness timeout doubled per each performed retry
+func readinessBackoff(readinessTimeout int, retries int) time.Duration {
+	backoff := time.Minute * time.Duration(readinessTimeout)
+	for i := 0; i < retries && backoff < maxReadinessBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxReadinessBackoff {
+		return maxReadinessBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,229 @@
+package patcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dell/csi-baremetal/pkg/events/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+func Test_readinessBackoff(t *testing.T) {
+	assert.Equal(t, 20*time.Minute, readinessBackoff(20, 0))
+	assert.Equal(t, 40*time.Minute, readinessBackoff(20, 1))
+	assert.Equal(t, 80*time.Minute, readinessBackoff(20, 2))
+	assert.Equal(t, maxReadinessBackoff, readinessBackoff(20, 10))
+	assert.Equal(t, time.Duration(0), readinessBackoff(0, 3))
+}
+
+func Test_isSchedulerPodPatched(t *testing.T) {
+	var (
+		csi = &csibaremetalv1.Deployment{
+			Spec: components.DeploymentSpec{
+				Platform:  constant.PlatformVanilla,
+				Scheduler: &components.Scheduler{Patcher: &components.Patcher{}, Log: &components.Log{}},
+			},
+		}
+		pod = &corev1.Pod{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Command: []string{"kube-scheduler", "--kubeconfig=/etc/kubernetes/scheduler.conf"},
+			}}},
+		}
+	)
+
+	assert.False(t, isSchedulerPodPatched(csi, pod))
+
+	pod.Spec.Containers[0].Command = append(pod.Spec.Containers[0].Command,
+		"--config=/etc/kubernetes/manifests/scheduler/config-29.yaml")
+	assert.True(t, isSchedulerPodPatched(csi, pod))
+
+	csi.Spec.Platform = constant.PlatformRKE
+	assert.False(t, isSchedulerPodPatched(csi, pod))
+
+	csi.Spec.Platform = constant.PlatformOpenShift
+	assert.True(t, isSchedulerPodPatched(csi, &corev1.Pod{}))
+}
+
+func Test_updateMastersState(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		curTime = time.Now()
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == extenderFilterPattern {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+		rw.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	assert.Nil(t, err)
+
+	csi := testDeploymentScheduler.DeepCopy()
+	csi.Spec.Scheduler.ExtenderPort = u.Port()
+	csi.Generation = 2
+
+	schedulerPod := func(name, nodeName string, patched bool) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "kube-system",
+				Labels:    map[string]string{"component": "kube-scheduler"},
+			},
+			Spec: corev1.PodSpec{
+				NodeName:   nodeName,
+				Containers: []corev1.Container{{Command: []string{"kube-scheduler"}}},
+			},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}
+		if patched {
+			pod.Spec.Containers[0].Command = append(pod.Spec.Containers[0].Command,
+				"--config=/etc/kubernetes/manifests/scheduler/config-29.yaml")
+		}
+		return pod
+	}
+	extenderPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      csiExtenderName + "-abcde",
+			Namespace: csi.Namespace,
+			Labels:    common.ConstructSelectorMap(csiExtenderName),
+		},
+		Spec:   corev1.PodSpec{NodeName: "master0"},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: u.Hostname()},
+	}
+
+	eventRecorder := new(mocks.EventRecorder)
+	eventRecorder.On("Eventf", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	scheme, _ := common.PrepareScheme()
+	sp := prepareSchedulerPatcher(eventRecorder,
+		prepareNodeClientSet(schedulerPod("kube-scheduler-master0", "master0", true),
+			schedulerPod("kube-scheduler-master1", "master1", false), extenderPod),
+		prepareValidatorClient(scheme))
+	sp.HTTPClient = server.Client()
+
+	options, err := NewExtenderReadinessOptions(csi, false)
+	assert.Nil(t, err)
+
+	statuses := &ReadinessStatusList{Items: []ReadinessStatus{
+		{NodeName: "master0", KubeScheduler: "kube-scheduler-master0", Restarted: true},
+		{NodeName: "master1", KubeScheduler: "kube-scheduler-master1", Restarted: true},
+	}}
+	previous := &ReadinessStatusList{
+		ObservedGeneration: 2,
+		Items: []ReadinessStatus{
+			{NodeName: "master1", Retries: 2, LastRetryTime: curTime},
+		},
+	}
+
+	err = sp.updateMastersState(ctx, csi, options, statuses, previous, false)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), statuses.ObservedGeneration)
+
+	assert.True(t, statuses.Items[0].Patched)
+	assert.True(t, statuses.Items[0].ExtenderReachable)
+	assert.True(t, statuses.Items[0].Healthy)
+	assert.Equal(t, 0, statuses.Items[0].Retries)
+
+	assert.False(t, statuses.Items[1].Patched)
+	assert.False(t, statuses.Items[1].ExtenderReachable)
+	assert.False(t, statuses.Items[1].Healthy)
+	assert.Equal(t, 2, statuses.Items[1].Retries)
+
+	// retry counters are dropped if csi deployment was changed
+	csi.Generation = 3
+	err = sp.updateMastersState(ctx, csi, options, statuses, previous, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, statuses.Items[1].Retries)
+}
+
+func Test_handleUnhealthyMasters(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		csi        = testDeploymentScheduler.DeepCopy()
+		patcherPod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      patcherName + "-abcde",
+				Namespace: csi.Namespace,
+				Labels:    common.ConstructSelectorMap(patcherName),
+			},
+			Spec: corev1.PodSpec{NodeName: "master0"},
+		}
+		patcherDaemonSet = &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: patcherName, Namespace: csi.Namespace},
+		}
+		cmCreationTime = metav1.Time{Time: time.Now().Add(-time.Hour)}
+	)
+	csi.Spec.Scheduler.Patcher.ReadinessTimeout = 20
+	csi.Spec.Scheduler.Patcher.MaxRetries = 1
+
+	eventRecorder := new(mocks.EventRecorder)
+	eventRecorder.On("Eventf", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	scheme, _ := common.PrepareScheme()
+	clientSet := prepareNodeClientSet(patcherPod, patcherDaemonSet)
+	sp := prepareSchedulerPatcher(eventRecorder, clientSet, prepareValidatorClient(scheme))
+
+	statuses := &ReadinessStatusList{Items: []ReadinessStatus{
+		{NodeName: "master0", Healthy: false},
+		{NodeName: "master1", Healthy: true},
+	}}
+
+	// first retry deletes patcher pod on unhealthy master
+	err := sp.handleUnhealthyMasters(ctx, csi, scheme, statuses, cmCreationTime, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, statuses.Items[0].Retries)
+	assert.False(t, statuses.Items[0].LastRetryTime.IsZero())
+	assert.Equal(t, 0, statuses.Items[1].Retries)
+	assert.False(t, statuses.RolledBack)
+	_, err = clientSet.CoreV1().Pods(csi.Namespace).Get(ctx, patcherPod.Name, metav1.GetOptions{})
+	assert.True(t, k8sError.IsNotFound(err))
+
+	// backoff is not passed
+	err = sp.handleUnhealthyMasters(ctx, csi, scheme, statuses, cmCreationTime, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, statuses.Items[0].Retries)
+	assert.False(t, statuses.RolledBack)
+
+	// retries are exhausted
+	statuses.Items[0].LastRetryTime = time.Now().Add(-2 * time.Hour)
+	err = sp.handleUnhealthyMasters(ctx, csi, scheme, statuses, cmCreationTime, false)
+	assert.Nil(t, err)
+	assert.True(t, statuses.RolledBack)
+	_, err = clientSet.AppsV1().DaemonSets(csi.Namespace).Get(ctx, patcherName, metav1.GetOptions{})
+	assert.True(t, k8sError.IsNotFound(err))
+
+	// rolled back state is saved and checked on the next reconcile
+	options, err := NewExtenderReadinessOptions(csi, false)
+	assert.Nil(t, err)
+	statuses.ObservedGeneration = csi.Generation
+	cm, err := createReadinessConfigMap(options, statuses)
+	assert.Nil(t, err)
+	_, err = clientSet.CoreV1().ConfigMaps(csi.Namespace).Create(ctx, cm, metav1.CreateOptions{})
+	assert.Nil(t, err)
+
+	rolledBack, err := sp.isPatchingRolledBack(ctx, csi)
+	assert.Nil(t, err)
+	assert.True(t, rolledBack)
+
+	csi.Generation++
+	rolledBack, err = sp.isPatchingRolledBack(ctx, csi)
+	assert.Nil(t, err)
+	assert.False(t, rolledBack)
+}
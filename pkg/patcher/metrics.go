@@ -0,0 +1,48 @@
+package patcher
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	metricsSubsystem = "scheduler_patcher"
+)
+
+var (
+	// masterReady reports readiness of kube-scheduler on each master to work with csi-baremetal extender
+	masterReady = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: constant.MetricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "master_ready",
+		Help:      "1 if kube-scheduler on the master is patched, restarted and can reach the extender, 0 otherwise",
+	}, []string{"node"})
+
+	// masterRetries reports number of patching retries performed for each master
+	masterRetries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: constant.MetricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "master_retries",
+		Help:      "Number of patching retries performed for the master",
+	}, []string{"node"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(masterReady, masterRetries)
+}
+
+// recordReadinessStatuses exposes readiness statuses of kube-schedulers, removed masters are dropped
+func recordReadinessStatuses(statuses *ReadinessStatusList) {
+	masterReady.Reset()
+	masterRetries.Reset()
+	for _, status := range statuses.Items {
+		ready := 0.0
+		if status.Healthy {
+			ready = 1
+		}
+		masterReady.WithLabelValues(status.NodeName).Set(ready)
+		masterRetries.WithLabelValues(status.NodeName).Set(float64(status.Retries))
+	}
+}
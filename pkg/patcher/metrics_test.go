@@ -0,0 +1,22 @@
+package patcher
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_recordReadinessStatuses(t *testing.T) {
+	recordReadinessStatuses(&ReadinessStatusList{Items: []ReadinessStatus{
+		{NodeName: "master-1", Healthy: true},
+		{NodeName: "master-2", Healthy: false, Retries: 2},
+	}})
+	assert.Equal(t, float64(1), testutil.ToFloat64(masterReady.WithLabelValues("master-1")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(masterReady.WithLabelValues("master-2")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(masterRetries.WithLabelValues("master-2")))
+
+	// removed master is dropped
+	recordReadinessStatuses(&ReadinessStatusList{Items: []ReadinessStatus{{NodeName: "master-1", Healthy: true}}})
+	assert.Equal(t, 1, testutil.CollectAndCount(masterReady))
+}
@@ -6,6 +6,7 @@ import (
 
 	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
 	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
 	"github.com/dell/csi-baremetal-operator/pkg/constant"
 )
 
@@ -62,12 +63,13 @@ func newPatcherConfiguration(csi *csibaremetalv1.Deployment) (*patcherConfigurat
 	default:
 		return nil, fmt.Errorf("%s platform is not supported platform for the patcher", csi.Spec.Platform)
 	}
-	config.image = csi.Spec.Scheduler.Patcher.Image
+	if csi.Spec.Scheduler.Patcher.Image != nil {
+		config.image = common.ConstructFullImageName(csi.Spec.Scheduler.Patcher.Image, csi)
+	}
 	config.interval = csi.Spec.Scheduler.Patcher.Interval
 	config.restoreOnShutdown = csi.Spec.Scheduler.Patcher.RestoreOnShutdown
 	config.configMapName = csi.Spec.Scheduler.Patcher.ConfigMapName
 	config.ns = csi.GetNamespace()
-	config.globalRegistry = csi.Spec.GlobalRegistry
 	config.registrySecret = csi.Spec.RegistrySecret
 	config.pullPolicy = csi.Spec.PullPolicy
 	config.loglevel = csi.Spec.Scheduler.Log.Level
@@ -75,13 +77,14 @@ func newPatcherConfiguration(csi *csibaremetalv1.Deployment) (*patcherConfigurat
 	config.resources = csi.Spec.Scheduler.Patcher.Resources
 	config.securityContext = csi.Spec.Scheduler.SecurityContext
 	config.serviceAccount = csi.Spec.Scheduler.ServiceAccount
+	config.podOverrides = csi.Spec.Scheduler.Patcher.PodOverrides
+	config.controlPlaneSelector = csi.Spec.Scheduler.ControlPlaneSelector
 	return &config, nil
 }
 
 type patcherConfiguration struct {
 	ns                string
-	image             *components.Image
-	globalRegistry    string
+	image             string
 	registrySecret    string
 	pullPolicy        string
 	loglevel          components.Level
@@ -89,6 +92,9 @@ type patcherConfiguration struct {
 	restoreOnShutdown bool
 	resources         *components.ResourceRequirements
 	securityContext   *components.SecurityContext
+	podOverrides      *components.PodOverrides
+
+	controlPlaneSelector *components.NodeSelector
 
 	platform        string
 	targetConfig    string
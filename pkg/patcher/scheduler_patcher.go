@@ -8,10 +8,12 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
 	"github.com/dell/csi-baremetal-operator/pkg/constant"
 	securityverifier "github.com/dell/csi-baremetal-operator/pkg/feature/security_verifier"
 )
@@ -68,6 +70,26 @@ func (p *SchedulerPatcher) Update(ctx context.Context, csi *csibaremetalv1.Deplo
 		return nil
 	}
 
+	rolledBack, err := p.isPatchingRolledBack(ctx, csi)
+	if err != nil {
+		return err
+	}
+	if rolledBack {
+		p.Log.Warn("Kubernetes scheduler configuration was restored after patching retries were exhausted. " +
+			"Update csi Deployment to retry patching")
+		return nil
+	}
+
+	// kube-scheduler configuration isn't patched and readiness of schedulers isn't tracked in plan-only mode,
+	// only patcher objects are compared on Vanilla and RKE
+	if common.GetPlan(ctx) != nil {
+		if csi.Spec.Platform == constant.PlatformOpenShift {
+			p.Log.Info("OpenShift scheduler configuration isn't planned")
+			return nil
+		}
+		return p.updateVanilla(ctx, csi, scheme)
+	}
+
 	useOpenshiftSecondaryScheduler, err := p.useOpenshiftSecondaryScheduler(csi.Spec.Platform)
 	if err != nil {
 		return err
@@ -88,10 +110,37 @@ func (p *SchedulerPatcher) Update(ctx context.Context, csi *csibaremetalv1.Deplo
 	return p.UpdateReadinessConfigMap(ctx, csi, scheme, useOpenshiftSecondaryScheduler)
 }
 
-// Uninstall unpatch Openshift Scheduler
-func (p *SchedulerPatcher) Uninstall(ctx context.Context, csi *csibaremetalv1.Deployment) error {
-	if IsPatchingEnabled(csi) && csi.Spec.Platform == constant.PlatformOpenShift {
-		return p.unPatchOpenShift(ctx)
+// Uninstall restores original scheduler configuration
+// Returns true when the configuration is restored on all masters
+func (p *SchedulerPatcher) Uninstall(ctx context.Context, csi *csibaremetalv1.Deployment) (bool, error) {
+	if !IsPatchingEnabled(csi) {
+		return true, nil
+	}
+
+	switch csi.Spec.Platform {
+	case constant.PlatformOpenShift:
+		return true, p.unPatchOpenShift(ctx)
+	case constant.PlatformVanilla, constant.PlatformRKE:
+		return p.unPatchVanilla(ctx, csi)
+	}
+	return true, nil
+}
+
+// SchedulerConfigMaps returns ConfigMaps, which contain kube-scheduler configuration
+// and readiness of csi-baremetal scheduler extenders
+func SchedulerConfigMaps(csi *csibaremetalv1.Deployment) []types.NamespacedName {
+	configMaps := []types.NamespacedName{
+		{Namespace: csi.GetNamespace(), Name: ExtenderConfigMapName},
+		{Namespace: csi.GetNamespace(), Name: selectedSchedulerExtenderIPConfigMapName},
+	}
+	if csi.Spec.Scheduler != nil && csi.Spec.Scheduler.Patcher != nil && csi.Spec.Scheduler.Patcher.ConfigMapName != "" {
+		configMaps = append(configMaps, types.NamespacedName{Namespace: csi.GetNamespace(),
+			Name: csi.Spec.Scheduler.Patcher.ConfigMapName})
+	}
+	if csi.Spec.Platform == constant.PlatformOpenShift {
+		configMaps = append(configMaps,
+			types.NamespacedName{Namespace: openshiftConfigNamespace, Name: openshiftSchedulerPolicyConfigMapName},
+			types.NamespacedName{Namespace: OpenshiftSecondarySchedulerNamespace, Name: csiOpenshiftSecondarySchedulerConfigMapName})
 	}
-	return nil
+	return configMaps
 }
@@ -58,10 +58,14 @@ const (
 )
 
 func (p *SchedulerPatcher) checkSchedulerExtender(ip string, port string) error {
+	return p.checkSchedulerExtenderPattern(ip, port, p.ExtenderPatternChecked)
+}
+
+func (p *SchedulerPatcher) checkSchedulerExtenderPattern(ip string, port string, pattern string) error {
 	if p.HTTPClient == nil {
 		p.HTTPClient = &http.Client{Timeout: 5 * time.Second}
 	}
-	extenderFilterURL := fmt.Sprintf(extenderFilterURLFormat, ip, port, p.ExtenderPatternChecked)
+	extenderFilterURL := fmt.Sprintf(extenderFilterURLFormat, ip, port, pattern)
 	request, err := http.NewRequest(http.MethodGet, extenderFilterURL, nil)
 	if err != nil {
 		return err
@@ -164,29 +168,39 @@ func createSelectedSchedulerExtenderIPConfigMap(selectedSchedulerExtenderIP stri
 	}
 }
 
+// selectSchedulerExtenderIP tries to get working scheduler extender IP for maxRetries times
+func (p *SchedulerPatcher) selectSchedulerExtenderIP(ctx context.Context, csi *csibaremetalv1.Deployment,
+	scheme *runtime.Scheme, checkInterval time.Duration, maxRetries int) (string, error) {
+	var (
+		selectedSchedulerExtenderIP string
+		err                         error
+	)
+
+	i := 0
+	for ; i < maxRetries; i++ {
+		selectedSchedulerExtenderIP, err = p.getSchedulerExtenderIP(ctx, csi, scheme)
+		if err == nil {
+			break
+		}
+		p.SelectedSchedulerExtenderIP = ""
+		p.Log.Warnf("Fail to get scheduler extender IP: %s", err.Error())
+		<-time.After(checkInterval)
+	}
+	if i == maxRetries {
+		return "", err
+	}
+	p.Log.Infof("Selected Scheduler Extender's IP: %s", selectedSchedulerExtenderIP)
+
+	return selectedSchedulerExtenderIP, nil
+}
+
 func (p *SchedulerPatcher) createOpenshiftConfig(ctx context.Context, csi *csibaremetalv1.Deployment,
 	useOpenshiftSecondaryScheduler bool, scheme *runtime.Scheme, checkInterval time.Duration, maxRetries int) (string, error) {
 	if useOpenshiftSecondaryScheduler {
-		// try to get scheduler extender IP
-		var (
-			selectedSchedulerExtenderIP string
-			err                         error
-		)
-
-		i := 0
-		for ; i < maxRetries; i++ {
-			selectedSchedulerExtenderIP, err = p.getSchedulerExtenderIP(ctx, csi, scheme)
-			if err == nil {
-				break
-			}
-			p.SelectedSchedulerExtenderIP = ""
-			p.Log.Warnf("Fail to get scheduler extender IP: %s", err.Error())
-			<-time.After(checkInterval)
-		}
-		if i == maxRetries {
+		selectedSchedulerExtenderIP, err := p.selectSchedulerExtenderIP(ctx, csi, scheme, checkInterval, maxRetries)
+		if err != nil {
 			return "", err
 		}
-		p.Log.Infof("Selected Scheduler Extender's IP: %s", selectedSchedulerExtenderIP)
 
 		return fmt.Sprintf(`apiVersion: kubescheduler.config.k8s.io/v1beta3
 kind: KubeSchedulerConfiguration
@@ -222,6 +236,16 @@ extenders:
 
 func (p *SchedulerPatcher) patchOpenShift(ctx context.Context, csi *csibaremetalv1.Deployment,
 	useOpenshiftSecondaryScheduler bool, scheme *runtime.Scheme) error {
+	if useOpenshiftSecondaryScheduler {
+		mergeConfigName, err := p.getMergeSchedulerConfigName(ctx, csi)
+		if err != nil {
+			return err
+		}
+		if mergeConfigName != "" {
+			return p.mergeSecondarySchedulerConfig(ctx, csi, scheme, mergeConfigName)
+		}
+	}
+
 	config, err := p.createOpenshiftConfig(ctx, csi, useOpenshiftSecondaryScheduler, scheme,
 		getSchedulerExtenderIPInterval, getSchedulerExtenderIPMaxRetires)
 	if err != nil {
@@ -261,6 +285,17 @@ func (p *SchedulerPatcher) unPatchOpenShift(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if useOpenshiftSecondaryScheduler {
+		schedulerConfig, err := p.getSecondarySchedulerConfigName(ctx)
+		if err != nil {
+			return err
+		}
+		// csi-baremetal extender was merged into 3rd-party secondary scheduler config
+		if schedulerConfig != csiOpenshiftSecondarySchedulerConfigMapName {
+			return p.unmergeSecondarySchedulerConfig(ctx, schedulerConfig)
+		}
+	}
+
 	var (
 		cmName string
 		cmNS   string
@@ -345,7 +380,8 @@ func (p *SchedulerPatcher) patchSecondaryScheduler(ctx context.Context, csi *csi
 	csiOpenshiftSecondaryScheduler := csi.Spec.Scheduler.OpenshiftSecondaryScheduler
 	if csiOpenshiftSecondaryScheduler != nil && csiOpenshiftSecondaryScheduler.Image != nil {
 		csiOpenshiftSecondarySchedulerImage = csi.Spec.Scheduler.OpenshiftSecondaryScheduler.Image
-		if csiOpenshiftSecondarySchedulerImage.Name == "" || csiOpenshiftSecondarySchedulerImage.Tag == "" {
+		if csiOpenshiftSecondarySchedulerImage.Name == "" ||
+			(csiOpenshiftSecondarySchedulerImage.Tag == "" && csiOpenshiftSecondarySchedulerImage.Digest == "") {
 			p.Log.Warn("Invalid secondary scheduler image provided! Use default secondary scheduler image instead!")
 			csiOpenshiftSecondarySchedulerImage = &components.Image{
 				Name: openshiftSecondarySchedulerDefaultImageName,
@@ -358,7 +394,7 @@ func (p *SchedulerPatcher) patchSecondaryScheduler(ctx context.Context, csi *csi
 			Tag:  openshiftSecondarySchedulerDefaultImageTag,
 		}
 	}
-	csiOpenshiftSecondarySchedulerImageURL := common.ConstructFullImageName(csiOpenshiftSecondarySchedulerImage, csi.Spec.GlobalRegistry)
+	csiOpenshiftSecondarySchedulerImageURL := common.ConstructFullImageName(csiOpenshiftSecondarySchedulerImage, csi)
 
 	err := p.Client.Get(ctx, client.ObjectKey{Name: openshiftSchedulerResourceName,
 		Namespace: OpenshiftSecondarySchedulerNamespace}, secondaryScheduler)
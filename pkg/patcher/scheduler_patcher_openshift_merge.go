@@ -0,0 +1,202 @@
+package patcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ssv1 "github.com/openshift/secondary-scheduler-operator/pkg/apis/secondaryscheduler/v1"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+)
+
+const (
+	// mergedExtenderAnnotation keeps urlPrefix of csi-baremetal extender merged into 3rd-party scheduler config
+	mergedExtenderAnnotation = "csi-baremetal.dell.com/merged-extender"
+	// mergedAtAnnotation keeps the last time csi-baremetal extender was merged into 3rd-party scheduler config
+	mergedAtAnnotation = "csi-baremetal.dell.com/merged-at"
+
+	extendersKey = "extenders"
+	urlPrefixKey = "urlPrefix"
+)
+
+// getMergeSchedulerConfigName returns name of 3rd-party secondary scheduler ConfigMap
+// if csi-baremetal extender has to be merged into it, empty string otherwise
+func (p *SchedulerPatcher) getMergeSchedulerConfigName(ctx context.Context, csi *csibaremetalv1.Deployment) (string, error) {
+	if csi.Spec.Scheduler.OpenshiftSecondaryScheduler == nil || !csi.Spec.Scheduler.OpenshiftSecondaryScheduler.CoexistWithExisting {
+		return "", nil
+	}
+
+	schedulerConfig, err := p.getSecondarySchedulerConfigName(ctx)
+	if err != nil {
+		return "", err
+	}
+	if schedulerConfig == csiOpenshiftSecondarySchedulerConfigMapName {
+		return "", nil
+	}
+
+	return schedulerConfig, nil
+}
+
+// getSecondarySchedulerConfigName returns ConfigMap name used by SecondaryScheduler CR cluster
+// returns csi-baremetal ConfigMap name if SecondaryScheduler doesn't exist
+func (p *SchedulerPatcher) getSecondarySchedulerConfigName(ctx context.Context) (string, error) {
+	secondaryScheduler := &ssv1.SecondaryScheduler{}
+	err := p.Client.Get(ctx, client.ObjectKey{Name: openshiftSchedulerResourceName,
+		Namespace: OpenshiftSecondarySchedulerNamespace}, secondaryScheduler)
+	if err != nil {
+		if k8sError.IsNotFound(err) {
+			return csiOpenshiftSecondarySchedulerConfigMapName, nil
+		}
+		return "", err
+	}
+
+	if secondaryScheduler.Spec.SchedulerConfig == "" {
+		return csiOpenshiftSecondarySchedulerConfigMapName, nil
+	}
+	return secondaryScheduler.Spec.SchedulerConfig, nil
+}
+
+// mergeSecondarySchedulerConfig adds csi-baremetal extender into 3rd-party secondary scheduler ConfigMap
+// Extender entry is tracked with ConfigMap annotations and replaced if extender IP was changed
+func (p *SchedulerPatcher) mergeSecondarySchedulerConfig(ctx context.Context, csi *csibaremetalv1.Deployment,
+	scheme *runtime.Scheme, cmName string) error {
+	extenderIP, err := p.selectSchedulerExtenderIP(ctx, csi, scheme, getSchedulerExtenderIPInterval, getSchedulerExtenderIPMaxRetires)
+	if err != nil {
+		return err
+	}
+	urlPrefix := fmt.Sprintf("http://%s:%s", extenderIP, csi.Spec.Scheduler.ExtenderPort)
+
+	cmClient := p.Clientset.CoreV1().ConfigMaps(OpenshiftSecondarySchedulerNamespace)
+	cm, err := cmClient.Get(ctx, cmName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	merged := cm.GetAnnotations()[mergedExtenderAnnotation]
+	if merged == urlPrefix {
+		return nil
+	}
+
+	config, err := parseSchedulerConfig(cm)
+	if err != nil {
+		return err
+	}
+	extenders := removeExtender(config, merged)
+	config[extendersKey] = append(extenders, map[string]interface{}{
+		urlPrefixKey:       urlPrefix,
+		"filterVerb":       "filter",
+		"prioritizeVerb":   "prioritize",
+		"weight":           1,
+		"enableHTTPS":      false,
+		"nodeCacheCapable": false,
+		"ignorable":        true,
+	})
+
+	if err = setSchedulerConfig(cm, config); err != nil {
+		return err
+	}
+	if cm.Annotations == nil {
+		cm.Annotations = map[string]string{}
+	}
+	cm.Annotations[mergedExtenderAnnotation] = urlPrefix
+	cm.Annotations[mergedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+
+	if _, err = cmClient.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	p.Log.Infof("Scheduler extender %s was merged into 3rd-party secondary scheduler config %s", urlPrefix, cmName)
+
+	return nil
+}
+
+// unmergeSecondarySchedulerConfig removes only csi-baremetal extender from 3rd-party secondary scheduler ConfigMap
+func (p *SchedulerPatcher) unmergeSecondarySchedulerConfig(ctx context.Context, cmName string) error {
+	cmClient := p.Clientset.CoreV1().ConfigMaps(OpenshiftSecondarySchedulerNamespace)
+	cm, err := cmClient.Get(ctx, cmName, metav1.GetOptions{})
+	if err != nil {
+		if k8sError.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	merged, ok := cm.GetAnnotations()[mergedExtenderAnnotation]
+	if !ok {
+		return nil
+	}
+
+	config, err := parseSchedulerConfig(cm)
+	if err != nil {
+		return err
+	}
+	extenders := removeExtender(config, merged)
+	if len(extenders) == 0 {
+		delete(config, extendersKey)
+	} else {
+		config[extendersKey] = extenders
+	}
+
+	if err = setSchedulerConfig(cm, config); err != nil {
+		return err
+	}
+	delete(cm.Annotations, mergedExtenderAnnotation)
+	delete(cm.Annotations, mergedAtAnnotation)
+
+	if _, err = cmClient.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	p.Log.Infof("Scheduler extender %s was removed from 3rd-party secondary scheduler config %s", merged, cmName)
+
+	return nil
+}
+
+// getMergedAt returns the time csi-baremetal extender was merged into the ConfigMap
+// returns ConfigMap creation time if extender wasn't merged
+func getMergedAt(cm *corev1.ConfigMap) metav1.Time {
+	mergedAt, err := time.Parse(time.RFC3339, cm.GetAnnotations()[mergedAtAnnotation])
+	if err != nil {
+		return cm.GetCreationTimestamp()
+	}
+	return metav1.Time{Time: mergedAt}
+}
+
+func parseSchedulerConfig(cm *corev1.ConfigMap) (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(cm.Data[openshiftSecondarySchedulerDataKey]), &config); err != nil {
+		return nil, fmt.Errorf("unable to parse secondary scheduler config %s: %s", cm.GetName(), err.Error())
+	}
+	return config, nil
+}
+
+func setSchedulerConfig(cm *corev1.ConfigMap, config map[string]interface{}) error {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[openshiftSecondarySchedulerDataKey] = string(data)
+	return nil
+}
+
+// removeExtender returns scheduler config extenders without one with passed urlPrefix
+func removeExtender(config map[string]interface{}, urlPrefix string) []interface{} {
+	extenders, _ := config[extendersKey].([]interface{})
+
+	result := make([]interface{}, 0, len(extenders))
+	for _, extender := range extenders {
+		if entry, ok := extender.(map[string]interface{}); ok && urlPrefix != "" && entry[urlPrefixKey] == urlPrefix {
+			continue
+		}
+		result = append(result, extender)
+	}
+	return result
+}
@@ -0,0 +1,119 @@
+package patcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dell/csi-baremetal/pkg/events/mocks"
+	ssv1 "github.com/openshift/secondary-scheduler-operator/pkg/apis/secondaryscheduler/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+)
+
+const thirdPartySchedulerConfig = `apiVersion: kubescheduler.config.k8s.io/v1beta3
+kind: KubeSchedulerConfiguration
+profiles:
+  - schedulerName: custom-scheduler
+extenders:
+  - urlPrefix: "http://10.0.0.1:9999"
+    filterVerb: filter
+`
+
+func Test_MergeSecondarySchedulerConfig(t *testing.T) {
+	var (
+		ctx                = context.Background()
+		thirdPartyConfigCM = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "custom-config", Namespace: OpenshiftSecondarySchedulerNamespace},
+			Data:       map[string]string{openshiftSecondarySchedulerDataKey: thirdPartySchedulerConfig},
+		}
+		secondaryScheduler = &ssv1.SecondaryScheduler{
+			ObjectMeta: metav1.ObjectMeta{Name: openshiftSchedulerResourceName, Namespace: OpenshiftSecondarySchedulerNamespace},
+			Spec:       ssv1.SecondarySchedulerSpec{SchedulerConfig: "custom-config"},
+		}
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	assert.Nil(t, err)
+
+	csi := csiDeploy.DeepCopy()
+	csi.Spec.Scheduler.ExtenderPort = u.Port()
+
+	eventRecorder := new(mocks.EventRecorder)
+	eventRecorder.On("Eventf", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	scheme, _ := common.PrepareScheme()
+	clientSet := prepareNodeClientSet(thirdPartyConfigCM)
+	sp := prepareSchedulerPatcher(eventRecorder, clientSet, prepareValidatorClient(scheme, secondaryScheduler))
+	sp.HTTPClient = server.Client()
+	sp.SelectedSchedulerExtenderIP = u.Hostname()
+
+	// coexistence is not enabled
+	name, err := sp.getMergeSchedulerConfigName(ctx, csi)
+	assert.Nil(t, err)
+	assert.Empty(t, name)
+
+	csi.Spec.Scheduler.OpenshiftSecondaryScheduler = &components.OpenshiftSecondaryScheduler{CoexistWithExisting: true}
+	name, err = sp.getMergeSchedulerConfigName(ctx, csi)
+	assert.Nil(t, err)
+	assert.Equal(t, "custom-config", name)
+
+	// merge
+	err = sp.patchOpenShift(ctx, csi, true, scheme)
+	assert.Nil(t, err)
+
+	cm, err := clientSet.CoreV1().ConfigMaps(OpenshiftSecondarySchedulerNamespace).Get(ctx, "custom-config", metav1.GetOptions{})
+	assert.Nil(t, err)
+	urlPrefix := "http://" + u.Host
+	assert.Equal(t, urlPrefix, cm.Annotations[mergedExtenderAnnotation])
+	assert.NotEmpty(t, cm.Annotations[mergedAtAnnotation])
+	config, err := parseSchedulerConfig(cm)
+	assert.Nil(t, err)
+	assert.Len(t, config[extendersKey], 2)
+
+	// merge is idempotent
+	err = sp.mergeSecondarySchedulerConfig(ctx, csi, scheme, "custom-config")
+	assert.Nil(t, err)
+	cm, err = clientSet.CoreV1().ConfigMaps(OpenshiftSecondarySchedulerNamespace).Get(ctx, "custom-config", metav1.GetOptions{})
+	assert.Nil(t, err)
+	config, err = parseSchedulerConfig(cm)
+	assert.Nil(t, err)
+	assert.Len(t, config[extendersKey], 2)
+	mergedAt := getMergedAt(cm)
+	assert.False(t, mergedAt.IsZero())
+
+	// csi-baremetal secondary scheduler config is not created
+	_, err = clientSet.CoreV1().ConfigMaps(OpenshiftSecondarySchedulerNamespace).Get(ctx,
+		csiOpenshiftSecondarySchedulerConfigMapName, metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	// uninstall removes only csi-baremetal extender
+	sp.UseOpenshiftSecondaryScheduler = true
+	sp.KubernetesVersion = "1.26"
+	err = sp.unPatchOpenShift(ctx)
+	assert.Nil(t, err)
+
+	cm, err = clientSet.CoreV1().ConfigMaps(OpenshiftSecondarySchedulerNamespace).Get(ctx, "custom-config", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.NotContains(t, cm.Annotations, mergedExtenderAnnotation)
+	config, err = parseSchedulerConfig(cm)
+	assert.Nil(t, err)
+	extenders := config[extendersKey].([]interface{})
+	assert.Len(t, extenders, 1)
+	assert.Equal(t, "http://10.0.0.1:9999", extenders[0].(map[string]interface{})[urlPrefixKey])
+
+	// 3rd-party secondary scheduler is kept
+	name, err = sp.getSecondarySchedulerConfigName(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "custom-config", name)
+}
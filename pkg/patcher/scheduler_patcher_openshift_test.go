@@ -256,7 +256,7 @@ func Test_SecondaryScheduler(t *testing.T) {
 			&components.Image{
 				Name: openshiftSecondarySchedulerDefaultImageName,
 				Tag:  openshiftSecondarySchedulerDefaultImageTag,
-			}, csiDeploy.Spec.GlobalRegistry)
+			}, csiDeploy)
 		// case that creates new SecondaryScheduler CR cluster
 		sp := prepareSchedulerPatcher(eventRecorder, prepareNodeClientSet(), prepareValidatorClient(scheme))
 		secondarySchduler, err := sp.patchSecondaryScheduler(ctx, csiDeploy)
@@ -283,7 +283,7 @@ func Test_SecondaryScheduler(t *testing.T) {
 			},
 		}
 		csiOpenshiftSecondarySchedulerImage := common.ConstructFullImageName(
-			csiDeploy.Spec.Scheduler.OpenshiftSecondaryScheduler.Image, csiDeploy.Spec.GlobalRegistry)
+			csiDeploy.Spec.Scheduler.OpenshiftSecondaryScheduler.Image, csiDeploy)
 		secondarySchduler, err = sp.patchSecondaryScheduler(ctx, csiDeploy)
 		assert.Equal(t, csiOpenshiftSecondarySchedulerConfigMapName, secondarySchduler.Spec.SchedulerConfig)
 		assert.Equal(t, csiOpenshiftSecondarySchedulerImage, secondarySchduler.Spec.SchedulerImage)
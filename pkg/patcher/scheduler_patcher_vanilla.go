@@ -8,7 +8,9 @@ import (
 
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -16,6 +18,7 @@ import (
 	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
 	"github.com/dell/csi-baremetal-operator/pkg/common"
 	"github.com/dell/csi-baremetal-operator/pkg/constant"
+	"github.com/dell/csi-baremetal-operator/pkg/controlplane"
 	securityverifier "github.com/dell/csi-baremetal-operator/pkg/feature/security_verifier"
 	verifierModels "github.com/dell/csi-baremetal-operator/pkg/feature/security_verifier/models"
 )
@@ -67,6 +70,10 @@ func (p *SchedulerPatcher) updateVanillaDaemonset(ctx context.Context, csi *csib
 		return err
 	}
 
+	if err := common.AddConfigHashAnnotation(ctx, p.Clientset, csi, &expected.Spec.Template); err != nil {
+		return err
+	}
+
 	if err := common.UpdateDaemonSet(ctx, p.Clientset, expected, p.Log); err != nil {
 		return err
 	}
@@ -162,23 +169,40 @@ clientConnection:
 		}}, nil
 }
 
-func (p *SchedulerPatcher) retryPatchVanilla(ctx context.Context, csi *csibaremetalv1.Deployment, scheme *runtime.Scheme) error {
-	dsClient := p.Clientset.AppsV1().DaemonSets(csi.GetNamespace())
-	err := dsClient.Delete(ctx, patcherName, metav1.DeleteOptions{})
+// retryPatchVanillaOnNode deletes csi-baremetal-se-patcher pod on the node.
+// Patcher restores kube-scheduler manifest on shutdown and patches it again after restart
+func (p *SchedulerPatcher) retryPatchVanillaOnNode(ctx context.Context, csi *csibaremetalv1.Deployment, nodeName string) error {
+	podClient := p.Clientset.CoreV1().Pods(csi.GetNamespace())
+	pods, err := podClient.List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(common.ConstructSelectorMap(patcherName)).String(),
+	})
 	if err != nil {
-		p.Log.Error(err, "Failed to delete patcher daemonset")
+		p.Log.Error(err, "Failed to list patcher pods")
 		return err
 	}
 
-	cmClient := p.Clientset.CoreV1().ConfigMaps(csi.GetNamespace())
-	err = cmClient.Delete(ctx, csi.Spec.Scheduler.Patcher.ConfigMapName, metav1.DeleteOptions{})
-	if err != nil {
-		p.Log.Error(err, "Failed to delete patcher configmap")
-		return err
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != nodeName {
+			continue
+		}
+		if err = podClient.Delete(ctx, pod.Name, metav1.DeleteOptions{}); err != nil && !k8sError.IsNotFound(err) {
+			p.Log.Error(err, "Failed to delete patcher pod "+pod.Name)
+			return err
+		}
+		p.Log.Infof("Patcher pod %s on node %s was deleted to retry patching", pod.Name, nodeName)
+		return nil
 	}
 
-	err = p.updateVanilla(ctx, csi, scheme)
-	if err != nil {
+	return fmt.Errorf("patcher pod is not found on node %s", nodeName)
+}
+
+// rollbackVanilla deletes csi-baremetal-se-patcher daemonset.
+// Patcher restores original kube-scheduler manifest on shutdown
+func (p *SchedulerPatcher) rollbackVanilla(ctx context.Context, csi *csibaremetalv1.Deployment) error {
+	dsClient := p.Clientset.AppsV1().DaemonSets(csi.GetNamespace())
+	err := dsClient.Delete(ctx, patcherName, metav1.DeleteOptions{})
+	if err != nil && !k8sError.IsNotFound(err) {
+		p.Log.Error(err, "Failed to delete patcher daemonset")
 		return err
 	}
 
@@ -186,7 +210,7 @@ func (p *SchedulerPatcher) retryPatchVanilla(ctx context.Context, csi *csibareme
 }
 
 func (p patcherConfiguration) createPatcherDaemonSet() *v1.DaemonSet {
-	return &v1.DaemonSet{
+	daemonSet := &v1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      patcherName,
 			Namespace: p.ns,
@@ -216,29 +240,23 @@ func (p patcherConfiguration) createPatcherDaemonSet() *v1.DaemonSet {
 					ImagePullSecrets:              common.MakeImagePullSecrets(p.registrySecret),
 					SchedulerName:                 corev1.DefaultSchedulerName,
 					// todo https://github.com/dell/csi-baremetal/issues/329
-					Tolerations: []corev1.Toleration{
-						{Key: "CriticalAddonsOnly", Operator: corev1.TolerationOpExists},
-						{Key: "node-role.kubernetes.io/master", Effect: corev1.TaintEffectNoSchedule},
-					},
-					Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
-						RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
-							NodeSelectorTerms: []corev1.NodeSelectorTerm{
-								{MatchExpressions: []corev1.NodeSelectorRequirement{
-									{Key: "node-role.kubernetes.io/master", Operator: corev1.NodeSelectorOpExists},
-								}},
-							}},
-					}},
+					Tolerations: controlplane.Tolerations(),
+					Affinity:    controlplane.Affinity(p.controlPlaneSelector),
 				},
 			},
 		},
 	}
+
+	common.ApplyPodOverrides(&daemonSet.Spec.Template, p.podOverrides)
+
+	return daemonSet
 }
 
 func (p patcherConfiguration) createPatcherContainers() []corev1.Container {
 	return []corev1.Container{
 		{
 			Name:            patcherContainerName,
-			Image:           common.ConstructFullImageName(p.image, p.globalRegistry),
+			Image:           p.image,
 			ImagePullPolicy: corev1.PullPolicy(p.pullPolicy),
 			Command: []string{
 				"python3",
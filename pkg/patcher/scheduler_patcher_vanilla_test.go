@@ -11,7 +11,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "github.com/dell/csi-baremetal-operator/api/v1"
 	"github.com/dell/csi-baremetal-operator/api/v1/components"
@@ -120,36 +122,58 @@ var (
 func Test_Update_Retry_Scheduler_Patcher_Vanilla(t *testing.T) {
 	t.Run("Update", func(t *testing.T) {
 		var (
-			ctx        = context.Background()
-			deployment = testDeploymentScheduler.DeepCopy()
+			ctx         = context.Background()
+			deployment  = testDeploymentScheduler.DeepCopy()
 			roleBinding = testRoleBinding.DeepCopy()
 			role        = testRolePodSecurityPolicy.DeepCopy()
-		) 
+			patcherPod  = &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      patcherName + "-abcde",
+					Namespace: deployment.Namespace,
+					Labels:    common.ConstructSelectorMap(patcherName),
+				},
+				Spec: corev1.PodSpec{NodeName: "master0"},
+			}
+		)
 		scheme, _ := common.PrepareScheme()
 		eventRecorder := new(mocks.EventRecorder)
 		eventRecorder.On("Eventf", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
-		schedulerPatcher := prepareSchedulerPatcher(eventRecorder, prepareNodeClientSet(), prepareValidatorClient(scheme, roleBinding, role))
+		clientSet := prepareNodeClientSet(patcherPod)
+		schedulerPatcher := prepareSchedulerPatcher(eventRecorder, clientSet, prepareValidatorClient(scheme, roleBinding, role))
 		err := schedulerPatcher.updateVanilla(ctx, deployment, scheme)
 		assert.Nil(t, err)
-		err = schedulerPatcher.retryPatchVanilla(ctx, deployment, scheme)
+
+		err = schedulerPatcher.retryPatchVanillaOnNode(ctx, deployment, "master1")
+		assert.NotNil(t, err)
+
+		err = schedulerPatcher.retryPatchVanillaOnNode(ctx, deployment, "master0")
 		assert.Nil(t, err)
+		_, err = clientSet.CoreV1().Pods(deployment.Namespace).Get(ctx, patcherPod.Name, metav1.GetOptions{})
+		assert.True(t, k8sError.IsNotFound(err))
+
+		err = schedulerPatcher.rollbackVanilla(ctx, deployment)
+		assert.Nil(t, err)
+		_, err = clientSet.AppsV1().DaemonSets(deployment.Namespace).Get(ctx, patcherName, metav1.GetOptions{})
+		assert.True(t, k8sError.IsNotFound(err))
 	})
 }
 
-func Test_Retry_Patch_Vanilla_Error(t *testing.T) {
-	t.Run("Retry", func(t *testing.T) {
+func Test_Rollback_Vanilla_NotFound(t *testing.T) {
+	t.Run("Rollback", func(t *testing.T) {
 		var (
-			ctx        = context.Background()
-			deployment = testDeploymentScheduler.DeepCopy()
+			ctx         = context.Background()
+			deployment  = testDeploymentScheduler.DeepCopy()
 			roleBinding = testRoleBinding.DeepCopy()
 			role        = testRolePodSecurityPolicy.DeepCopy()
-		) 
+		)
 		scheme, _ := common.PrepareScheme()
 		eventRecorder := new(mocks.EventRecorder)
 		eventRecorder.On("Eventf", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
 		schedulerPatcher := prepareSchedulerPatcher(eventRecorder, prepareNodeClientSet(), prepareValidatorClient(scheme, roleBinding, role))
-		err := schedulerPatcher.retryPatchVanilla(ctx, deployment, scheme)
+		err := schedulerPatcher.rollbackVanilla(ctx, deployment)
+		assert.Nil(t, err)
+		err = schedulerPatcher.retryPatchVanillaOnNode(ctx, deployment, "master0")
 		assert.NotNil(t, err)
-		assert.True(t, strings.HasSuffix(err.Error(), "not found"))
+		assert.True(t, strings.HasSuffix(err.Error(), "not found on node master0"))
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,202 @@
+package patcher
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"path"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/ptr"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	restorer              = "se-restore"
+	restorerName          = constant.CSIName + "-" + restorer
+	restorerContainerName = "schedulerrestore"
+
+	kubeSchedulerManifest = "kube-scheduler.yaml"
+
+	// maxJobNameLength is limited by job-name label, which is set by job controller
+	maxJobNameLength = 63
+)
+
+// unPatchVanilla restores original kube-scheduler manifests on all masters.
+// Deletes patcher daemonset and runs restore job on every master after patcher pods are stopped.
+// Returns true when every kube-scheduler is restarted with the original configuration
+func (p *SchedulerPatcher) unPatchVanilla(ctx context.Context, csi *csibaremetalv1.Deployment) (bool, error) {
+	cfg, err := newPatcherConfiguration(csi)
+	if err != nil {
+		return false, err
+	}
+
+	if err = p.rollbackVanilla(ctx, csi); err != nil {
+		return false, err
+	}
+
+	// patcher can patch manifest again until it's stopped
+	patcherPods, err := p.Clientset.CoreV1().Pods(csi.GetNamespace()).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(common.ConstructSelectorMap(patcherName)).String(),
+	})
+	if err != nil {
+		p.Log.Error(err, "Failed to list patcher pods")
+		return false, err
+	}
+	if len(patcherPods.Items) != 0 {
+		p.Log.Infof("Waiting for %d patcher pods to be stopped", len(patcherPods.Items))
+		return false, nil
+	}
+
+	options, err := NewExtenderReadinessOptions(csi, false)
+	if err != nil {
+		return false, err
+	}
+	schedulerPods, err := p.Clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{LabelSelector: options.kubeSchedulerLabel})
+	if err != nil {
+		p.Log.Error(err, "Unable to get pods with kube scheduler label")
+		return false, err
+	}
+
+	var (
+		errMsgs  []string
+		restored = true
+		jobs     = p.Clientset.BatchV1().Jobs(csi.GetNamespace())
+	)
+	for i := range schedulerPods.Items {
+		pod := &schedulerPods.Items[i]
+		if !isSchedulerPodPatched(csi, pod) && isPodReady(pod) {
+			continue
+		}
+		restored = false
+
+		job := cfg.createRestoreJob(pod.Spec.NodeName)
+		_, err = jobs.Get(ctx, job.Name, metav1.GetOptions{})
+		if err == nil {
+			p.Log.Infof("Waiting for kube-scheduler %s to be restored", pod.Name)
+			continue
+		}
+		if !k8sError.IsNotFound(err) {
+			errMsgs = append(errMsgs, err.Error())
+			continue
+		}
+
+		if _, err = jobs.Create(ctx, job, metav1.CreateOptions{}); err != nil {
+			p.Log.Error(err, "Failed to create restore job "+job.Name)
+			errMsgs = append(errMsgs, err.Error())
+			continue
+		}
+		p.Log.Infof("Restore job %s was created on node %s", job.Name, pod.Spec.NodeName)
+	}
+
+	if len(errMsgs) != 0 {
+		return false, fmt.Errorf(strings.Join(errMsgs, "\n"))
+	}
+	if !restored {
+		return false, nil
+	}
+
+	return true, p.deleteRestoreJobs(ctx, csi)
+}
+
+// deleteRestoreJobs removes restore jobs with their pods
+func (p *SchedulerPatcher) deleteRestoreJobs(ctx context.Context, csi *csibaremetalv1.Deployment) error {
+	err := p.Clientset.BatchV1().Jobs(csi.GetNamespace()).DeleteCollection(ctx,
+		metav1.DeleteOptions{PropagationPolicy: ptr.To(metav1.DeletePropagationBackground)},
+		metav1.ListOptions{LabelSelector: labels.SelectorFromSet(common.ConstructSelectorMap(restorerName)).String()})
+	if err != nil && !k8sError.IsNotFound(err) {
+		p.Log.Error(err, "Failed to delete restore jobs")
+		return err
+	}
+
+	return nil
+}
+
+// createRestoreJob creates job, which copies kube-scheduler manifest backup made by patcher
+// to the manifests folder on the node. Manifest is restored only if it's still patched
+func (p patcherConfiguration) createRestoreJob(nodeName string) *batchv1.Job {
+	var (
+		manifest = path.Join(p.manifestsFolder, kubeSchedulerManifest)
+		backup   = path.Join(p.schedulerFolder, kubeSchedulerManifest)
+		script   = fmt.Sprintf("if [ -f %[1]s ] && grep -q -- '--config=%[2]s/' %[3]s; then cp %[1]s %[3]s; fi",
+			backup, p.schedulerFolder, manifest)
+	)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restoreJobName(nodeName),
+			Namespace: p.ns,
+			Labels:    common.ConstructLabelMap(restorerName, restorer),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ptr.To(int32(3)),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: common.ConstructLabelMap(restorerName, restorer),
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            restorerContainerName,
+							Image:           p.image,
+							ImagePullPolicy: corev1.PullPolicy(p.pullPolicy),
+							Command:         []string{"sh", "-c", script},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: kubernetesSchedulerVolume, MountPath: p.schedulerFolder},
+								{Name: kubernetesManifestsVolume, MountPath: p.manifestsFolder},
+							},
+							TerminationMessagePath:   constant.TerminationMessagePath,
+							TerminationMessagePolicy: constant.TerminationMessagePolicy,
+							Resources:                common.ConstructResourceRequirements(p.resources),
+							SecurityContext:          p.createSecurityContext(),
+						},
+					},
+					Volumes:                       p.createRestoreVolumes(),
+					NodeName:                      nodeName,
+					RestartPolicy:                 corev1.RestartPolicyOnFailure,
+					TerminationGracePeriodSeconds: ptr.To(int64(constant.TerminationGracePeriodSeconds)),
+					ServiceAccountName:            p.serviceAccount,
+					DeprecatedServiceAccount:      p.serviceAccount,
+					SecurityContext:               &corev1.PodSecurityContext{},
+					ImagePullSecrets:              common.MakeImagePullSecrets(p.registrySecret),
+					// job is bound to the master, it has to ignore any taints
+					Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+				},
+			},
+		},
+	}
+}
+
+func (p patcherConfiguration) createRestoreVolumes() []corev1.Volume {
+	unset := corev1.HostPathUnset
+	return []corev1.Volume{
+		{Name: kubernetesSchedulerVolume, VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{Path: p.schedulerFolder, Type: &unset},
+		}},
+		{Name: kubernetesManifestsVolume, VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{Path: p.manifestsFolder, Type: &unset},
+		}},
+	}
+}
+
+// restoreJobName returns name of restore job for the node, long names are truncated
+// and suffixed with hash of the node name to stay unique
+func restoreJobName(nodeName string) string {
+	name := restorerName + "-" + nodeName
+	if len(name) <= maxJobNameLength {
+		return name
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(nodeName))
+	suffix := fmt.Sprintf("-%08x", hash.Sum32())
+	return strings.TrimRight(name[:maxJobNameLength-len(suffix)], "-.") + suffix
+}
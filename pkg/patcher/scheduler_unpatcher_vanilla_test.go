@@ -0,0 +1,106 @@
+package patcher
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dell/csi-baremetal/pkg/events/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+)
+
+func Test_Uninstall_Vanilla(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		csi        = testDeploymentScheduler.DeepCopy()
+		patcherPod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      patcherName + "-abcde",
+				Namespace: csi.Namespace,
+				Labels:    common.ConstructSelectorMap(patcherName),
+			},
+			Spec: corev1.PodSpec{NodeName: "master0"},
+		}
+		patcherDaemonSet = &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: patcherName, Namespace: csi.Namespace},
+		}
+		schedulerPod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kube-scheduler-master0",
+				Namespace: "kube-system",
+				Labels:    map[string]string{"component": "kube-scheduler"},
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "master0",
+				Containers: []corev1.Container{{Command: []string{"kube-scheduler",
+					"--config=/etc/kubernetes/manifests/scheduler/config-29.yaml"}}},
+			},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		}
+	)
+
+	eventRecorder := new(mocks.EventRecorder)
+	eventRecorder.On("Eventf", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	scheme, _ := common.PrepareScheme()
+	clientSet := prepareNodeClientSet(patcherPod, patcherDaemonSet, schedulerPod)
+	sp := prepareSchedulerPatcher(eventRecorder, clientSet, prepareValidatorClient(scheme))
+
+	// patcher daemonset is deleted, restore is postponed until patcher pods are stopped
+	restored, err := sp.Uninstall(ctx, csi)
+	assert.Nil(t, err)
+	assert.False(t, restored)
+	_, err = clientSet.AppsV1().DaemonSets(csi.Namespace).Get(ctx, patcherName, metav1.GetOptions{})
+	assert.True(t, k8sError.IsNotFound(err))
+
+	// restore job is created on the patched master
+	assert.Nil(t, clientSet.CoreV1().Pods(csi.Namespace).Delete(ctx, patcherPod.Name, metav1.DeleteOptions{}))
+	restored, err = sp.Uninstall(ctx, csi)
+	assert.Nil(t, err)
+	assert.False(t, restored)
+	job, err := clientSet.BatchV1().Jobs(csi.Namespace).Get(ctx, restoreJobName("master0"), metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "master0", job.Spec.Template.Spec.NodeName)
+
+	// kube-scheduler is restarted with the original config
+	schedulerPod.Spec.Containers[0].Command = []string{"kube-scheduler"}
+	_, err = clientSet.CoreV1().Pods(schedulerPod.Namespace).Update(ctx, schedulerPod, metav1.UpdateOptions{})
+	assert.Nil(t, err)
+	restored, err = sp.Uninstall(ctx, csi)
+	assert.Nil(t, err)
+	assert.True(t, restored)
+	lastAction := clientSet.(*fake.Clientset).Actions()[len(clientSet.(*fake.Clientset).Actions())-1]
+	assert.True(t, lastAction.Matches("delete-collection", "jobs"))
+
+	// nothing to restore if patching is disabled
+	csi.Spec.Scheduler.Patcher.Enable = false
+	restored, err = sp.Uninstall(ctx, csi)
+	assert.Nil(t, err)
+	assert.True(t, restored)
+}
+
+func Test_createRestoreJob(t *testing.T) {
+	cfg, err := newPatcherConfiguration(testDeploymentScheduler.DeepCopy())
+	assert.Nil(t, err)
+
+	job := cfg.createRestoreJob("master0")
+	assert.Equal(t, restorerName+"-master0", job.Name)
+	assert.Equal(t, "master0", job.Spec.Template.Spec.NodeName)
+
+	script := job.Spec.Template.Spec.Containers[0].Command[2]
+	assert.Contains(t, script, "cp /etc/kubernetes/manifests/scheduler/kube-scheduler.yaml /etc/kubernetes/manifests/kube-scheduler.yaml")
+
+	longName := restoreJobName(strings.Repeat("a", 100))
+	assert.Len(t, longName, maxJobNameLength)
+	// names with the same long prefix don't clash
+	assert.NotEqual(t, longName, restoreJobName(strings.Repeat("a", 100)+"b"))
+}
@@ -0,0 +1,47 @@
+package render
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+)
+
+// ParseDeployment parses csi Deployment from YAML or JSON
+func ParseDeployment(data []byte) (*csibaremetalv1.Deployment, error) {
+	csi := &csibaremetalv1.Deployment{}
+	if err := yaml.UnmarshalStrict(data, csi); err != nil {
+		return nil, fmt.Errorf("failed to parse csi Deployment: %s", err.Error())
+	}
+	if csi.Kind != "Deployment" || csi.APIVersion != csibaremetalv1.GroupVersion.String() {
+		return nil, fmt.Errorf("expected Deployment of %s, got %s of %s",
+			csibaremetalv1.GroupVersion.String(), csi.Kind, csi.APIVersion)
+	}
+	return csi, nil
+}
+
+// ParseNodes parses a Node or a list of Nodes, e.g. output of `kubectl get nodes -o yaml`
+func ParseNodes(data []byte) ([]corev1.Node, error) {
+	var list struct {
+		Kind  string        `json:"kind"`
+		Items []corev1.Node `json:"items"`
+	}
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse nodes: %s", err.Error())
+	}
+
+	switch list.Kind {
+	case "Node":
+		node := corev1.Node{}
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, fmt.Errorf("failed to parse node: %s", err.Error())
+		}
+		return []corev1.Node{node}, nil
+	case "NodeList", "List":
+		return list.Items, nil
+	default:
+		return nil, fmt.Errorf("expected Node, NodeList or List, got %q", list.Kind)
+	}
+}
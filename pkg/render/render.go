@@ -0,0 +1,286 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/version"
+	k8sversion "k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/yaml"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/pkg"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/compatibility"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+	"github.com/dell/csi-baremetal-operator/pkg/patcher"
+)
+
+const (
+	// DefaultNamespace is used if namespace of csi Deployment isn't set
+	DefaultNamespace = "default"
+	// DefaultKubernetesVersion is used if version of API server isn't passed
+	DefaultKubernetesVersion = "v1.27.0"
+
+	// maxReconciles limits retries of reconcile, while components request requeue
+	maxReconciles = 5
+	// rbacName is a name of RBAC objects, which grant all permissions to service accounts of components
+	rbacName = "csi-baremetal-render"
+)
+
+// Options describes cluster, which objects are rendered for
+type Options struct {
+	// Platform overrides platform of csi Deployment if it's set
+	Platform string
+	// KubernetesVersion is a version of API server, DefaultKubernetesVersion is used if it isn't set
+	KubernetesVersion string
+	// Nodes are used to select node DaemonSets by kernel versions of nodes
+	Nodes []corev1.Node
+}
+
+// Render writes objects, which operator creates for csi Deployment, as multi-document YAML.
+// Operator reconciles csi Deployment against fake cluster, which contains only passed nodes and csi-baremetal CRDs,
+// RBAC is considered as granted and image tags are not resolved to digests.
+// Runtime metadata, owner references and status are removed from rendered objects
+func Render(ctx context.Context, csi *csibaremetalv1.Deployment, opts Options, out io.Writer, log *logrus.Logger) error {
+	scheme, err := common.PrepareScheme()
+	if err != nil {
+		return err
+	}
+
+	csi = csi.DeepCopy()
+	if csi.GetNamespace() == "" {
+		csi.SetNamespace(DefaultNamespace)
+	}
+	if opts.Platform != "" {
+		csi.Spec.Platform = opts.Platform
+	}
+	// objects are rendered as they would be applied
+	delete(csi.Annotations, csibaremetalv1.PlanOnlyAnnotation)
+	// OpenShift scheduler configuration refers to IP of running extender, so it can't be rendered
+	if csi.Spec.Platform == constant.PlatformOpenShift && patcher.IsPatchingEnabled(csi) {
+		log.Warn("OpenShift scheduler configuration depends on running scheduler extenders and isn't rendered")
+		scheduler, schedulerPatcher := *csi.Spec.Scheduler, *csi.Spec.Scheduler.Patcher
+		schedulerPatcher.Enable = false
+		scheduler.Patcher = &schedulerPatcher
+		csi.Spec.Scheduler = &scheduler
+	}
+	// labels are set by kubelet on real nodes, operator adds platform label to them
+	nodes := make([]corev1.Node, len(opts.Nodes))
+	for i := range opts.Nodes {
+		opts.Nodes[i].DeepCopyInto(&nodes[i])
+		if nodes[i].Labels == nil {
+			nodes[i].Labels = map[string]string{}
+		}
+	}
+	opts.Nodes = nodes
+
+	clientSet, err := prepareClientSet(opts)
+	if err != nil {
+		return err
+	}
+
+	objects := append(prepareRBAC(csi.GetNamespace()), csi)
+	crds, err := prepareCRDs()
+	if err != nil {
+		return err
+	}
+	objects = append(objects, crds...)
+	for i := range opts.Nodes {
+		objects = append(objects, opts.Nodes[i].DeepCopy())
+	}
+
+	var created []client.Object
+	cl := fakeClient.NewClientBuilder().WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&csibaremetalv1.Deployment{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if err := c.Create(ctx, obj, opts...); err != nil {
+					return err
+				}
+				created = append(created, obj.DeepCopyObject().(client.Object))
+				return nil
+			},
+		}).Build()
+	csiDeployment := pkg.NewCSIDeployment(clientSet, cl, allowAllMatcher{}, nil, rbacv1.PolicyRule{},
+		eventRecorder{}, log)
+	csiDeployment.DisableDigestResolution()
+
+	for i := 0; i < maxReconciles; i++ {
+		err = csiDeployment.Update(ctx, csi, scheme)
+		if _, ok := common.IsRequeueError(err); !ok {
+			break
+		}
+	}
+	if _, ok := common.IsRequeueError(err); err != nil && !ok {
+		return err
+	}
+
+	return writeObjects(collectCreated(ctx, clientSet, cl, created), scheme, out)
+}
+
+// prepareClientSet returns fake clientset with nodes and version of API server
+func prepareClientSet(opts Options) (*fake.Clientset, error) {
+	objects := make([]runtime.Object, 0, len(opts.Nodes))
+	for i := range opts.Nodes {
+		objects = append(objects, opts.Nodes[i].DeepCopy())
+	}
+	clientSet := fake.NewSimpleClientset(objects...)
+
+	kubernetesVersion := opts.KubernetesVersion
+	if kubernetesVersion == "" {
+		kubernetesVersion = DefaultKubernetesVersion
+	}
+	parsed, err := version.ParseGeneric(kubernetesVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubernetes version %s: %s", kubernetesVersion, err.Error())
+	}
+
+	discovery := clientSet.Discovery().(*fakediscovery.FakeDiscovery)
+	discovery.FakedServerVersion = &k8sversion.Info{
+		Major:      fmt.Sprint(parsed.Major()),
+		Minor:      fmt.Sprint(parsed.Minor()),
+		GitVersion: kubernetesVersion,
+	}
+
+	return clientSet, nil
+}
+
+// prepareCRDs returns csi-baremetal CRDs from compatibility matrix
+func prepareCRDs() ([]client.Object, error) {
+	matrix, err := compatibility.LoadMatrix()
+	if err != nil {
+		return nil, err
+	}
+	crds, err := compatibility.NewCRDs(matrix)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]client.Object, 0, len(crds))
+	for _, crd := range crds {
+		objects = append(objects, crd)
+	}
+	return objects, nil
+}
+
+// prepareRBAC returns role bindings, which are matched by allowAllMatcher for all service accounts
+func prepareRBAC(namespace string) []client.Object {
+	return []client.Object{
+		&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: rbacName, Namespace: namespace}},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: rbacName, Namespace: namespace},
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: rbacName},
+		},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: rbacName}},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: rbacName},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: rbacName},
+		},
+	}
+}
+
+// collectCreated returns the latest state of objects created in clientset and client
+func collectCreated(ctx context.Context, clientSet *fake.Clientset, cl client.Client, created []client.Object) []runtime.Object {
+	var objects []runtime.Object
+	seen := map[string]bool{}
+	for _, action := range clientSet.Actions() {
+		createAction, ok := action.(k8stesting.CreateAction)
+		if !ok || action.GetVerb() != "create" || action.GetSubresource() != "" {
+			continue
+		}
+		meta, ok := createAction.GetObject().(metav1.Object)
+		if !ok {
+			continue
+		}
+		key := action.GetResource().String() + "/" + action.GetNamespace() + "/" + meta.GetName()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		obj, err := clientSet.Tracker().Get(action.GetResource(), action.GetNamespace(), meta.GetName())
+		if err != nil {
+			// object may be removed by operator after creation
+			continue
+		}
+		objects = append(objects, obj)
+	}
+
+	for _, obj := range created {
+		latest := obj.DeepCopyObject().(client.Object)
+		if err := cl.Get(ctx, client.ObjectKeyFromObject(obj), latest); err != nil {
+			continue
+		}
+		objects = append(objects, latest)
+	}
+	return objects
+}
+
+// writeObjects writes objects sorted by kind, namespace and name
+func writeObjects(objects []runtime.Object, scheme *runtime.Scheme, out io.Writer) error {
+	type rendered struct {
+		kind, namespace, name string
+		content               map[string]interface{}
+	}
+
+	result := make([]rendered, 0, len(objects))
+	for _, obj := range objects {
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+		if err != nil {
+			return err
+		}
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return err
+		}
+		content["apiVersion"], content["kind"] = gvk.GroupVersion().String(), gvk.Kind
+		delete(content, "status")
+
+		var namespace, name string
+		if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+			for _, field := range []string{"resourceVersion", "creationTimestamp", "uid", "generation",
+				"managedFields", "ownerReferences"} {
+				delete(metadata, field)
+			}
+			namespace, _ = metadata["namespace"].(string)
+			name, _ = metadata["name"].(string)
+		}
+		result = append(result, rendered{kind: gvk.Kind, namespace: namespace, name: name, content: content})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].kind != result[j].kind {
+			return result[i].kind < result[j].kind
+		}
+		if result[i].namespace != result[j].namespace {
+			return result[i].namespace < result[j].namespace
+		}
+		return result[i].name < result[j].name
+	})
+
+	for _, obj := range result {
+		data, err := yaml.Marshal(obj.content)
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintf(out, "---\n%s", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var testNodes = []corev1.Node{
+	{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{KernelVersion: "5.4.0-generic"}},
+	},
+	{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+		Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{KernelVersion: "4.18.0"}},
+	},
+}
+
+// getRenderedObjects returns <kind>/<name> of rendered objects
+func getRenderedObjects(t *testing.T, output string) []string {
+	var objects []string
+	for _, doc := range strings.Split(output, "---\n") {
+		if doc == "" {
+			continue
+		}
+		obj := map[string]interface{}{}
+		assert.Nil(t, yaml.Unmarshal([]byte(doc), &obj))
+		metadata := obj["metadata"].(map[string]interface{})
+		assert.NotContains(t, metadata, "resourceVersion")
+		assert.NotContains(t, metadata, "ownerReferences")
+		assert.NotContains(t, obj, "status")
+		objects = append(objects, obj["kind"].(string)+"/"+metadata["name"].(string))
+	}
+	return objects
+}
+
+func Test_Render(t *testing.T) {
+	data, err := os.ReadFile("testdata/deployment.yaml")
+	assert.Nil(t, err)
+	csi, err := ParseDeployment(data)
+	assert.Nil(t, err)
+
+	t.Run("Vanilla", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		assert.Nil(t, Render(context.Background(), csi, Options{Nodes: testNodes}, out, logrus.New()))
+		assert.Equal(t, []string{
+			"ConfigMap/extender-readiness",
+			"ConfigMap/node-config",
+			"ConfigMap/schedulerpatcher-config",
+			"DaemonSet/csi-baremetal-node",
+			"DaemonSet/csi-baremetal-node-kernel-5.4",
+			"DaemonSet/csi-baremetal-se",
+			"DaemonSet/csi-baremetal-se-patcher",
+			"Deployment/csi-baremetal-controller",
+			"Deployment/csi-baremetal-node-controller",
+		}, getRenderedObjects(t, out.String()))
+
+		// input isn't changed
+		assert.Nil(t, testNodes[0].Labels)
+	})
+
+	t.Run("Output is stable", func(t *testing.T) {
+		first, second := &bytes.Buffer{}, &bytes.Buffer{}
+		assert.Nil(t, Render(context.Background(), csi, Options{Nodes: testNodes}, first, logrus.New()))
+		assert.Nil(t, Render(context.Background(), csi, Options{Nodes: testNodes}, second, logrus.New()))
+		assert.Equal(t, first.String(), second.String())
+	})
+
+	t.Run("OpenShift", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		assert.Nil(t, Render(context.Background(), csi, Options{Platform: "openshift", Nodes: testNodes[:1]}, out, logrus.New()))
+		assert.Equal(t, []string{
+			"ConfigMap/node-config",
+			"DaemonSet/csi-baremetal-node-kernel-5.4",
+			"DaemonSet/csi-baremetal-se",
+			"Deployment/csi-baremetal-controller",
+			"Deployment/csi-baremetal-node-controller",
+		}, getRenderedObjects(t, out.String()))
+		assert.True(t, csi.Spec.Scheduler.Patcher.Enable)
+	})
+
+	t.Run("Invalid kubernetes version", func(t *testing.T) {
+		assert.NotNil(t, Render(context.Background(), csi, Options{KubernetesVersion: "latest"}, &bytes.Buffer{}, logrus.New()))
+	})
+}
+
+func Test_ParseDeployment(t *testing.T) {
+	_, err := ParseDeployment([]byte("apiVersion: v1\nkind: ConfigMap\n"))
+	assert.NotNil(t, err)
+
+	_, err = ParseDeployment([]byte("apiVersion: csi-baremetal.dell.com/v1\nkind: Deployment\nspec:\n  unknown: true\n"))
+	assert.NotNil(t, err)
+}
+
+func Test_ParseNodes(t *testing.T) {
+	nodes, err := ParseNodes([]byte(`
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Node
+  metadata:
+    name: node-1
+- apiVersion: v1
+  kind: Node
+  metadata:
+    name: node-2
+`))
+	assert.Nil(t, err)
+	assert.Len(t, nodes, 2)
+
+	nodes, err = ParseNodes([]byte("apiVersion: v1\nkind: Node\nmetadata:\n  name: node-1\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, "node-1", nodes[0].Name)
+
+	_, err = ParseNodes([]byte("apiVersion: v1\nkind: Pod\n"))
+	assert.NotNil(t, err)
+}
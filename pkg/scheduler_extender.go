@@ -19,6 +19,7 @@ import (
 	"github.com/dell/csi-baremetal-operator/api/v1/components"
 	"github.com/dell/csi-baremetal-operator/pkg/common"
 	"github.com/dell/csi-baremetal-operator/pkg/constant"
+	"github.com/dell/csi-baremetal-operator/pkg/controlplane"
 	securityverifier "github.com/dell/csi-baremetal-operator/pkg/feature/security_verifier"
 	verifierModels "github.com/dell/csi-baremetal-operator/pkg/feature/security_verifier/models"
 	"github.com/dell/csi-baremetal-operator/pkg/patcher"
@@ -72,6 +73,10 @@ func (n *SchedulerExtender) Update(ctx context.Context, csi *csibaremetalv1.Depl
 		return err
 	}
 
+	if err := common.AddConfigHashAnnotation(ctx, n.Clientset, csi, &expected.Spec.Template); err != nil {
+		return err
+	}
+
 	if err := common.UpdateDaemonSet(ctx, n.Clientset, expected, n.Entry); err != nil {
 		return err
 	}
@@ -98,7 +103,7 @@ func (n *SchedulerExtender) createExtenderDaemonSet(csi *csibaremetalv1.Deployme
 			}})
 	}
 
-	return &v1.DaemonSet{
+	daemonSet := &v1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      extenderName,
 			Namespace: csi.GetNamespace(),
@@ -116,11 +121,8 @@ func (n *SchedulerExtender) createExtenderDaemonSet(csi *csibaremetalv1.Deployme
 					// labels
 					Labels: common.ConstructLabelMap(extenderName, extender),
 					// integration with monitoring
-					Annotations: map[string]string{
-						"prometheus.io/scrape": "true",
-						"prometheus.io/port":   strconv.Itoa(constant.PrometheusPort),
-						"prometheus.io/path":   "/metrics",
-					},
+					Annotations: common.MetricsAnnotations(common.MetricsPort(csi.Spec.Scheduler.Metrics),
+						common.MetricsPath(csi.Spec.Scheduler.Metrics)),
 				},
 				Spec: corev1.PodSpec{
 					Containers:                    createExtenderContainers(csi, isPatchingEnabled),
@@ -133,23 +135,17 @@ func (n *SchedulerExtender) createExtenderDaemonSet(csi *csibaremetalv1.Deployme
 					ImagePullSecrets:              common.MakeImagePullSecrets(csi.Spec.RegistrySecret),
 					SchedulerName:                 corev1.DefaultSchedulerName,
 					HostNetwork:                   true,
-					Tolerations: []corev1.Toleration{
-						{Key: "CriticalAddonsOnly", Operator: corev1.TolerationOpExists},
-						{Key: "node-role.kubernetes.io/master", Effect: corev1.TaintEffectNoSchedule},
-					},
-					Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
-						RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
-							NodeSelectorTerms: []corev1.NodeSelectorTerm{
-								{MatchExpressions: []corev1.NodeSelectorRequirement{
-									{Key: "node-role.kubernetes.io/master", Operator: corev1.NodeSelectorOpExists},
-								}},
-							}},
-					}},
-					Volumes: volumes,
+					Tolerations:                   controlplane.Tolerations(),
+					Affinity:                      controlplane.Affinity(csi.Spec.Scheduler.ControlPlaneSelector),
+					Volumes:                       volumes,
 				},
 			},
 		},
 	}
+
+	common.ApplyPodOverrides(&daemonSet.Spec.Template, csi.Spec.Scheduler.PodOverrides)
+
+	return daemonSet
 }
 
 func createExtenderContainers(csi *csibaremetalv1.Deployment, isPatchingEnabled bool) []corev1.Container {
@@ -169,8 +165,8 @@ func createExtenderContainers(csi *csibaremetalv1.Deployment, isPatchingEnabled
 		constant.LogLevelSlogan + common.MatchLogLevel(csi.Spec.Scheduler.Log.Level),
 		"--certFile=",
 		"--privateKeyFile=",
-		"--metrics-address=:" + strconv.Itoa(constant.PrometheusPort),
-		"--metrics-path=/metrics",
+		"--metrics-address=:" + strconv.Itoa(int(common.MetricsPort(csi.Spec.Scheduler.Metrics))),
+		"--metrics-path=" + common.MetricsPath(csi.Spec.Scheduler.Metrics),
 		"--usenodeannotation=" + strconv.FormatBool(csi.Spec.NodeIDAnnotation),
 		"--isPatchingEnabled=" + strconv.FormatBool(isPatchingEnabled),
 	}
@@ -184,7 +180,7 @@ func createExtenderContainers(csi *csibaremetalv1.Deployment, isPatchingEnabled
 	return []corev1.Container{
 		{
 			Name:            extenderContainerName,
-			Image:           common.ConstructFullImageName(csi.Spec.Scheduler.Image, csi.Spec.GlobalRegistry),
+			Image:           common.ConstructFullImageName(csi.Spec.Scheduler.Image, csi),
 			ImagePullPolicy: corev1.PullPolicy(csi.Spec.PullPolicy),
 			Args:            args,
 			Env: []corev1.EnvVar{
@@ -197,7 +193,8 @@ func createExtenderContainers(csi *csibaremetalv1.Deployment, isPatchingEnabled
 				{Name: "LOG_FORMAT", Value: common.MatchLogFormat(csi.Spec.Scheduler.Log.Format)},
 			},
 			Ports: []corev1.ContainerPort{
-				{Name: "metrics", HostPort: constant.PrometheusPort, ContainerPort: constant.PrometheusPort, Protocol: corev1.ProtocolTCP},
+				{Name: "metrics", HostPort: common.MetricsPort(csi.Spec.Scheduler.Metrics),
+					ContainerPort: common.MetricsPort(csi.Spec.Scheduler.Metrics), Protocol: corev1.ProtocolTCP},
 				{Name: "extender", HostPort: extenderPort, ContainerPort: extenderPort, Protocol: corev1.ProtocolTCP},
 			},
 			ReadinessProbe: &corev1.Probe{
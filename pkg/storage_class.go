@@ -0,0 +1,287 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	apiV1 "github.com/dell/csi-baremetal/api/v1"
+	"github.com/dell/csi-baremetal/api/v1/drivecrd"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	defaultStorageClassName   = constant.CSIName + "-sc"
+	defaultStorageClassFSType = "xfs"
+
+	storageTypeParam   = "storageType"
+	fsTypeParam        = "fsType"
+	isPartitionedParam = "isPartitioned"
+
+	rawPartSuffix = "-raw-part"
+
+	// defaultStorageClassAnnotation marks StorageClass as the default one in cluster
+	defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+)
+
+// StorageClasses controls StorageClasses of csi-baremetal storage types, which drives are present in cluster
+type StorageClasses struct {
+	Clientset kubernetes.Interface
+	Client    client.Client
+	*logrus.Entry
+}
+
+// storageClassTemplate describes StorageClass created for discovered drive type
+type storageClassTemplate struct {
+	storageType   string
+	partitioned   bool
+	allowResizing bool
+}
+
+// Update creates StorageClasses for media types of Drive CRs, existing StorageClasses are not removed
+// if drives are gone to keep provisioning of already requested volumes
+func (s *StorageClasses) Update(ctx context.Context, csi *csibaremetalv1.Deployment) error {
+	if !isStorageClassesEnabled(csi) {
+		return nil
+	}
+
+	drives := &drivecrd.DriveList{}
+	if err := s.Client.List(ctx, drives); err != nil {
+		return err
+	}
+
+	var errMsgs []string
+	for _, template := range getStorageClassTemplates(drives.Items) {
+		if err := s.updateStorageClass(ctx, createStorageClass(csi.Spec.StorageClass, template)); err != nil {
+			errMsgs = append(errMsgs, err.Error())
+		}
+	}
+
+	if len(errMsgs) != 0 {
+		return fmt.Errorf(strings.Join(errMsgs, "\n"))
+	}
+	return nil
+}
+
+// Uninstall removes StorageClasses created by operator
+func (s *StorageClasses) Uninstall(ctx context.Context, csi *csibaremetalv1.Deployment) error {
+	if !isStorageClassesEnabled(csi) {
+		return nil
+	}
+
+	scClient := s.Clientset.StorageV1().StorageClasses()
+	classes, err := scClient.List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(common.ConstructLabelAppMap()).String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	var errMsgs []string
+	for _, class := range classes.Items {
+		if class.Provisioner != constant.CSIName {
+			continue
+		}
+		if err = scClient.Delete(ctx, class.Name, metav1.DeleteOptions{}); err != nil && !k8sError.IsNotFound(err) {
+			errMsgs = append(errMsgs, err.Error())
+		}
+	}
+
+	if len(errMsgs) != 0 {
+		return fmt.Errorf(strings.Join(errMsgs, "\n"))
+	}
+	return nil
+}
+
+// updateStorageClass creates StorageClass if not found, recreates it if immutable fields are changed
+func (s *StorageClasses) updateStorageClass(ctx context.Context, expected *storagev1.StorageClass) error {
+	scClient := s.Clientset.StorageV1().StorageClasses()
+
+	found, err := scClient.Get(ctx, expected.Name, metav1.GetOptions{})
+	if err != nil {
+		if k8sError.IsNotFound(err) {
+			if plan := common.GetPlan(ctx); plan != nil {
+				plan.Add(csibaremetalv1.PlannedCreate, "StorageClass", "", expected.Name)
+				return nil
+			}
+			if _, err = scClient.Create(ctx, expected, metav1.CreateOptions{}); err != nil {
+				s.Error(err, "Failed to create storageclass "+expected.Name)
+				return err
+			}
+			s.Info("Storageclass created successfully: " + expected.Name)
+			return nil
+		}
+		s.Error(err, "Failed to get storageclass "+expected.Name)
+		return err
+	}
+
+	if storageClassImmutableChanged(expected, found) {
+		if plan := common.GetPlan(ctx); plan != nil {
+			plan.Add(csibaremetalv1.PlannedRecreate, "StorageClass", "", expected.Name)
+			return nil
+		}
+		if err = scClient.Delete(ctx, found.Name, metav1.DeleteOptions{}); err != nil && !k8sError.IsNotFound(err) {
+			s.Error(err, "Failed to delete storageclass "+expected.Name)
+			return err
+		}
+		if _, err = scClient.Create(ctx, expected, metav1.CreateOptions{}); err != nil {
+			s.Error(err, "Failed to recreate storageclass "+expected.Name)
+			return err
+		}
+		s.Info("Storageclass recreated successfully: " + expected.Name)
+		return nil
+	}
+
+	if found.Annotations[defaultStorageClassAnnotation] == expected.Annotations[defaultStorageClassAnnotation] &&
+		equality.Semantic.DeepEqual(found.Labels, expected.Labels) &&
+		equality.Semantic.DeepEqual(found.AllowVolumeExpansion, expected.AllowVolumeExpansion) {
+		return nil
+	}
+
+	if plan := common.GetPlan(ctx); plan != nil {
+		plan.Add(csibaremetalv1.PlannedUpdate, "StorageClass", "", expected.Name)
+		return nil
+	}
+
+	if found.Annotations == nil {
+		found.Annotations = map[string]string{}
+	}
+	if value, ok := expected.Annotations[defaultStorageClassAnnotation]; ok {
+		found.Annotations[defaultStorageClassAnnotation] = value
+	} else {
+		delete(found.Annotations, defaultStorageClassAnnotation)
+	}
+	found.Labels = expected.Labels
+	found.AllowVolumeExpansion = expected.AllowVolumeExpansion
+	if _, err = scClient.Update(ctx, found, metav1.UpdateOptions{}); err != nil {
+		s.Error(err, "Failed to update storageclass "+expected.Name)
+		return err
+	}
+	s.Info("Storageclass updated successfully: " + expected.Name)
+	return nil
+}
+
+func isStorageClassesEnabled(csi *csibaremetalv1.Deployment) bool {
+	return csi.Spec.StorageClass != nil && csi.Spec.StorageClass.Enable
+}
+
+// storageClassImmutableChanged checks fields, which can't be updated in existing StorageClass
+func storageClassImmutableChanged(expected, found *storagev1.StorageClass) bool {
+	return expected.Provisioner != found.Provisioner ||
+		!equality.Semantic.DeepEqual(expected.Parameters, found.Parameters) ||
+		!equality.Semantic.DeepEqual(expected.ReclaimPolicy, found.ReclaimPolicy) ||
+		!equality.Semantic.DeepEqual(expected.VolumeBindingMode, found.VolumeBindingMode) ||
+		!equality.Semantic.DeepEqual(expected.AllowedTopologies, found.AllowedTopologies)
+}
+
+// getStorageClassTemplates returns StorageClasses for media types of drives, removed drives are skipped
+func getStorageClassTemplates(drives []drivecrd.Drive) []storageClassTemplate {
+	var (
+		types     = map[string]bool{}
+		hasSystem bool
+	)
+	for _, drive := range drives {
+		if drive.Spec.Usage == apiV1.DriveUsageRemoved {
+			continue
+		}
+		types[drive.Spec.Type] = true
+		hasSystem = hasSystem || drive.Spec.IsSystem
+	}
+
+	var templates []storageClassTemplate
+	if len(types) != 0 {
+		templates = append(templates, storageClassTemplate{storageType: apiV1.StorageClassAny})
+	}
+	if types[apiV1.DriveTypeHDD] {
+		templates = append(templates,
+			storageClassTemplate{storageType: apiV1.StorageClassHDD},
+			storageClassTemplate{storageType: apiV1.StorageClassHDDLVG, allowResizing: true})
+	}
+	if types[apiV1.DriveTypeSSD] {
+		templates = append(templates,
+			storageClassTemplate{storageType: apiV1.StorageClassSSD},
+			storageClassTemplate{storageType: apiV1.StorageClassSSDLVG, allowResizing: true})
+	}
+	if types[apiV1.DriveTypeNVMe] {
+		templates = append(templates,
+			storageClassTemplate{storageType: apiV1.StorageClassNVMe},
+			storageClassTemplate{storageType: apiV1.StorageClassNVMe, partitioned: true},
+			storageClassTemplate{storageType: apiV1.StorageClassNVMeLVG, allowResizing: true})
+	}
+	if hasSystem {
+		templates = append(templates, storageClassTemplate{storageType: apiV1.StorageClassSystemLVG, allowResizing: true})
+	}
+	return templates
+}
+
+// getStorageClassName returns <name> for ANY storage type and <name>-<type> for others
+func getStorageClassName(name string, template storageClassTemplate) string {
+	if template.storageType == apiV1.StorageClassAny {
+		return name
+	}
+	scName := name + "-" + strings.ToLower(template.storageType)
+	if template.partitioned {
+		scName += rawPartSuffix
+	}
+	return scName
+}
+
+func createStorageClass(config *components.StorageClass, template storageClassTemplate) *storagev1.StorageClass {
+	var (
+		name          = config.Name
+		fsType        = config.FSType
+		reclaimPolicy = corev1.PersistentVolumeReclaimDelete
+		bindingMode   = storagev1.VolumeBindingWaitForFirstConsumer
+	)
+	if name == "" {
+		name = defaultStorageClassName
+	}
+	if fsType == "" {
+		fsType = defaultStorageClassFSType
+	}
+	if config.ReclaimPolicy != "" {
+		reclaimPolicy = corev1.PersistentVolumeReclaimPolicy(config.ReclaimPolicy)
+	}
+
+	parameters := map[string]string{
+		storageTypeParam: template.storageType,
+		fsTypeParam:      fsType,
+	}
+	if template.partitioned {
+		parameters[isPartitionedParam] = strconv.FormatBool(true)
+	}
+
+	class := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   getStorageClassName(name, template),
+			Labels: common.ConstructLabelAppMap(),
+		},
+		Provisioner:       constant.CSIName,
+		Parameters:        parameters,
+		ReclaimPolicy:     &reclaimPolicy,
+		VolumeBindingMode: &bindingMode,
+		AllowedTopologies: config.AllowedTopologies,
+	}
+	if template.allowResizing {
+		class.AllowVolumeExpansion = ptr.To(true)
+	}
+	if !template.partitioned && config.Default != "" && strings.EqualFold(config.Default, template.storageType) {
+		class.Annotations = map[string]string{defaultStorageClassAnnotation: strconv.FormatBool(true)}
+	}
+	return class
+}
@@ -0,0 +1,110 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/dell/csi-baremetal/api/generated/v1"
+	apiV1 "github.com/dell/csi-baremetal/api/v1"
+	"github.com/dell/csi-baremetal/api/v1/drivecrd"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	v1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+var testStorageClassDeployment = &v1.Deployment{
+	ObjectMeta: metav1.ObjectMeta{Name: "test-deployment", Namespace: "test-csi"},
+	Spec: components.DeploymentSpec{
+		StorageClass: &components.StorageClass{Enable: true, Default: apiV1.StorageClassHDDLVG},
+	},
+}
+
+func Test_getStorageClassTemplates(t *testing.T) {
+	assert.Len(t, getStorageClassTemplates(nil), 0)
+
+	templates := getStorageClassTemplates([]drivecrd.Drive{
+		{Spec: api.Drive{Type: apiV1.DriveTypeHDD}},
+		{Spec: api.Drive{Type: apiV1.DriveTypeNVMe, Usage: apiV1.DriveUsageRemoved}},
+	})
+	names := make([]string, 0, len(templates))
+	for _, template := range templates {
+		names = append(names, getStorageClassName(defaultStorageClassName, template))
+	}
+	assert.Equal(t, []string{"csi-baremetal-sc", "csi-baremetal-sc-hdd", "csi-baremetal-sc-hddlvg"}, names)
+
+	templates = getStorageClassTemplates([]drivecrd.Drive{
+		{Spec: api.Drive{Type: apiV1.DriveTypeNVMe, IsSystem: true}},
+	})
+	names = names[:0]
+	for _, template := range templates {
+		names = append(names, getStorageClassName("sc", template))
+	}
+	assert.Equal(t, []string{"sc", "sc-nvme", "sc-nvme-raw-part", "sc-nvmelvg", "sc-syslvg"}, names)
+}
+
+func Test_StorageClassesUpdate(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		deployment = testStorageClassDeployment.DeepCopy()
+		drive      = &drivecrd.Drive{
+			ObjectMeta: metav1.ObjectMeta{Name: "drive1"},
+			Spec:       api.Drive{Type: apiV1.DriveTypeHDD},
+		}
+	)
+
+	scheme, _ := common.PrepareScheme()
+	clientSet := fake.NewSimpleClientset()
+	s := &StorageClasses{
+		Clientset: clientSet,
+		Client:    prepareSnapshotClient(scheme, drive),
+		Entry:     logrus.New().WithField(constant.CSIName, "storageClasses"),
+	}
+
+	err := s.Update(ctx, deployment)
+	assert.Nil(t, err)
+
+	classes, err := clientSet.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, classes.Items, 3)
+
+	hddlvg, err := clientSet.StorageV1().StorageClasses().Get(ctx, "csi-baremetal-sc-hddlvg", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "true", hddlvg.Annotations[defaultStorageClassAnnotation])
+	assert.Equal(t, apiV1.StorageClassHDDLVG, hddlvg.Parameters[storageTypeParam])
+	assert.Equal(t, defaultStorageClassFSType, hddlvg.Parameters[fsTypeParam])
+	assert.True(t, *hddlvg.AllowVolumeExpansion)
+
+	// default class and parameters are changed
+	deployment.Spec.StorageClass = &components.StorageClass{
+		Enable:        true,
+		Default:       apiV1.StorageClassAny,
+		FSType:        "ext4",
+		ReclaimPolicy: string(corev1.PersistentVolumeReclaimRetain),
+	}
+	err = s.Update(ctx, deployment)
+	assert.Nil(t, err)
+
+	hddlvg, err = clientSet.StorageV1().StorageClasses().Get(ctx, "csi-baremetal-sc-hddlvg", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Empty(t, hddlvg.Annotations[defaultStorageClassAnnotation])
+	assert.Equal(t, "ext4", hddlvg.Parameters[fsTypeParam])
+	assert.Equal(t, corev1.PersistentVolumeReclaimRetain, *hddlvg.ReclaimPolicy)
+
+	anyClass, err := clientSet.StorageV1().StorageClasses().Get(ctx, "csi-baremetal-sc", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "true", anyClass.Annotations[defaultStorageClassAnnotation])
+
+	// operator StorageClasses are removed on uninstall
+	err = s.Uninstall(ctx, deployment)
+	assert.Nil(t, err)
+	classes, err = clientSet.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, classes.Items, 0)
+}
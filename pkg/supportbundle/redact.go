@@ -0,0 +1,76 @@
+package supportbundle
+
+import (
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	redacted = "<redacted>"
+	// lastAppliedAnnotation contains the whole object applied by kubectl, including data of Secrets
+	lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+// sensitiveEnv matches names of environment variables, which values are credentials
+var sensitiveEnv = regexp.MustCompile(`(?i)(password|passwd|token|secret|credential|key)`)
+
+// redactSecret keeps keys of Secret and replaces their values
+func redactSecret(secret *corev1.Secret) {
+	for key := range secret.Data {
+		secret.Data[key] = []byte(redacted)
+	}
+	for key := range secret.StringData {
+		secret.StringData[key] = redacted
+	}
+	delete(secret.Annotations, lastAppliedAnnotation)
+}
+
+// redactPodSpec replaces values of environment variables, which names look like credentials
+func redactPodSpec(spec *corev1.PodSpec) {
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			for j := range containers[i].Env {
+				env := &containers[i].Env[j]
+				if env.Value != "" && sensitiveEnv.MatchString(env.Name) {
+					env.Value = redacted
+				}
+			}
+		}
+	}
+}
+
+// getSecretRefs returns names of Secrets used by pod
+func getSecretRefs(spec *corev1.PodSpec) map[string]bool {
+	refs := map[string]bool{}
+	for _, secret := range spec.ImagePullSecrets {
+		refs[secret.Name] = true
+	}
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil {
+			refs[volume.Secret.SecretName] = true
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil {
+					refs[source.Secret.Name] = true
+				}
+			}
+		}
+	}
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for _, container := range containers {
+			for _, envFrom := range container.EnvFrom {
+				if envFrom.SecretRef != nil {
+					refs[envFrom.SecretRef.Name] = true
+				}
+			}
+			for _, env := range container.Env {
+				if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+					refs[env.ValueFrom.SecretKeyRef.Name] = true
+				}
+			}
+		}
+	}
+	return refs
+}
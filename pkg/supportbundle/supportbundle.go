@@ -0,0 +1,445 @@
+package supportbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
+
+	acrcrd "github.com/dell/csi-baremetal/api/v1/acreservationcrd"
+	accrd "github.com/dell/csi-baremetal/api/v1/availablecapacitycrd"
+	"github.com/dell/csi-baremetal/api/v1/drivecrd"
+	"github.com/dell/csi-baremetal/api/v1/lvgcrd"
+	"github.com/dell/csi-baremetal/api/v1/nodecrd"
+	"github.com/dell/csi-baremetal/api/v1/volumecrd"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/pkg/acrvalidator"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+	"github.com/dell/csi-baremetal-operator/pkg/crconsistency"
+	"github.com/dell/csi-baremetal-operator/pkg/logreceiver"
+	"github.com/dell/csi-baremetal-operator/pkg/node"
+	"github.com/dell/csi-baremetal-operator/pkg/patcher"
+)
+
+const (
+	// DefaultLogLines limits number of collected log lines of each container
+	DefaultLogLines = 1000
+
+	// bundleDir is a root directory of files in archive
+	bundleDir = "csi-baremetal-support-bundle"
+)
+
+// Collector gathers csi Deployments, csi-baremetal CRs, configuration, events and logs of components
+// into tar.gz archive. Values of Secrets and credentials in environment of containers are redacted
+type Collector struct {
+	Clientset kubernetes.Interface
+	Client    client.Client
+	Scheme    *runtime.Scheme
+	// Namespaces are collected in addition to namespaces of csi Deployments
+	Namespaces []string
+	// LogLines limits number of collected log lines of each container, all lines are collected if it's 0
+	LogLines int64
+	*logrus.Entry
+}
+
+// bundle writes files to archive, the first write error is kept and returned by Collect
+type bundle struct {
+	tw  *tar.Writer
+	now time.Time
+	err error
+	// failures are errors of getting objects and logs, they don't stop collection
+	failures []string
+}
+
+// Collect writes support bundle to out
+func (c *Collector) Collect(ctx context.Context, out io.Writer) error {
+	gz := gzip.NewWriter(out)
+	b := &bundle{tw: tar.NewWriter(gz), now: time.Now().UTC()}
+	state := &crconsistency.State{}
+
+	deployments := &csibaremetalv1.DeploymentList{}
+	c.collectList(ctx, b, "csi-baremetal/deployments.yaml", deployments)
+	state.CSIBMNodes = c.collectList(ctx, b, "csi-baremetal/csibmnodes.yaml", &nodecrd.NodeList{}).(*nodecrd.NodeList).Items
+	state.Drives = c.collectList(ctx, b, "csi-baremetal/drives.yaml", &drivecrd.DriveList{}).(*drivecrd.DriveList).Items
+	state.ACs = c.collectList(ctx, b, "csi-baremetal/availablecapacities.yaml",
+		&accrd.AvailableCapacityList{}).(*accrd.AvailableCapacityList).Items
+	state.ACRs = c.collectList(ctx, b, "csi-baremetal/availablecapacityreservations.yaml",
+		&acrcrd.AvailableCapacityReservationList{}).(*acrcrd.AvailableCapacityReservationList).Items
+	state.LVGs = c.collectList(ctx, b, "csi-baremetal/logicalvolumegroups.yaml",
+		&lvgcrd.LogicalVolumeGroupList{}).(*lvgcrd.LogicalVolumeGroupList).Items
+	state.Volumes = c.collectList(ctx, b, "csi-baremetal/volumes.yaml", &volumecrd.VolumeList{}).(*volumecrd.VolumeList).Items
+
+	if nodes, err := c.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); err != nil {
+		b.fail("nodes", err)
+	} else {
+		state.Nodes = nodes.Items
+		c.addObjects(b, "cluster/nodes.yaml", toObjects(nodes))
+	}
+	if pvs, err := c.Clientset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{}); err != nil {
+		b.fail("persistentvolumes", err)
+	} else {
+		state.PVs = pvs.Items
+		c.addObjects(b, "cluster/persistentvolumes.yaml", toObjects(pvs))
+	}
+	c.collectStorageClasses(ctx, b)
+
+	namespaces := c.getNamespaces(deployments)
+	c.collectConfigMaps(ctx, b, deployments)
+	for _, namespace := range namespaces {
+		c.collectNamespace(ctx, b, namespace, deployments)
+	}
+	c.collectEvents(ctx, b, namespaces)
+
+	state.MissingPods = c.getMissingACRPods(ctx, b, state.ACRs)
+	c.addFile(b, "summary.txt", []byte(c.summarize(b, deployments, state)))
+	if len(b.failures) != 0 {
+		c.addFile(b, "errors.txt", []byte(strings.Join(b.failures, "\n")+"\n"))
+	}
+
+	if err := b.tw.Close(); err != nil && b.err == nil {
+		b.err = err
+	}
+	if err := gz.Close(); err != nil && b.err == nil {
+		b.err = err
+	}
+	return b.err
+}
+
+// collectList lists objects of the list type and writes them as List, returns the list
+func (c *Collector) collectList(ctx context.Context, b *bundle, name string, list client.ObjectList) client.ObjectList {
+	if err := c.Client.List(ctx, list); err != nil {
+		b.fail(name, err)
+		return list
+	}
+
+	objects, err := meta.ExtractList(list)
+	if err != nil {
+		b.fail(name, err)
+		return list
+	}
+	c.addObjects(b, name, objects)
+	return list
+}
+
+// collectStorageClasses writes StorageClasses provisioned by csi-baremetal
+func (c *Collector) collectStorageClasses(ctx context.Context, b *bundle) {
+	classes, err := c.Clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		b.fail("storageclasses", err)
+		return
+	}
+
+	var objects []runtime.Object
+	for i := range classes.Items {
+		if classes.Items[i].Provisioner == constant.CSIName {
+			objects = append(objects, &classes.Items[i])
+		}
+	}
+	c.addObjects(b, "cluster/storageclasses.yaml", objects)
+}
+
+// collectConfigMaps writes ConfigMaps of node pods, log receiver and kube-scheduler configuration
+func (c *Collector) collectConfigMaps(ctx context.Context, b *bundle, deployments *csibaremetalv1.DeploymentList) {
+	var refs []types.NamespacedName
+	for i := range deployments.Items {
+		csi := &deployments.Items[i]
+		for _, name := range append(node.ConfigMapNames(), logreceiver.ConfigMapName) {
+			refs = append(refs, types.NamespacedName{Namespace: csi.GetNamespace(), Name: name})
+		}
+		refs = append(refs, patcher.SchedulerConfigMaps(csi)...)
+	}
+
+	byNamespace := map[string][]runtime.Object{}
+	seen := map[types.NamespacedName]bool{}
+	for _, ref := range refs {
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+
+		cm, err := c.Clientset.CoreV1().ConfigMaps(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			if !k8sError.IsNotFound(err) {
+				b.fail("configmap "+ref.String(), err)
+			}
+			continue
+		}
+		byNamespace[ref.Namespace] = append(byNamespace[ref.Namespace], cm)
+	}
+
+	for namespace, objects := range byNamespace {
+		c.addObjects(b, path.Join("namespaces", namespace, "configmaps.yaml"), objects)
+	}
+}
+
+// collectNamespace writes workloads, pods, their logs, referenced Secrets and all Events of the namespace
+func (c *Collector) collectNamespace(ctx context.Context, b *bundle, namespace string, deployments *csibaremetalv1.DeploymentList) {
+	dir := path.Join("namespaces", namespace)
+	selector := labels.SelectorFromSet(map[string]string{constant.AppLabelKey: constant.AppLabelValue}).String()
+
+	if daemonSets, err := c.Clientset.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector}); err != nil {
+		b.fail("daemonsets in "+namespace, err)
+	} else {
+		for i := range daemonSets.Items {
+			redactPodSpec(&daemonSets.Items[i].Spec.Template.Spec)
+		}
+		c.addObjects(b, path.Join(dir, "daemonsets.yaml"), toObjects(daemonSets))
+	}
+
+	if workloads, err := c.Clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector}); err != nil {
+		b.fail("deployments in "+namespace, err)
+	} else {
+		for i := range workloads.Items {
+			redactPodSpec(&workloads.Items[i].Spec.Template.Spec)
+		}
+		c.addObjects(b, path.Join(dir, "deployments.yaml"), toObjects(workloads))
+	}
+
+	secrets := map[string]bool{}
+	for _, csi := range deployments.Items {
+		if csi.GetNamespace() == namespace && csi.Spec.RegistrySecret != "" {
+			secrets[csi.Spec.RegistrySecret] = true
+		}
+	}
+
+	pods, err := c.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		b.fail("pods in "+namespace, err)
+	} else {
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			for name := range getSecretRefs(&pod.Spec) {
+				secrets[name] = true
+			}
+			c.collectLogs(ctx, b, path.Join(dir, "logs"), pod)
+			redactPodSpec(&pod.Spec)
+		}
+		c.addObjects(b, path.Join(dir, "pods.yaml"), toObjects(pods))
+	}
+
+	var secretObjects []runtime.Object
+	for _, name := range sortedKeys(secrets) {
+		secret, err := c.Clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if !k8sError.IsNotFound(err) {
+				b.fail("secret "+namespace+"/"+name, err)
+			}
+			continue
+		}
+		redactSecret(secret)
+		secretObjects = append(secretObjects, secret)
+	}
+	c.addObjects(b, path.Join(dir, "secrets.yaml"), secretObjects)
+
+	if events, err := c.Clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{}); err != nil {
+		b.fail("events in "+namespace, err)
+	} else {
+		c.addObjects(b, path.Join(dir, "events.yaml"), toObjects(events))
+	}
+}
+
+// collectLogs writes logs of all containers of the pod, logs of the previous run are written for restarted containers
+func (c *Collector) collectLogs(ctx context.Context, b *bundle, dir string, pod *corev1.Pod) {
+	restarted := map[string]bool{}
+	for _, status := range append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...),
+		pod.Status.ContainerStatuses...) {
+		restarted[status.Name] = status.RestartCount > 0
+	}
+
+	for _, container := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		previousRuns := []bool{false}
+		if restarted[container.Name] {
+			previousRuns = append(previousRuns, true)
+		}
+		for _, previous := range previousRuns {
+			opts := &corev1.PodLogOptions{Container: container.Name, Previous: previous}
+			if c.LogLines > 0 {
+				opts.TailLines = &c.LogLines
+			}
+			name := container.Name + ".log"
+			if previous {
+				name = container.Name + ".previous.log"
+			}
+
+			logs, err := c.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).DoRaw(ctx)
+			if err != nil {
+				b.fail("logs of "+pod.Namespace+"/"+pod.Name+"/"+name, err)
+				continue
+			}
+			c.addFile(b, path.Join(dir, pod.Name, name), logs)
+		}
+	}
+}
+
+// collectEvents writes Events of csi-baremetal objects and components from namespaces, which are not collected
+func (c *Collector) collectEvents(ctx context.Context, b *bundle, namespaces []string) {
+	events, err := c.Clientset.CoreV1().Events("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		b.fail("events", err)
+		return
+	}
+
+	collected := map[string]bool{}
+	for _, namespace := range namespaces {
+		collected[namespace] = true
+	}
+	var objects []runtime.Object
+	for i := range events.Items {
+		if !collected[events.Items[i].Namespace] && isRelatedEvent(&events.Items[i]) {
+			objects = append(objects, &events.Items[i])
+		}
+	}
+	c.addObjects(b, "cluster/events.yaml", objects)
+}
+
+// getMissingACRPods returns <namespace>/<name> of pods, which ACRs are created for, but which are not found
+func (c *Collector) getMissingACRPods(ctx context.Context, b *bundle, acrs []acrcrd.AvailableCapacityReservation) map[string]bool {
+	missing := map[string]bool{}
+	for i := range acrs {
+		namespace, name := acrvalidator.GetPodName(&acrs[i])
+		_, err := c.Clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case k8sError.IsNotFound(err):
+			missing[namespace+"/"+name] = true
+		case err != nil:
+			b.fail("pod "+namespace+"/"+name, err)
+		}
+	}
+	return missing
+}
+
+// getNamespaces returns namespaces of csi Deployments and namespaces passed to Collector
+func (c *Collector) getNamespaces(deployments *csibaremetalv1.DeploymentList) []string {
+	namespaces := map[string]bool{}
+	for _, csi := range deployments.Items {
+		namespaces[csi.GetNamespace()] = true
+	}
+	for _, namespace := range c.Namespaces {
+		if namespace != "" {
+			namespaces[namespace] = true
+		}
+	}
+	return sortedKeys(namespaces)
+}
+
+// summarize returns numbers of collected objects and found inconsistencies
+func (c *Collector) summarize(b *bundle, deployments *csibaremetalv1.DeploymentList, state *crconsistency.State) string {
+	summary := &strings.Builder{}
+	fmt.Fprintf(summary, "csi-baremetal support bundle collected at %s\n\n", b.now.Format(time.RFC3339))
+	fmt.Fprintf(summary, "csi Deployments: %d\nNodes: %d\ncsibmnodes: %d\nDrives: %d\nAvailableCapacities: %d\n"+
+		"AvailableCapacityReservations: %d\nLogicalVolumeGroups: %d\nVolumes: %d\n\n",
+		len(deployments.Items), len(state.Nodes), len(state.CSIBMNodes), len(state.Drives), len(state.ACs),
+		len(state.ACRs), len(state.LVGs), len(state.Volumes))
+
+	violations := crconsistency.FindViolations(state)
+	if len(violations) == 0 {
+		summary.WriteString("No inconsistencies found\n")
+	} else {
+		summary.WriteString("Inconsistencies:\n")
+		for _, v := range violations {
+			summary.WriteString("- " + v.Message + "\n")
+		}
+	}
+
+	if len(b.failures) != 0 {
+		fmt.Fprintf(summary, "\n%d objects or logs were not collected, see errors.txt\n", len(b.failures))
+	}
+	return summary.String()
+}
+
+// addObjects writes objects as List, GVK is set for each item and managed fields are removed
+func (c *Collector) addObjects(b *bundle, name string, objects []runtime.Object) {
+	items := make([]interface{}, 0, len(objects))
+	for _, obj := range objects {
+		gvk, err := apiutil.GVKForObject(obj, c.Scheme)
+		if err != nil {
+			b.fail(name, err)
+			return
+		}
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			b.fail(name, err)
+			return
+		}
+		content["apiVersion"], content["kind"] = gvk.GroupVersion().String(), gvk.Kind
+		if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+			delete(metadata, "managedFields")
+		}
+		items = append(items, content)
+	}
+
+	data, err := yaml.Marshal(map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": items})
+	if err != nil {
+		b.fail(name, err)
+		return
+	}
+	c.addFile(b, name, data)
+}
+
+// addFile writes file to archive, nothing is written after the first error
+func (c *Collector) addFile(b *bundle, name string, data []byte) {
+	if b.err != nil {
+		return
+	}
+
+	header := &tar.Header{
+		Name:    path.Join(bundleDir, name),
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: b.now,
+	}
+	if b.err = b.tw.WriteHeader(header); b.err != nil {
+		return
+	}
+	_, b.err = b.tw.Write(data)
+}
+
+// fail records error of collecting, which is reported in errors.txt
+func (b *bundle) fail(what string, err error) {
+	b.failures = append(b.failures, fmt.Sprintf("%s: %s", what, err.Error()))
+}
+
+// toObjects returns items of the list
+func toObjects(list runtime.Object) []runtime.Object {
+	objects, err := meta.ExtractList(list)
+	if err != nil {
+		return nil
+	}
+	return objects
+}
+
+// isRelatedEvent checks if Event is reported for csi-baremetal object or by csi-baremetal component
+func isRelatedEvent(event *corev1.Event) bool {
+	return strings.HasPrefix(event.InvolvedObject.APIVersion, csibaremetalv1.GroupVersion.Group) ||
+		strings.Contains(event.Source.Component, constant.CSIName) ||
+		strings.Contains(event.ReportingController, constant.CSIName) ||
+		strings.Contains(event.Message, constant.CSIName)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,164 @@
+package supportbundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	api "github.com/dell/csi-baremetal/api/generated/v1"
+	acrcrd "github.com/dell/csi-baremetal/api/v1/acreservationcrd"
+	"github.com/dell/csi-baremetal/api/v1/drivecrd"
+	"github.com/dell/csi-baremetal/api/v1/nodecrd"
+	"github.com/dell/csi-baremetal/api/v1/volumecrd"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	csibaremetalv1 "github.com/dell/csi-baremetal-operator/api/v1"
+	"github.com/dell/csi-baremetal-operator/api/v1/components"
+	"github.com/dell/csi-baremetal-operator/pkg/common"
+	"github.com/dell/csi-baremetal-operator/pkg/constant"
+)
+
+const (
+	testNamespace = "csi"
+	testPassword  = "password-value"
+)
+
+// readBundle returns content of archive files by their names relative to bundle directory
+func readBundle(t *testing.T, data []byte) map[string]string {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	assert.Nil(t, err)
+	tr := tar.NewReader(gz)
+
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		content, err := io.ReadAll(tr)
+		assert.Nil(t, err)
+		files[strings.TrimPrefix(header.Name, bundleDir+"/")] = string(content)
+	}
+	return files
+}
+
+func prepareCollector(t *testing.T) *Collector {
+	scheme, err := common.PrepareScheme()
+	assert.Nil(t, err)
+
+	clientSet := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "csi-baremetal-node-abc", Namespace: testNamespace,
+				Labels: map[string]string{constant.AppLabelKey: constant.AppLabelValue}},
+			Spec: corev1.PodSpec{
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry-secret"}},
+				Containers: []corev1.Container{{Name: "node", Env: []corev1.EnvVar{
+					{Name: "DB_PASSWORD", Value: testPassword},
+					{Name: "LOG_FORMAT", Value: "text"},
+				}}},
+			},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: "node", RestartCount: 1}}},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "registry-secret", Namespace: testNamespace},
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(testPassword)},
+		},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: testNamespace}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "extender-readiness", Namespace: testNamespace}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: testNamespace}},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "pvc-event", Namespace: "app"},
+			InvolvedObject: corev1.ObjectReference{Kind: "PersistentVolumeClaim", Name: "data"},
+			Message:        "waiting for a volume to be created by external provisioner \"csi-baremetal\"",
+		},
+		&corev1.Event{ObjectMeta: metav1.ObjectMeta{Name: "unrelated-event", Namespace: "app"}, Message: "pulled"},
+	)
+
+	cl := fakeClient.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&csibaremetalv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "csi-baremetal", Namespace: testNamespace},
+			Spec:       components.DeploymentSpec{Platform: constant.PlatformVanilla},
+		},
+		&nodecrd.Node{ObjectMeta: metav1.ObjectMeta{Name: "csibmnode-1"},
+			Spec: api.Node{UUID: "uuid-1", Addresses: map[string]string{"Hostname": "node-1"}}},
+		&nodecrd.Node{ObjectMeta: metav1.ObjectMeta{Name: "csibmnode-2"},
+			Spec: api.Node{UUID: "uuid-2", Addresses: map[string]string{"Hostname": "node-2"}}},
+		&drivecrd.Drive{ObjectMeta: metav1.ObjectMeta{Name: "drive-1"}, Spec: api.Drive{UUID: "drive-1", NodeId: "uuid-1"}},
+		&volumecrd.Volume{ObjectMeta: metav1.ObjectMeta{Name: "pvc-1", Namespace: "app"},
+			Spec: api.Volume{Id: "pvc-1", NodeId: "uuid-3", Location: "drive-1"}},
+		&acrcrd.AvailableCapacityReservation{ObjectMeta: metav1.ObjectMeta{Name: "app-web-0"},
+			Spec: api.AvailableCapacityReservation{Namespace: "app"}},
+	).Build()
+
+	return &Collector{
+		Clientset: clientSet,
+		Client:    cl,
+		Scheme:    scheme,
+		LogLines:  DefaultLogLines,
+		Entry:     logrus.WithField("Test name", "SupportBundleTest"),
+	}
+}
+
+func Test_Collect(t *testing.T) {
+	out := &bytes.Buffer{}
+	assert.Nil(t, prepareCollector(t).Collect(context.Background(), out))
+	files := readBundle(t, out.Bytes())
+
+	for _, name := range []string{
+		"csi-baremetal/deployments.yaml",
+		"csi-baremetal/csibmnodes.yaml",
+		"csi-baremetal/drives.yaml",
+		"csi-baremetal/availablecapacities.yaml",
+		"csi-baremetal/availablecapacityreservations.yaml",
+		"csi-baremetal/logicalvolumegroups.yaml",
+		"csi-baremetal/volumes.yaml",
+		"cluster/nodes.yaml",
+		"cluster/persistentvolumes.yaml",
+		"cluster/events.yaml",
+		"namespaces/csi/pods.yaml",
+		"namespaces/csi/configmaps.yaml",
+		"namespaces/csi/secrets.yaml",
+		"namespaces/csi/events.yaml",
+		"namespaces/csi/logs/csi-baremetal-node-abc/node.log",
+		"namespaces/csi/logs/csi-baremetal-node-abc/node.previous.log",
+		"summary.txt",
+	} {
+		assert.Contains(t, files, name)
+	}
+	assert.Contains(t, files["csi-baremetal/deployments.yaml"], "kind: Deployment")
+
+	t.Run("Only related objects are collected", func(t *testing.T) {
+		assert.Contains(t, files["namespaces/csi/configmaps.yaml"], "extender-readiness")
+		assert.NotContains(t, files["namespaces/csi/configmaps.yaml"], "unrelated")
+		assert.Contains(t, files["namespaces/csi/secrets.yaml"], "registry-secret")
+		assert.NotContains(t, files["namespaces/csi/secrets.yaml"], "unrelated")
+		assert.Contains(t, files["cluster/events.yaml"], "pvc-event")
+		assert.NotContains(t, files["cluster/events.yaml"], "unrelated-event")
+	})
+
+	t.Run("Secrets are redacted", func(t *testing.T) {
+		for name, content := range files {
+			assert.NotContains(t, content, testPassword, name)
+		}
+		assert.Contains(t, files["namespaces/csi/pods.yaml"], "value: text")
+	})
+
+	t.Run("Inconsistencies are summarized", func(t *testing.T) {
+		summary := files["summary.txt"]
+		assert.Contains(t, summary, "csibmnode csibmnode-2 refers to missing Kubernetes node \"node-2\"")
+		assert.Contains(t, summary, "Volume app/pvc-1 is on missing csibmnode uuid-3")
+		assert.Contains(t, summary, "AvailableCapacityReservation app-web-0 has no pod app/web-0")
+		assert.NotContains(t, summary, "Drive drive-1")
+	})
+}
@@ -9,4 +9,5 @@ import (
 // rbacValidator is a private interface for rbac validator
 type rbacValidator interface {
 	ValidateServiceAccountIsBound(ctx context.Context, rules *models.ServiceAccountIsRoleBoundData) error
+	ValidateServiceAccountIsClusterBound(ctx context.Context, rules *models.ServiceAccountIsClusterRoleBoundData) error
 }
@@ -6,6 +6,9 @@ type Rule string
 // ServiceAccountIsRoleBound is a type for checking whether service account is bounded to certain role or policy rules
 var ServiceAccountIsRoleBound Rule
 
+// ServiceAccountIsClusterRoleBound is a type for checking whether service account is bounded to certain cluster role or policy rules
+var ServiceAccountIsClusterRoleBound Rule = "ServiceAccountIsClusterRoleBound"
+
 // RBACRules is a bundle of data, needed to check rbac rules
 type RBACRules struct {
 	Data interface{}
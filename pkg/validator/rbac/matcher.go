@@ -11,6 +11,8 @@ type Matcher interface {
 	MatchRoleBindingsSubjects(roleBindings []rbacv1.RoleBinding, subjectName, namespace string) (matchesRoleBindings []rbacv1.RoleBinding)
 	MatchRoleBindingSubjects(roleBinding *rbacv1.RoleBinding, subjectName, namespace string) (matches bool)
 	MatchRoles(roles []rbacv1.Role, names []string) (matchesRoles []rbacv1.Role)
+	MatchClusterRoleBindingsSubjects(clusterRoleBindings []rbacv1.ClusterRoleBinding, subjectName, namespace string) (matchesClusterRoleBindings []rbacv1.ClusterRoleBinding)
+	MatchClusterRoles(clusterRoles []rbacv1.ClusterRole, names []string) (matchesClusterRoles []rbacv1.ClusterRole)
 }
 
 type matcher struct{}
@@ -112,6 +114,34 @@ func (m *matcher) MatchRoles(roles []rbacv1.Role, names []string) (matchesRoles
 	return
 }
 
+func (m *matcher) MatchClusterRoleBindingsSubjects(
+	clusterRoleBindings []rbacv1.ClusterRoleBinding, subjectName, namespace string,
+) (matchesClusterRoleBindings []rbacv1.ClusterRoleBinding) {
+	for i := 0; i < len(clusterRoleBindings); i++ {
+		for _, subject := range clusterRoleBindings[i].Subjects {
+			if subject.Kind == rbacv1.ServiceAccountKind && subject.Name == subjectName && subject.Namespace == namespace {
+				matchesClusterRoleBindings = append(matchesClusterRoleBindings, clusterRoleBindings[i])
+				break
+			}
+		}
+	}
+	return matchesClusterRoleBindings
+}
+
+func (m *matcher) MatchClusterRoles(clusterRoles []rbacv1.ClusterRole, names []string) (matchesClusterRoles []rbacv1.ClusterRole) {
+	preparedNames := make(map[string]struct{})
+	for i := 0; i < len(names); i++ {
+		preparedNames[names[i]] = struct{}{}
+	}
+
+	for i := 0; i < len(clusterRoles); i++ {
+		if _, ok := preparedNames[clusterRoles[i].Name]; ok {
+			matchesClusterRoles = append(matchesClusterRoles, clusterRoles[i])
+		}
+	}
+	return
+}
+
 // NewMatcher is a constructor for matcher
 func NewMatcher() Matcher {
 	return &matcher{}
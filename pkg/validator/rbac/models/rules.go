@@ -9,3 +9,11 @@ type ServiceAccountIsRoleBoundData struct {
 	ServiceAccountName string
 	Namespace          string
 }
+
+// ServiceAccountIsClusterRoleBoundData is bundle of data, needed for checking whether service account is bounded
+// to cluster roles with certain policy rules
+type ServiceAccountIsClusterRoleBoundData struct {
+	ClusterRole        *v1.ClusterRole
+	ServiceAccountName string
+	Namespace          string
+}
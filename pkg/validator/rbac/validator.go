@@ -14,6 +14,7 @@ import (
 // Validator is rbac validator for checking predefined rule (e.g. service account is bound to role with certain policy rules)
 type Validator interface {
 	ValidateServiceAccountIsBound(ctx context.Context, rules *models.ServiceAccountIsRoleBoundData) error
+	ValidateServiceAccountIsClusterBound(ctx context.Context, rules *models.ServiceAccountIsClusterRoleBoundData) error
 }
 
 type rbac struct {
@@ -75,6 +76,55 @@ func (r *rbac) ValidateServiceAccountIsBound(ctx context.Context, rules *models.
 		"service account: '%s', namespace: '%s'", rules.ServiceAccountName, rules.Namespace))
 }
 
+func (r *rbac) ValidateServiceAccountIsClusterBound(ctx context.Context, rules *models.ServiceAccountIsClusterRoleBoundData) (err error) {
+	// obtaining cluster role bindings
+	clusterRoleBindings := rbacv1.ClusterRoleBindingList{}
+	if err = r.client.List(ctx, &clusterRoleBindings); err != nil {
+		r.log.Errorf("failed to get cluster role bindings list: %s", err.Error())
+		return err
+	}
+
+	// check if there exists cluster role bindings, which matches passed service account
+	matchesClusterRoleBindings := r.matcher.MatchClusterRoleBindingsSubjects(clusterRoleBindings.Items,
+		rules.ServiceAccountName, rules.Namespace)
+	if len(matchesClusterRoleBindings) == 0 {
+		return NewRBACError(fmt.Sprintf("service account not matched to cluster roles, service account: '%s', namespace: '%s'",
+			rules.ServiceAccountName, rules.Namespace))
+	}
+
+	// obtaining cluster roles
+	clusterRoles := rbacv1.ClusterRoleList{}
+	if err = r.client.List(ctx, &clusterRoles); err != nil {
+		r.log.Errorf("failed to get cluster roles list: %s", err.Error())
+		return err
+	}
+
+	// preparing founded cluster role bindings refs and finding matched ones between them
+	matchesClusterRoleBindingsRefs := make([]string, len(matchesClusterRoleBindings))
+	for i := 0; i < len(matchesClusterRoleBindings); i++ {
+		matchesClusterRoleBindingsRefs[i] = matchesClusterRoleBindings[i].RoleRef.Name
+	}
+	matchesClusterRoles := r.matcher.MatchClusterRoles(clusterRoles.Items, matchesClusterRoleBindingsRefs)
+	if len(matchesClusterRoles) == 0 {
+		return NewRBACError(fmt.Sprintf("cluster roles not matched, service account: '%s', namespace: '%s'",
+			rules.ServiceAccountName, rules.Namespace))
+	}
+
+	// requested policies may be split between several cluster roles and rules,
+	// so each of them is matched separately
+	var actualRules []rbacv1.PolicyRule
+	for i := 0; i < len(matchesClusterRoles); i++ {
+		actualRules = append(actualRules, matchesClusterRoles[i].Rules...)
+	}
+	for i := 0; i < len(rules.ClusterRole.Rules); i++ {
+		if !r.matcher.MatchPolicyRules(actualRules, rules.ClusterRole.Rules[i:i+1]) {
+			return NewRBACError(fmt.Sprintf("policy rule %v is not found in cluster roles, "+
+				"service account: '%s', namespace: '%s'", rules.ClusterRole.Rules[i], rules.ServiceAccountName, rules.Namespace))
+		}
+	}
+	return nil
+}
+
 // NewValidator is a constructor for rbac validator
 func NewValidator(client client.Client, log *logrus.Entry, matcher Matcher) Validator {
 	return &rbac{
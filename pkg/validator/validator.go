@@ -25,6 +25,12 @@ func (v *validator) ValidateRBAC(ctx context.Context, rules *models.RBACRules) (
 			return fmt.Errorf("unknown data for service account is role bound validation")
 		}
 		return v.ValidateServiceAccountIsBound(ctx, adaptedRules)
+	case models.ServiceAccountIsClusterRoleBound:
+		adaptedRules, ok := rules.Data.(*rbacmodels.ServiceAccountIsClusterRoleBoundData)
+		if !ok {
+			return fmt.Errorf("unknown data for service account is cluster role bound validation")
+		}
+		return v.ValidateServiceAccountIsClusterBound(ctx, adaptedRules)
 	default:
 		return fmt.Errorf("unknown validation rule type, %s", rules.Type)
 	}
@@ -25,6 +25,10 @@ BLD_SHA          := $(shell echo ${BUILD_REL_B})
 RELEASE_STR      := ${BLD_CNT}.${BLD_SHA}
 FULL_VERSION     := ${PRODUCT_VERSION}-${RELEASE_STR}
 TAG              := ${FULL_VERSION}
+
+# operator version is used to check compatibility of csi-baremetal components on upgrade
+LDFLAGS          := -X github.com/dell/csi-baremetal-operator/pkg/compatibility.OperatorVersion=${PRODUCT_VERSION}
+
 BRANCH           := $(shell git rev-parse --abbrev-ref HEAD)
 
 ### go env vars